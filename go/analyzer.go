@@ -0,0 +1,126 @@
+package wid
+
+import (
+	"fmt"
+	"time"
+)
+
+// FindingKind classifies an anomaly reported by StreamAnalyzer.
+type FindingKind string
+
+const (
+	FindingInvalid    FindingKind = "invalid"
+	FindingGap        FindingKind = "gap"
+	FindingBurst      FindingKind = "burst"
+	FindingRegression FindingKind = "regression"
+	FindingNodeFlap   FindingKind = "node_flap"
+)
+
+// Finding is one anomaly detected in a stream of IDs.
+type Finding struct {
+	Kind   FindingKind
+	ID     string
+	Prev   string
+	At     time.Time
+	Detail string
+}
+
+// StreamAnalyzer consumes IDs in arrival order and reports gaps longer than
+// GapThreshold, ticks holding more IDs than W digits allow, timestamps or
+// counters that move backwards, and HLC nodes that flap back to a node seen
+// within FlapWindow. Parameters are inferred per ID from its shape. A
+// StreamAnalyzer is not safe for concurrent use.
+type StreamAnalyzer struct {
+	// GapThreshold is the largest silence between consecutive IDs that is not
+	// reported; zero disables gap detection.
+	GapThreshold time.Duration
+	// FlapWindow bounds how recently a node must have been left for a switch
+	// back to it to count as flapping; zero disables flap detection.
+	FlapWindow time.Duration
+
+	count    int
+	invalid  int
+	findings []Finding
+
+	prev        string
+	prevTime    time.Time
+	prevCounter int
+	prevNode    string
+	tickCount   int
+	burstNoted  bool
+	nodeLeft    map[string]time.Time
+}
+
+// NewStreamAnalyzer returns an analyzer reporting gaps longer than gap and
+// node flaps within one minute.
+func NewStreamAnalyzer(gap time.Duration) *StreamAnalyzer {
+	return &StreamAnalyzer{GapThreshold: gap, FlapWindow: time.Minute, nodeLeft: map[string]time.Time{}}
+}
+
+// Observe feeds the next ID and returns the findings it triggered.
+func (a *StreamAnalyzer) Observe(id string) []Finding {
+	var out []Finding
+	report := func(kind FindingKind, at time.Time, format string, args ...any) {
+		out = append(out, Finding{Kind: kind, ID: id, Prev: a.prev, At: at, Detail: fmt.Sprintf(format, args...)})
+	}
+	a.count++
+	p, h, s, err := ParseAny(id)
+	if err != nil {
+		a.invalid++
+		report(FindingInvalid, time.Time{}, "%v", err)
+		a.findings = append(a.findings, out...)
+		return out
+	}
+	var ts time.Time
+	var counter int
+	var node string
+	if p != nil {
+		ts, counter = p.Timestamp, p.Sequence
+	} else {
+		ts, counter, node = h.Timestamp, h.LogicalCounter, h.Node
+	}
+
+	if a.prev != "" {
+		switch {
+		case ts.Before(a.prevTime):
+			report(FindingRegression, ts, "timestamp went back %s", a.prevTime.Sub(ts))
+		case ts.Equal(a.prevTime) && counter <= a.prevCounter && node == a.prevNode:
+			report(FindingRegression, ts, "counter went from %d to %d within one tick", a.prevCounter, counter)
+		case a.GapThreshold > 0 && ts.Sub(a.prevTime) > a.GapThreshold:
+			report(FindingGap, ts, "no IDs for %s", ts.Sub(a.prevTime))
+		}
+		if node != a.prevNode {
+			if left, ok := a.nodeLeft[node]; ok && a.FlapWindow > 0 && ts.Sub(left) <= a.FlapWindow {
+				report(FindingNodeFlap, ts, "node switched back from %s to %s after %s", a.prevNode, node, ts.Sub(left))
+			}
+			if a.nodeLeft == nil {
+				a.nodeLeft = map[string]time.Time{}
+			}
+			a.nodeLeft[a.prevNode] = ts
+		}
+	}
+
+	if a.prev != "" && ts.Equal(a.prevTime) {
+		a.tickCount++
+	} else {
+		a.tickCount, a.burstNoted = 1, false
+	}
+	if capacity := pow10(s.W); a.tickCount > capacity && !a.burstNoted {
+		a.burstNoted = true
+		report(FindingBurst, ts, "more than %d IDs in one tick", capacity)
+	}
+
+	a.prev, a.prevTime, a.prevCounter, a.prevNode = id, ts, counter, node
+	a.findings = append(a.findings, out...)
+	return out
+}
+
+// Findings returns every finding reported so far.
+func (a *StreamAnalyzer) Findings() []Finding {
+	return append([]Finding(nil), a.findings...)
+}
+
+// Counts reports how many IDs were observed and how many failed to parse.
+func (a *StreamAnalyzer) Counts() (observed, invalid int) {
+	return a.count, a.invalid
+}
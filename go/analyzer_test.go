@@ -0,0 +1,58 @@
+package wid
+
+import (
+	"testing"
+	"time"
+)
+
+func findingKinds(fs []Finding) []FindingKind {
+	out := make([]FindingKind, len(fs))
+	for i, f := range fs {
+		out[i] = f.Kind
+	}
+	return out
+}
+
+// TestStreamAnalyzerFindings walks a crafted stream through each anomaly kind.
+func TestStreamAnalyzerFindings(t *testing.T) {
+	a := NewStreamAnalyzer(10 * time.Second)
+	steps := []struct {
+		id   string
+		want []FindingKind
+	}{
+		{"20260212T091530.0000Z", nil},
+		{"20260212T091530.0001Z", nil},
+		{"20260212T091530.0001Z", []FindingKind{FindingRegression}},
+		{"20260212T091529.0005Z", []FindingKind{FindingRegression}},
+		{"20260212T091600.0000Z", []FindingKind{FindingGap}},
+		{"nonsense", []FindingKind{FindingInvalid}},
+		{"20260212T091601.0000Z-n1", nil},
+		{"20260212T091602.0000Z-n2", nil},
+		{"20260212T091603.0000Z-n1", []FindingKind{FindingNodeFlap}},
+	}
+	for _, st := range steps {
+		got := findingKinds(a.Observe(st.id))
+		if len(got) != len(st.want) || len(got) > 0 && got[0] != st.want[0] {
+			t.Fatalf("Observe(%s) = %v, want %v", st.id, got, st.want)
+		}
+	}
+	if n, bad := a.Counts(); n != len(steps) || bad != 1 || len(a.Findings()) != 5 {
+		t.Fatalf("Counts = %d/%d, findings = %d", n, bad, len(a.Findings()))
+	}
+}
+
+// TestStreamAnalyzerBurst checks a tick holding more IDs than W allows is reported once.
+func TestStreamAnalyzerBurst(t *testing.T) {
+	a := NewStreamAnalyzer(0)
+	var bursts int
+	for i := 0; i < 12; i++ {
+		for _, f := range a.Observe("20260212T091530." + string(rune('0'+i%10)) + "Z") {
+			if f.Kind == FindingBurst {
+				bursts++
+			}
+		}
+	}
+	if bursts != 1 {
+		t.Fatalf("expected one burst finding, got %d", bursts)
+	}
+}
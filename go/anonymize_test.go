@@ -0,0 +1,40 @@
+package wid
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAnonymizeCoarsensAndHashes checks the timestamp is truncated, the rest
+// is keyed, and the output keeps the ID's shape and extensions.
+func TestAnonymizeCoarsensAndHashes(t *testing.T) {
+	key := []byte("export-2026")
+	cases := []struct{ in, prefix, suffix string }{
+		{"20260212T091530.0042Z-a3f91c", "20260212T090000.", ""},
+		{"20260212T091530123.0042Z-node01-a3f91c", "20260212T090000000.", ""},
+		{"20260212T091530.0042Z-a3f91c_orders", "20260212T090000.", "_orders"},
+		{"20260212T091530.0042Z-a3f91c~60", "20260212T090000.", "~60"},
+	}
+	for _, c := range cases {
+		got, err := Anonymize(c.in, time.Hour, key)
+		if err != nil {
+			t.Fatalf("%s: %v", c.in, err)
+		}
+		if len(got) != len(c.in) || !strings.HasPrefix(got, c.prefix) || !strings.HasSuffix(got, c.suffix) {
+			t.Fatalf("Anonymize(%s) = %s", c.in, got)
+		}
+		again, _ := Anonymize(c.in, time.Hour, key)
+		other, _ := Anonymize(c.in, time.Hour, []byte("another key"))
+		if again != got || other == got {
+			t.Fatalf("%s: same key gave %s and %s, other key %s", c.in, got, again, other)
+		}
+	}
+	got, _ := Anonymize("20260212T091530.0042Z-node01-a3f91c", time.Hour, key)
+	if !ValidateHlcWid(got, 4, 6) || !strings.Contains(got, "-node01-") {
+		t.Fatalf("HLC result %s", got)
+	}
+	if _, err := Anonymize("20260212T091530.0042Z", 0, key); err != ErrInvalidGranularity {
+		t.Fatalf("zero granularity = %v", err)
+	}
+}
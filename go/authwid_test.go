@@ -0,0 +1,33 @@
+package wid
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestAuthenticatedWidRoundTrip checks generated IDs verify under their
+// secret only, and that altering any field breaks the tag.
+func TestAuthenticatedWidRoundTrip(t *testing.T) {
+	secret := []byte("shared-secret")
+	g, err := NewAuthenticatedWidGen(secret, 4, 12, TimeUnitMs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range g.NextN(3) {
+		if err := VerifyAuthenticatedWid(id, secret, 4, 12, TimeUnitMs); err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		if err := VerifyAuthenticatedWid(id, []byte("other"), 4, 12, TimeUnitMs); !errors.Is(err, ErrInvalidAuthTag) {
+			t.Fatalf("wrong secret = %v", err)
+		}
+		zi := zIndex(id)
+		bumped := []byte(id)
+		bumped[zi-1] = '0' + (bumped[zi-1]-'0'+1)%10
+		if err := VerifyAuthenticatedWid(string(bumped), secret, 4, 12, TimeUnitMs); !errors.Is(err, ErrInvalidAuthTag) {
+			t.Fatalf("altered sequence %s = %v", bumped, err)
+		}
+	}
+	if _, err := NewAuthenticatedWidGen(secret, 4, 0, TimeUnitSec); !errors.Is(err, ErrInvalidZ) {
+		t.Fatalf("Z=0 = %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package wid
+
+import (
+	"crypto/sha512"
+	"encoding/binary"
+)
+
+// blowfish is the key-schedule state of the Blowfish cipher, only as much of
+// it as bcrypt_pbkdf needs: the expensive salted key setup and encryption.
+type blowfish struct {
+	p [18]uint32
+	s [4][256]uint32
+}
+
+func (c *blowfish) f(x uint32) uint32 {
+	return ((c.s[0][x>>24] + c.s[1][x>>16&0xff]) ^ c.s[2][x>>8&0xff]) + c.s[3][x&0xff]
+}
+
+func (c *blowfish) encryptBlock(l, r uint32) (uint32, uint32) {
+	l ^= c.p[0]
+	for i := 1; i <= 16; i += 2 {
+		r ^= c.f(l) ^ c.p[i]
+		l ^= c.f(r) ^ c.p[i+1]
+	}
+	return r ^ c.p[17], l
+}
+
+// nextWord reads a big-endian word from b, wrapping around its end.
+func nextWord(b []byte, j *int) uint32 {
+	var w uint32
+	for i := 0; i < 4; i++ {
+		w = w<<8 | uint32(b[*j])
+		*j = (*j + 1) % len(b)
+	}
+	return w
+}
+
+// expand is Blowfish's key schedule with the salt of eksblowfish: key is
+// folded into the P-array, then the state is rewritten by encrypting the
+// running block XORed with successive salt words. A nil salt is the plain
+// schedule.
+func (c *blowfish) expand(key, salt []byte) {
+	j := 0
+	for i := range c.p {
+		c.p[i] ^= nextWord(key, &j)
+	}
+	j = 0
+	var l, r uint32
+	next := func(dst []uint32) {
+		for i := 0; i < len(dst); i += 2 {
+			if salt != nil {
+				l ^= nextWord(salt, &j)
+				r ^= nextWord(salt, &j)
+			}
+			l, r = c.encryptBlock(l, r)
+			dst[i], dst[i+1] = l, r
+		}
+	}
+	next(c.p[:])
+	for i := range c.s {
+		next(c.s[i][:])
+	}
+}
+
+// bcryptHash is the core of bcrypt_pbkdf: an eksblowfish setup with 64
+// rounds, then 64 encryptions of a fixed magic, as little-endian words.
+func bcryptHash(out, shapass, shasalt []byte) {
+	c := &blowfish{p: blowfishP, s: blowfishS}
+	c.expand(shapass, shasalt)
+	for i := 0; i < 64; i++ {
+		c.expand(shasalt, nil)
+		c.expand(shapass, nil)
+	}
+	copy(out, "OxychromaticBlowfishSwatDynamite")
+	for i := 0; i < 32; i += 8 {
+		l, r := binary.BigEndian.Uint32(out[i:]), binary.BigEndian.Uint32(out[i+4:])
+		for j := 0; j < 64; j++ {
+			l, r = c.encryptBlock(l, r)
+		}
+		binary.LittleEndian.PutUint32(out[i:], l)
+		binary.LittleEndian.PutUint32(out[i+4:], r)
+	}
+}
+
+// bcryptPBKDF derives keyLen bytes from password and salt as OpenBSD's
+// bcrypt_pbkdf does, the KDF of passphrase-protected OpenSSH keys.
+func bcryptPBKDF(password, salt []byte, rounds, keyLen int) []byte {
+	blocks := (keyLen + 31) / 32
+	key := make([]byte, blocks*32)
+	shapass := sha512.Sum512(password)
+	tmp, out := make([]byte, 32), make([]byte, 32)
+	for block := 1; block <= blocks; block++ {
+		h := sha512.New()
+		h.Write(salt)
+		h.Write(binary.BigEndian.AppendUint32(nil, uint32(block)))
+		bcryptHash(tmp, shapass[:], h.Sum(nil))
+		copy(out, tmp)
+		for i := 1; i < rounds; i++ {
+			shasalt := sha512.Sum512(tmp)
+			bcryptHash(tmp, shapass[:], shasalt[:])
+			for j := range out {
+				out[j] ^= tmp[j]
+			}
+		}
+		// The output is interleaved across blocks, not concatenated.
+		for i, v := range out {
+			key[i*blocks+block-1] = v
+		}
+	}
+	return key[:keyLen]
+}
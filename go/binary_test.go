@@ -0,0 +1,50 @@
+package wid
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"testing"
+)
+
+// TestBinaryRoundTripAndOrder encodes generated IDs, checks each decodes to
+// itself and that byte order matches string order.
+func TestBinaryRoundTripAndOrder(t *testing.T) {
+	for _, c := range []struct {
+		w, z int
+		unit TimeUnit
+	}{{4, 6, TimeUnitSec}, {4, 0, TimeUnitMs}, {1, 15, TimeUnitSec}, {18, 0, TimeUnitMs}} {
+		g, _ := NewWidGenWithUnit(c.w, c.z, c.unit)
+		ids := g.NextN(200)
+		encs := make([][16]byte, len(ids))
+		for i, id := range ids {
+			b, err := EncodeBinary(id)
+			if err != nil {
+				t.Fatalf("EncodeBinary(%s) = %v", id, err)
+			}
+			if back, err := DecodeBinary(b); back != id || err != nil {
+				t.Fatalf("DecodeBinary(EncodeBinary(%s)) = %q, %v", id, back, err)
+			}
+			encs[i] = b
+		}
+		sort.Slice(encs, func(i, j int) bool { return bytes.Compare(encs[i][:], encs[j][:]) < 0 })
+		sort.Strings(ids)
+		for i := range ids {
+			if back, _ := DecodeBinary(encs[i]); back != ids[i] {
+				t.Fatalf("W=%d Z=%d %s: byte order differs from string order at %d", c.w, c.z, c.unit, i)
+			}
+		}
+	}
+	if _, err := EncodeBinary("20261016T120000.0001Z-node1"); !errors.Is(err, ErrBinaryHLC) {
+		t.Fatalf("HLC-WID = %v", err)
+	}
+	if _, err := EncodeBinary("20261016T120000.0001Z-" + "0123456789abcdef"); !errors.Is(err, ErrBinaryRange) {
+		t.Fatalf("Z=16 = %v", err)
+	}
+	var bad [16]byte
+	bad[6] = 1
+	bad[15] = 10
+	if _, err := DecodeBinary(bad); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("sequence 10 with W=1 = %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package wid
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidBucket is returned by Bucket for a non-positive width.
+var ErrInvalidBucket = errors.New("bucket width must be positive")
+
+// Bucket maps an ID to the key of the time bucket of width d containing it,
+// for partition keys in object stores, topics and tables. Buckets follow
+// time.Time.Truncate in UTC, so hours and days start on the hour and at
+// midnight. The key is the bucket start in the WID timestamp form, cut to
+// the coarsest precision that still shows it exactly (20261016 for a day,
+// 20261016T12 for an hour, 20261016T1230 for a minute, seconds, then
+// milliseconds), so keys sort like the IDs in them. A layout, in time.Format
+// syntax, replaces the default form. The ID's parameters are detected, so
+// WIDs and HLC-WIDs of any W, Z and unit are accepted.
+func Bucket(id string, d time.Duration, layout ...string) (string, error) {
+	if d <= 0 {
+		return "", ErrInvalidBucket
+	}
+	pw, ph, _, err := ParseAny(id)
+	if err != nil {
+		return "", err
+	}
+	var ts time.Time
+	if pw != nil {
+		ts = pw.Timestamp
+	} else {
+		ts = ph.Timestamp
+	}
+	start := ts.UTC().Truncate(d)
+	if len(layout) > 0 {
+		return start.Format(layout[0]), nil
+	}
+	switch {
+	case d%(24*time.Hour) == 0:
+		return start.Format("20060102"), nil
+	case d%time.Hour == 0:
+		return start.Format("20060102T15"), nil
+	case d%time.Minute == 0:
+		return start.Format("20060102T1504"), nil
+	case d%time.Second == 0:
+		return start.Format("20060102T150405"), nil
+	default:
+		return start.Format("20060102T150405") + fmt.Sprintf("%03d", start.Nanosecond()/int(time.Millisecond)), nil
+	}
+}
@@ -0,0 +1,37 @@
+package wid
+
+import (
+	"testing"
+	"time"
+)
+
+// TestBucketKeys checks default key forms, custom layouts and rejection of
+// bad input.
+func TestBucketKeys(t *testing.T) {
+	cases := []struct {
+		id   string
+		d    time.Duration
+		want string
+	}{
+		{"20261016T123456.0001Z-abcdef", 24 * time.Hour, "20261016"},
+		{"20261016T123456.0001Z-node1", time.Hour, "20261016T12"},
+		{"20261016T123456.0001Z", 6 * time.Hour, "20261016T12"},
+		{"20261016T123456.0001Z", 15 * time.Minute, "20261016T1230"},
+		{"20261016T123456.0001Z", 10 * time.Second, "20261016T123450"},
+		{"20261016T123456789.0001Z", 100 * time.Millisecond, "20261016T123456700"},
+	}
+	for _, c := range cases {
+		if got, err := Bucket(c.id, c.d); got != c.want || err != nil {
+			t.Errorf("Bucket(%s, %v) = %q, %v; want %s", c.id, c.d, got, err, c.want)
+		}
+	}
+	if got, _ := Bucket("20261016T123456.0001Z", time.Hour, "2006/01/02/15"); got != "2026/10/16/12" {
+		t.Fatalf("custom layout = %q", got)
+	}
+	if _, err := Bucket("20261016T123456.0001Z", 0); err != ErrInvalidBucket {
+		t.Fatalf("zero width = %v", err)
+	}
+	if _, err := Bucket("garbage", time.Hour); err == nil {
+		t.Fatal("expected an error for garbage input")
+	}
+}
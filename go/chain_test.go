@@ -0,0 +1,33 @@
+package wid
+
+import "testing"
+
+// TestHashChainVerify checks issued tags verify in order and that tampering is detected at the right link.
+func TestHashChainVerify(t *testing.T) {
+	secret := []byte("chain-secret")
+	g, _ := NewWidGen(4, 6)
+	h := NewHashChain(secret)
+	ids := g.NextN(5)
+	tags := make([]string, len(ids))
+	for i, id := range ids {
+		tags[i] = h.Append(id)
+	}
+	if n, err := VerifyChain(secret, "", ids, tags); err != nil || n != 5 {
+		t.Fatalf("VerifyChain = %d, %v", n, err)
+	}
+	// Dropping an ID breaks the next link.
+	if n, err := VerifyChain(secret, "", append(ids[:2:2], ids[3:]...), append(tags[:2:2], tags[3:]...)); err != ErrChainBroken || n != 2 {
+		t.Fatalf("expected break at 2, got %d, %v", n, err)
+	}
+	// A resumed chain continues from the head.
+	r, err := ResumeHashChain(secret, tags[2])
+	if err != nil || r.Append(ids[3]) != tags[3] {
+		t.Fatal("resumed chain must reproduce the next tag")
+	}
+	if n, err := VerifyChain(secret, tags[2], ids[3:], tags[3:]); err != nil || n != 2 {
+		t.Fatalf("VerifyChain from head = %d, %v", n, err)
+	}
+	if _, err := VerifyChain([]byte("other"), "", ids, tags); err != ErrChainBroken {
+		t.Fatal("wrong secret must not verify")
+	}
+}
@@ -0,0 +1,87 @@
+package wid
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrChecksum is returned by VerifyChecksum when the check character does not
+// match the ID.
+var ErrChecksum = errors.New("checksum mismatch")
+
+// checksumAlphabet is ISO 7064's MOD 37-2 character set, lower-cased; the
+// check character may be the 37th symbol, '*'.
+const checksumAlphabet = "0123456789abcdefghijklmnopqrstuvwxyz*"
+
+// Checksum computes the check character of an ID with ISO 7064 MOD 37-2 over
+// its letters and digits, case-insensitively, skipping separators. It
+// catches every single mistyped character and every swap of two adjacent
+// ones, the usual errors when IDs are read aloud or copied by hand.
+func Checksum(id string) byte {
+	p := 0
+	for i := 0; i < len(id); i++ {
+		v := strings.IndexByte(checksumAlphabet[:36], lowerASCII(id[i]))
+		if v < 0 {
+			continue
+		}
+		p = (p + v) * 2 % 37
+	}
+	return checksumAlphabet[(38-p)%37]
+}
+
+// AppendChecksum returns id followed by "-" and its check character (a
+// letter, digit or "*").
+func AppendChecksum(id string) string {
+	return id + "-" + string(Checksum(id))
+}
+
+// VerifyChecksum checks an ID produced with AppendChecksum (or a generator
+// with SetChecksum) and returns it without the check character, ready for
+// the usual validators. Case is ignored, so an ID read back in capitals
+// still verifies.
+func VerifyChecksum(s string) (string, error) {
+	if len(s) < 3 || s[len(s)-2] != '-' {
+		return "", ErrInvalidFormat
+	}
+	id := s[:len(s)-2]
+	if lowerASCII(s[len(s)-1]) != Checksum(id) {
+		return "", ErrChecksum
+	}
+	return id, nil
+}
+
+// SetChecksum makes the generator append a check character to every ID, as
+// AppendChecksum does.
+func (g *WidGen) SetChecksum(on bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.checksum = on
+}
+
+// SetChecksum is WidGen.SetChecksum for HLC generators.
+func (g *HLCWidGen) SetChecksum(on bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.checksum = on
+}
+
+func (g *WidGen) withChecksum(id string) string {
+	if g.checksum {
+		return AppendChecksum(id)
+	}
+	return id
+}
+
+func (g *HLCWidGen) withChecksum(id string) string {
+	if g.checksum {
+		return AppendChecksum(id)
+	}
+	return id
+}
+
+func lowerASCII(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
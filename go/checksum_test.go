@@ -0,0 +1,50 @@
+package wid
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestChecksumCatchesTypos checks generated IDs verify and that every single
+// substitution and adjacent swap of letters and digits is caught.
+func TestChecksumCatchesTypos(t *testing.T) {
+	g, _ := NewWidGen(4, 6)
+	g.SetChecksum(true)
+	h, _ := NewHLCWidGen("node1", 4, 0)
+	h.SetChecksum(true)
+	for _, s := range []string{g.Next(), h.Next()} {
+		id, err := VerifyChecksum(s)
+		if err != nil {
+			t.Fatalf("VerifyChecksum(%s) = %v", s, err)
+		}
+		if _, err := VerifyChecksum(strings.ToUpper(s)); err != nil {
+			t.Fatalf("upper-case %s = %v", s, err)
+		}
+		if !ValidateWid(id, 4, 6) && !ValidateHlcWid(id, 4, 0) {
+			t.Fatalf("%s without its check character is not valid", id)
+		}
+		for i := 0; i < len(s)-2; i++ {
+			if strings.IndexByte(checksumAlphabet[:36], lowerASCII(s[i])) < 0 {
+				continue
+			}
+			for _, c := range []byte(checksumAlphabet[:36]) {
+				if c == lowerASCII(s[i]) {
+					continue
+				}
+				typo := s[:i] + string(c) + s[i+1:]
+				if _, err := VerifyChecksum(typo); err != ErrChecksum {
+					t.Fatalf("typo %s of %s not caught", typo, s)
+				}
+			}
+			if j := i + 1; j < len(s)-2 && s[j] != s[i] && strings.IndexByte(checksumAlphabet[:36], lowerASCII(s[j])) >= 0 {
+				swap := s[:i] + string(s[j]) + string(s[i]) + s[j+1:]
+				if _, err := VerifyChecksum(swap); err != ErrChecksum {
+					t.Fatalf("swap %s of %s not caught", swap, s)
+				}
+			}
+		}
+	}
+	if _, err := VerifyChecksum("20261016T120000.0001Z"); err != ErrInvalidFormat {
+		t.Fatalf("unchecksummed ID = %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package wid
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrClockBackwards is matched (via errors.Is) by the *ClockBackwardsError
+// NextE returns when the wall clock steps back further than the tolerance.
+var ErrClockBackwards = errors.New("wall clock moved backwards")
+
+// ClockBackwardsError reports a clock regression seen by NextE. Delta is how
+// far the clock went back, at the generator's time-unit resolution.
+type ClockBackwardsError struct {
+	Delta     time.Duration
+	Tolerance time.Duration
+}
+
+func (e *ClockBackwardsError) Error() string {
+	return fmt.Sprintf("%v by %v (tolerance %v)", ErrClockBackwards, e.Delta, e.Tolerance)
+}
+
+func (e *ClockBackwardsError) Is(target error) bool { return target == ErrClockBackwards }
+
+func tickDuration(unit TimeUnit) time.Duration {
+	if unit == TimeUnitMs {
+		return time.Millisecond
+	}
+	return time.Second
+}
+
+// clockRegression returns the error for a reading now after lastNow, or nil
+// when the clock did not go back or went back within tolerance.
+func clockRegression(lastNow, now int64, unit TimeUnit, tolerance time.Duration) error {
+	if now >= lastNow {
+		return nil
+	}
+	delta := time.Duration(lastNow-now) * tickDuration(unit)
+	if delta <= tolerance {
+		return nil
+	}
+	return &ClockBackwardsError{Delta: delta, Tolerance: tolerance}
+}
+
+// SetClockTolerance sets how far the wall clock may step back before NextE
+// reports it; the default is zero, so any regression is reported.
+func (g *WidGen) SetClockTolerance(d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.tolerance = d
+}
+
+// NextE is Next that refuses to paper over a clock step back: where Next pins
+// to the last tick and keeps counting, NextE returns a *ClockBackwardsError
+// and issues nothing until the clock catches up. A regression within the
+// tolerance is absorbed as Next would absorb it.
+func (g *WidGen) NextE() (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	if err := clockRegression(g.lastNow, now, g.TimeUnit, g.tolerance); err != nil {
+		g.stats.ClockRegressions++
+		return "", err
+	}
+	return g.issueLocked(now, g.padding(), true)
+}
+
+// SetClockTolerance is WidGen.SetClockTolerance for HLC generators.
+func (g *HLCWidGen) SetClockTolerance(d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.tolerance = d
+}
+
+// NextE is WidGen.NextE for HLC generators.
+func (g *HLCWidGen) NextE() (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	if err := clockRegression(g.lastNow, now, g.TimeUnit, g.tolerance); err != nil {
+		g.stats.ClockRegressions++
+		return "", err
+	}
+	return g.nextAtLocked(now), nil
+}
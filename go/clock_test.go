@@ -0,0 +1,33 @@
+package wid
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestNextEClockBackwards simulates an NTP step back and checks NextE
+// reports it, with the delta, unless it is within tolerance.
+func TestNextEClockBackwards(t *testing.T) {
+	g, _ := NewWidGen(4, 0)
+	if _, err := g.NextE(); err != nil {
+		t.Fatal(err)
+	}
+	g.lastNow += 30 // the previous reading was 30s ahead of now
+	_, err := g.NextE()
+	var cb *ClockBackwardsError
+	if !errors.Is(err, ErrClockBackwards) || !errors.As(err, &cb) || cb.Delta < 29*time.Second {
+		t.Fatalf("NextE after a 30s step back = %v", err)
+	}
+	g.SetClockTolerance(time.Minute)
+	if _, err := g.NextE(); err != nil {
+		t.Fatalf("regression within tolerance: %v", err)
+	}
+
+	h, _ := NewHLCWidGenWithUnit("node01", 4, 0, TimeUnitMs)
+	h.Next()
+	h.lastNow += 5000
+	if _, err := h.NextE(); !errors.As(err, &cb) || cb.Delta < 4*time.Second {
+		t.Fatalf("HLC NextE after a 5s step back = %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Encryption at rest for state wid persists: the DLQ, spill and SAF queues,
+// the SAF and spill acks and chain head under the data dir, the E=sql generator
+// counters, and the daemon's service log. When $WID_DATA_KEY (32 bytes as
+// base64 or hex) or $WID_DATA_KEY_FILE is set, every record is sealed with
+// AES-256-GCM and stored as "enc:v1:<base64(nonce||ciphertext)>". With
+// $WID_DATA_KEY_KMS set to an awskms://, gcpkms:// or vault:// key URI, the
+// key (or key file) instead holds that service's ciphertext of the data
+// key, unwrapped once at start. Records are sealed one line at a time so
+// append-only files stay appendable, and plaintext lines written before a
+// key was configured remain readable.
+//
+// E=sql counters move from wid_state to wid_state_sealed (see
+// sealStateRow), so with a key set the SQL state is no longer shared with
+// the other implementations, and E=sql without the key refuses it.
+const sealedPrefix = "enc:v1:"
+
+var sealedAAD = []byte("wid-at-rest-v1")
+
+var (
+	dataAEADOnce sync.Once
+	dataAEAD     cipher.AEAD
+	dataAEADErr  error
+	dataNonceKey []byte
+)
+
+// dataKeyAEAD returns the configured cipher, or nil when encryption is off.
+func dataKeyAEAD() (cipher.AEAD, error) {
+	dataAEADOnce.Do(func() {
+		raw := strings.TrimSpace(os.Getenv("WID_DATA_KEY"))
+		if raw == "" {
+			if path := strings.TrimSpace(os.Getenv("WID_DATA_KEY_FILE")); path != "" {
+				b, err := os.ReadFile(path)
+				if err != nil {
+					dataAEADErr = err
+					return
+				}
+				raw = strings.TrimSpace(string(b))
+			}
+		}
+		if raw == "" {
+			if os.Getenv("WID_DATA_KEY_KMS") != "" {
+				dataAEADErr = errors.New("WID_DATA_KEY_KMS needs the wrapped key in WID_DATA_KEY or WID_DATA_KEY_FILE")
+			}
+			return
+		}
+		var key []byte
+		var err error
+		if uri := strings.TrimSpace(os.Getenv("WID_DATA_KEY_KMS")); uri != "" {
+			if key, err = kmsDecrypt(uri, raw); err == nil && len(key) != 32 {
+				err = errors.New("WID_DATA_KEY_KMS unwrapped a key that is not 32 bytes")
+			}
+		} else {
+			key, err = decodeDataKey(raw)
+		}
+		if err != nil {
+			dataAEADErr = err
+			return
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			dataAEADErr = err
+			return
+		}
+		m := hmac.New(sha256.New, key)
+		_, _ = m.Write([]byte("wid-at-rest-v1:row-nonce"))
+		dataNonceKey = m.Sum(nil)
+		dataAEAD, dataAEADErr = cipher.NewGCM(block)
+	})
+	return dataAEAD, dataAEADErr
+}
+
+func decodeDataKey(raw string) ([]byte, error) {
+	if b, err := hex.DecodeString(raw); err == nil && len(b) == 32 {
+		return b, nil
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if b, err := enc.DecodeString(raw); err == nil && len(b) == 32 {
+			return b, nil
+		}
+	}
+	return nil, errors.New("WID_DATA_KEY must be 32 bytes, hex or base64 encoded")
+}
+
+// sealRecord encrypts one record when a data key is configured.
+func sealRecord(plain []byte) ([]byte, error) {
+	aead, err := dataKeyAEAD()
+	if err != nil || aead == nil {
+		return plain, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	ct := aead.Seal(nonce, nonce, plain, sealedAAD)
+	return []byte(sealedPrefix + base64.RawStdEncoding.EncodeToString(ct)), nil
+}
+
+// openRecord reverses sealRecord; unsealed records pass through unchanged.
+func openRecord(line []byte) ([]byte, error) {
+	body, ok := strings.CutPrefix(string(line), sealedPrefix)
+	if !ok {
+		return line, nil
+	}
+	aead, err := dataKeyAEAD()
+	if err != nil {
+		return nil, err
+	}
+	if aead == nil {
+		return nil, errors.New("encrypted record found but WID_DATA_KEY is not set")
+	}
+	ct, err := base64.RawStdEncoding.DecodeString(body)
+	if err != nil || len(ct) < aead.NonceSize() {
+		return nil, errors.New("malformed encrypted record")
+	}
+	plain, err := aead.Open(nil, ct[:aead.NonceSize()], ct[aead.NonceSize():], sealedAAD)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt record (wrong WID_DATA_KEY?): %v", err)
+	}
+	return plain, nil
+}
+
+// sealStateRow seals the E=sql counters of row k. Unlike sealRecord the
+// nonce is derived from the row and its counters, so a pair always seals
+// to the same text and sqlCompareAndSwapState can match on the stored
+// value; counters only move forward, so this reveals nothing more. The row
+// key is authenticated, so a sealed value cannot be moved to another row.
+func sealStateRow(k string, tick int64, seq int) (string, error) {
+	aead, err := dataKeyAEAD()
+	if err != nil {
+		return "", err
+	}
+	if aead == nil {
+		return "", errors.New("sealed SQL state needs WID_DATA_KEY")
+	}
+	aad := append(append([]byte(nil), sealedAAD...), ":"+k...)
+	plain := []byte(fmt.Sprintf("%d|%d", tick, seq))
+	m := hmac.New(sha256.New, dataNonceKey)
+	_, _ = m.Write(aad)
+	_, _ = m.Write([]byte{0})
+	_, _ = m.Write(plain)
+	nonce := m.Sum(nil)[:aead.NonceSize()]
+	return sealedPrefix + base64.RawStdEncoding.EncodeToString(aead.Seal(nonce, nonce, plain, aad)), nil
+}
+
+// openStateRow reverses sealStateRow.
+func openStateRow(k, v string) (int64, int, error) {
+	aead, err := dataKeyAEAD()
+	if err != nil {
+		return 0, 0, err
+	}
+	body, ok := strings.CutPrefix(strings.TrimSpace(v), sealedPrefix)
+	if aead == nil || !ok {
+		return 0, 0, errors.New("invalid sealed sql state row")
+	}
+	ct, err := base64.RawStdEncoding.DecodeString(body)
+	if err != nil || len(ct) < aead.NonceSize() {
+		return 0, 0, errors.New("invalid sealed sql state row")
+	}
+	aad := append(append([]byte(nil), sealedAAD...), ":"+k...)
+	plain, err := aead.Open(nil, ct[:aead.NonceSize()], ct[aead.NonceSize():], aad)
+	if err != nil {
+		return 0, 0, fmt.Errorf("cannot decrypt sql state row %s (wrong WID_DATA_KEY?): %v", k, err)
+	}
+	return sqlParseState(string(plain))
+}
+
+// writeDataFile atomically replaces path with a sealed copy of b.
+func writeDataFile(path string, b []byte) error {
+	sealed, err := sealRecord(b)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, sealed, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// readDataFile reads a file written by writeDataFile.
+func readDataFile(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return openRecord([]byte(strings.TrimSpace(string(b))))
+}
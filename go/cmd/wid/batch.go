@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	wid "github.com/waldiez/wid/go"
+)
+
+// batchChunk is how many lines A=sign/verify MODE=batch hold at once: each
+// chunk is processed on all CPUs and printed in input order before the next
+// is read, so memory stays flat on inputs of any size.
+const batchChunk = 4096
+
+// batchRecord is one JSON input line of a batch: the output of A=sign
+// MODE=batch, or {"wid": ...} with an optional DATA= style "data" path.
+type batchRecord struct {
+	Line     int             `json:"line,omitempty"`
+	WID      string          `json:"wid"`
+	Data     string          `json:"data,omitempty"`
+	Sig      string          `json:"sig,omitempty"`
+	Envelope json.RawMessage `json:"envelope,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// runBatch feeds the non-blank lines of IN= (stdin when "-" or empty) to
+// work, prints each result as one JSON line in input order and a summary on
+// stderr. The exit code is 0 only when every line succeeded.
+func runBatch(c canon, work func(line int, text string) (any, bool)) int {
+	var in io.Reader = os.Stdin
+	if c.in != "" && c.in != "-" {
+		f, err := os.Open(c.in)
+		if err != nil {
+			errln(err.Error())
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+	type job struct {
+		line int
+		text string
+	}
+	ok, failed := 0, 0
+	flush := func(jobs []job) {
+		out := make([]any, len(jobs))
+		good := make([]bool, len(jobs))
+		var wg sync.WaitGroup
+		next := make(chan int)
+		for w := 0; w < runtime.NumCPU(); w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range next {
+					out[i], good[i] = work(jobs[i].line, jobs[i].text)
+				}
+			}()
+		}
+		for i := range jobs {
+			next <- i
+		}
+		close(next)
+		wg.Wait()
+		for i := range out {
+			if good[i] {
+				ok++
+			} else {
+				failed++
+			}
+			printJSON(out[i])
+		}
+	}
+	var jobs []job
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		if jobs = append(jobs, job{n, text}); len(jobs) == batchChunk {
+			flush(jobs)
+			jobs = jobs[:0]
+		}
+	}
+	flush(jobs)
+	if err := sc.Err(); err != nil {
+		errln(err.Error())
+		return 1
+	}
+	summary, _ := json.Marshal(map[string]any{"in": c.in, "lines": ok + failed, "ok": ok, "failed": failed})
+	fmt.Fprintln(os.Stderr, string(summary))
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// parseBatchRecord reads a line as a JSON record or, failing that, a bare WID.
+func parseBatchRecord(text string) (batchRecord, error) {
+	var r batchRecord
+	if !strings.HasPrefix(text, "{") {
+		r.WID = text
+		return r, nil
+	}
+	err := json.Unmarshal([]byte(text), &r)
+	return r, err
+}
+
+// runSignBatch is A=sign MODE=batch: one signature (FORMAT=jws: envelope)
+// per input WID, with the key loaded once.
+func runSignBatch(c canon) int {
+	sign := func(msg []byte) ([]byte, error) { return pkcs11Sign(c.key, msg) }
+	if !isPKCS11(c.key) {
+		pk, err := loadSigningKey(c.key)
+		if err != nil {
+			errln(err.Error())
+			return 1
+		}
+		sign = func(msg []byte) ([]byte, error) { return wid.SignMessage(pk, msg) }
+	}
+	var kid, alg string
+	if c.format == "jws" {
+		var err error
+		if kid, alg, err = signingKeyInfo(c); err != nil {
+			errln(err.Error())
+			return 1
+		}
+	}
+	return runBatch(c, func(line int, text string) (any, bool) {
+		r, err := parseBatchRecord(text)
+		out := batchRecord{Line: line, WID: r.WID, Data: r.Data}
+		if err == nil {
+			err = signBatchRecord(c, r, kid, alg, sign, &out)
+		}
+		if err != nil {
+			out.Error = err.Error()
+			return out, false
+		}
+		return out, true
+	})
+}
+
+func signBatchRecord(c canon, r batchRecord, kid, alg string, sign func([]byte) ([]byte, error), out *batchRecord) error {
+	c.wid, c.data = r.WID, r.Data
+	msg, err := buildSignVerifyMessage(c)
+	if err != nil {
+		return err
+	}
+	if c.format == "jws" {
+		env, err := buildEnvelope(c, msg, kid, alg, sign)
+		if err != nil {
+			return err
+		}
+		out.Envelope, _ = json.Marshal(env)
+		return nil
+	}
+	sig, err := sign(msg)
+	if err != nil {
+		return err
+	}
+	out.Sig = b64urlEncode(sig)
+	if strings.TrimSpace(c.tsa) != "" {
+		token, err := requestTimestamp(c.tsa, sig)
+		if err != nil {
+			return fmt.Errorf("timestamping failed: %w", err)
+		}
+		out.Sig += "." + b64urlEncode(token)
+	}
+	return nil
+}
+
+// runVerifyBatch is A=verify MODE=batch. Each line is a JSON envelope, an
+// A=sign MODE=batch record, or wid<TAB>sig.
+func runVerifyBatch(c canon) int {
+	shared, err := loadSharedKeys(c)
+	if err != nil {
+		errln(err.Error())
+		return 1
+	}
+	c.wid = ""
+	return runBatch(c, func(line int, text string) (any, bool) {
+		r := verifyBatchLine(c, text, shared)
+		r.Line = line
+		return r, r.Valid
+	})
+}
+
+func verifyBatchLine(c canon, text string, ks *wid.KeySet) manifestResult {
+	if strings.HasPrefix(text, "{") {
+		var rec batchRecord
+		if err := json.Unmarshal([]byte(text), &rec); err != nil {
+			return manifestResult{Error: "invalid JSON line"}
+		}
+		switch {
+		case rec.Error != "":
+			return manifestResult{WID: rec.WID, Error: "not signed: " + rec.Error}
+		case rec.Envelope != nil:
+			c.data = rec.Data
+			return verifyEnvelope(c, string(rec.Envelope), ks)
+		case rec.Sig != "":
+			return verifyManifestEntry(c, manifestEntry{wid: rec.WID, sig: rec.Sig, data: rec.Data}, ks)
+		}
+		// anything else must be an envelope itself
+		return verifyEnvelope(c, text, ks)
+	}
+	id, sig, found := strings.Cut(text, "\t")
+	if !found {
+		return manifestResult{WID: text, Error: "expected wid<TAB>sig"}
+	}
+	return verifyManifestEntry(c, manifestEntry{wid: strings.TrimSpace(id), sig: strings.TrimSpace(sig)}, ks)
+}
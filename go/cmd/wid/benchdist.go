@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	wid "github.com/waldiez/wid/go"
+)
+
+// benchPlan is what the coordinator sends every worker: all workers run the
+// same shape against the same state backend at the same moment.
+type benchPlan struct {
+	Count   int    `json:"count"`
+	Kind    string `json:"kind"`
+	W       int    `json:"W"`
+	Z       int    `json:"Z"`
+	Unit    string `json:"time_unit"`
+	State   string `json:"state"`
+	DataDir string `json:"data_dir,omitempty"`
+}
+
+// benchReport is a worker's result; IDs are returned for the cross-worker
+// duplicate check.
+type benchReport struct {
+	Worker     string   `json:"worker"`
+	N          int      `json:"n"`
+	Seconds    float64  `json:"seconds"`
+	CASRetries int      `json:"cas_retries"`
+	Error      string   `json:"error,omitempty"`
+	IDs        []string `json:"ids"`
+}
+
+// runBenchCoordinator waits for --workers workers on --coordinator, starts
+// them together and aggregates throughput, CAS retry rate and duplicates.
+func runBenchCoordinator(o opts) int {
+	workers := o.workers
+	if workers == 0 {
+		workers = 1
+	}
+	plan := benchPlan{Count: o.count, Kind: o.kind, W: o.w, Z: o.z, Unit: string(o.timeUnit), State: o.state, DataDir: o.dataDir}
+	if plan.Count <= 0 {
+		plan.Count = 100000
+	}
+	if plan.State == "" {
+		plan.State = "memory"
+	}
+	ln, err := net.Listen("tcp", o.coordinator)
+	if err != nil {
+		errln(err.Error())
+		return 1
+	}
+	defer ln.Close()
+	fmt.Fprintf(os.Stderr, "bench coordinator on %s: waiting for %d worker(s)\n", ln.Addr(), workers)
+	conns := make([]net.Conn, 0, workers)
+	for len(conns) < workers {
+		conn, err := ln.Accept()
+		if err != nil {
+			errln(err.Error())
+			return 1
+		}
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+	start := time.Now()
+	reports := make([]benchReport, len(conns))
+	var wg sync.WaitGroup
+	for i, conn := range conns {
+		wg.Add(1)
+		go func(i int, conn net.Conn) {
+			defer wg.Done()
+			r := &reports[i]
+			r.Worker = conn.RemoteAddr().String()
+			if err := json.NewEncoder(conn).Encode(plan); err != nil {
+				r.Error = err.Error()
+				return
+			}
+			dec := json.NewDecoder(bufio.NewReader(conn))
+			if err := dec.Decode(r); err != nil {
+				r.Error = "no report: " + err.Error()
+			}
+		}(i, conn)
+	}
+	wg.Wait()
+	wall := time.Since(start).Seconds()
+
+	total, retries, failed := 0, 0, 0
+	seen := make(map[string]struct{}, plan.Count*len(reports))
+	dups := 0
+	for _, r := range reports {
+		if r.Error != "" {
+			failed++
+			errln(fmt.Sprintf("worker %s: %s", r.Worker, r.Error))
+		}
+		total += r.N
+		retries += r.CASRetries
+		for _, id := range r.IDs {
+			if _, ok := seen[id]; ok {
+				dups++
+			}
+			seen[id] = struct{}{}
+		}
+	}
+	retryRate := 0.0
+	if total > 0 {
+		retryRate = float64(retries) / float64(total)
+	}
+	rate := float64(total) / max(wall, 1e-9)
+	newEmitter(outputOr(o, "json")).emit(
+		fmt.Sprintf("impl=go mode=distributed workers=%d n=%d seconds=%.6f ids_per_sec=%.0f cas_retries=%d duplicates=%d", len(reports), total, wall, rate, retries, dups),
+		field{"impl", "go"},
+		field{"mode", "distributed"},
+		field{"kind", plan.Kind},
+		field{"state", plan.State},
+		field{"workers", len(reports)},
+		field{"failed_workers", failed},
+		field{"n", total},
+		field{"seconds", wall},
+		field{"ids_per_sec", rate},
+		field{"cas_retries", retries},
+		field{"cas_retry_rate", retryRate},
+		field{"duplicates", dups},
+	)
+	if dups > 0 || failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runBenchWorker connects to --worker, runs the coordinator's plan and
+// reports back. A local --data-dir overrides the coordinator's, for hosts
+// that mount the shared backend elsewhere.
+func runBenchWorker(o opts) int {
+	conn, err := net.Dial("tcp", o.worker)
+	if err != nil {
+		errln(err.Error())
+		return 1
+	}
+	defer conn.Close()
+	var plan benchPlan
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&plan); err != nil {
+		errln("bench worker: " + err.Error())
+		return 1
+	}
+	if o.dataDir != "" {
+		plan.DataDir = o.dataDir
+	}
+	r := runBenchPlan(plan, o.node)
+	r.Worker, _ = os.Hostname()
+	r.Worker = fmt.Sprintf("%s/%d", r.Worker, os.Getpid())
+	if err := json.NewEncoder(conn).Encode(r); err != nil {
+		errln(err.Error())
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "bench worker: %d ids in %.3fs, %d CAS retries\n", r.N, r.Seconds, r.CASRetries)
+	if r.Error != "" {
+		errln(r.Error)
+		return 1
+	}
+	return 0
+}
+
+func runBenchPlan(plan benchPlan, node string) benchReport {
+	var r benchReport
+	unit, err := wid.ParseTimeUnit(plan.Unit)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	c := canon{w: plan.W, z: plan.Z, t: unit, kind: plan.Kind, node: node, d: plan.DataDir}
+	r.IDs = make([]string, 0, plan.Count)
+	start := time.Now()
+	switch plan.State {
+	case "sql":
+		if err := os.MkdirAll(dataDir(c), 0o755); err != nil {
+			r.Error = err.Error()
+			return r
+		}
+		for i := 0; i < plan.Count; i++ {
+			id, retries, err := sqlAllocateNextWidCounted(c)
+			r.CASRetries += retries
+			if err != nil {
+				r.Error = err.Error()
+				break
+			}
+			r.IDs = append(r.IDs, id)
+		}
+	case "memory":
+		g, err := newCanonGen(c)
+		if err != nil {
+			r.Error = err.Error()
+			return r
+		}
+		for i := 0; i < plan.Count; i++ {
+			r.IDs = append(r.IDs, g.Next())
+		}
+	default:
+		r.Error = "unsupported state backend: " + plan.State
+	}
+	r.Seconds = time.Since(start).Seconds()
+	r.N = len(r.IDs)
+	return r
+}
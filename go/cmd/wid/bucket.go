@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	wid "github.com/waldiez/wid/go"
+)
+
+// cmdBucket prints the --by bucket key of each ID on stdin, using the same
+// rules as wid.Bucket. IDs that do not parse are reported on stderr and make
+// the exit status 1.
+func cmdBucket(o opts) int {
+	var layout []string
+	if o.layout != "" {
+		layout = append(layout, o.layout)
+	}
+	e := newEmitter(outputOr(o, "text"))
+	bad := 0
+	sc := bufio.NewScanner(os.Stdin)
+	for sc.Scan() {
+		id := strings.TrimSpace(sc.Text())
+		if id == "" {
+			continue
+		}
+		key, err := wid.Bucket(id, o.by, layout...)
+		if err != nil {
+			errln(fmt.Sprintf("%s: %v", id, err))
+			bad++
+			continue
+		}
+		e.emit(key+"\t"+id, field{"bucket", key}, field{"wid", id})
+	}
+	if err := sc.Err(); err != nil {
+		errln(err.Error())
+		return 1
+	}
+	if bad > 0 {
+		return 1
+	}
+	return 0
+}
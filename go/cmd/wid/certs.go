@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	wid "github.com/waldiez/wid/go"
+)
+
+// isKeySetPath reports whether KEY= names a key set for rotation: a JWKS URL, a
+// directory of public keys or a JSON Web Key Set file.
+func isKeySetPath(path string) bool {
+	if isKeySetURL(path) {
+		return true
+	}
+	if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+		return true
+	}
+	b, err := os.ReadFile(path)
+	return err == nil && strings.HasPrefix(strings.TrimSpace(string(b)), "{")
+}
+
+// loadVerifyKeySet resolves KEY= to the keys a signature may verify under:
+// every key of a key set (see wid.LoadKeySet), or the single key or
+// certificate of loadVerifyKey, with its signer identity.
+func loadVerifyKeySet(c canon) (*wid.KeySet, string, error) {
+	if isKeySetPath(c.key) {
+		ks, err := loadKeySet(c)
+		return ks, "", err
+	}
+	pk, signer, err := loadVerifyKey(c)
+	if err != nil {
+		return nil, "", err
+	}
+	ks := &wid.KeySet{}
+	ks.Add("", pk)
+	return ks, signer, nil
+}
+
+func isKeySetURL(key string) bool {
+	return strings.HasPrefix(key, "https://") || strings.HasPrefix(key, "http://")
+}
+
+// loadKeySet reads the key set KEY= names. A URL is fetched as a JWKS and
+// cached under the data dir for KEY_TTL_SEC; KEY_PIN= restricts it to the
+// keys with the listed fingerprints.
+func loadKeySet(c canon) (*wid.KeySet, error) {
+	if !isKeySetURL(c.key) {
+		return wid.LoadKeySet(c.key)
+	}
+	sum := sha256.Sum256([]byte(c.key))
+	r := &wid.RemoteKeySet{
+		URL:       c.key,
+		TTL:       time.Duration(c.keyTTLSec) * time.Second,
+		CacheFile: filepath.Join(dataDir(c), "jwks", hex.EncodeToString(sum[:8])+".json"),
+	}
+	for _, p := range strings.Split(c.keyPin, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			r.Pins = append(r.Pins, p)
+		}
+	}
+	return r.KeySet(context.Background())
+}
+
+// loadVerifyKey resolves KEY= for A=verify. A plain public key is used as is;
+// a certificate (optionally followed by its intermediates) is first verified
+// against the CA= bundle (or the system roots) at the WID's own timestamp, and
+// the leaf's subject is returned as the signer identity.
+func loadVerifyKey(c canon) (crypto.PublicKey, string, error) {
+	if _, ok := sshAgentKey(c.key); ok || isKMSKey(c.key) {
+		pk, err := loadPublicKey(c.key)
+		return pk, "", err
+	}
+	b, err := os.ReadFile(c.key)
+	if err != nil {
+		return nil, "", err
+	}
+	if !strings.Contains(string(b), "-----BEGIN CERTIFICATE-----") {
+		pk, err := loadPublicKey(c.key)
+		return pk, "", err
+	}
+	chain, err := parseCertChain(b)
+	if err != nil {
+		return nil, "", err
+	}
+	ms, err := wotpWidTickMs(c.wid)
+	if err != nil {
+		return nil, "", err
+	}
+	vo := x509.VerifyOptions{
+		Intermediates: x509.NewCertPool(),
+		CurrentTime:   time.UnixMilli(ms).UTC(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	for _, ic := range chain[1:] {
+		vo.Intermediates.AddCert(ic)
+	}
+	if strings.TrimSpace(c.ca) != "" {
+		cab, err := os.ReadFile(c.ca)
+		if err != nil {
+			return nil, "", err
+		}
+		vo.Roots = x509.NewCertPool()
+		if !vo.Roots.AppendCertsFromPEM(cab) {
+			return nil, "", fmt.Errorf("no certificates found in CA bundle: %s", c.ca)
+		}
+	}
+	leaf := chain[0]
+	if _, err := leaf.Verify(vo); err != nil {
+		return nil, "", fmt.Errorf("certificate not trusted at WID time %s: %v", vo.CurrentTime.Format(time.RFC3339), err)
+	}
+	if _, err := wid.SignatureAlgorithm(leaf.PublicKey); err != nil {
+		return nil, "", fmt.Errorf("certificate key: %w", err)
+	}
+	return leaf.PublicKey, certIdentity(leaf), nil
+}
+
+func parseCertChain(b []byte) ([]*x509.Certificate, error) {
+	var chain []*x509.Certificate
+	for {
+		var blk *pem.Block
+		blk, b = pem.Decode(b)
+		if blk == nil {
+			break
+		}
+		if blk.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(blk.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, cert)
+	}
+	if len(chain) == 0 {
+		return nil, errors.New("failed to parse PEM certificate")
+	}
+	return chain, nil
+}
+
+// certIdentity names the signer: the subject DN plus any email/URI SANs.
+func certIdentity(cert *x509.Certificate) string {
+	parts := []string{cert.Subject.String()}
+	parts = append(parts, cert.EmailAddresses...)
+	for _, u := range cert.URIs {
+		parts = append(parts, u.String())
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	wid "github.com/waldiez/wid/go"
+)
+
+// Compact forms of plain WIDs. All of them pack the same fields:
+//
+//	binary:    wid.EncodeBinary's 16 bytes
+//	binaryhex: lowercase hex of the 16 binary bytes
+//	base32:    wid.ToCompact, Crockford Base32 of the 16 binary bytes
+//	uuid7:     RFC 9562 UUIDv7; unix_ms = tick (scaled to ms), rand_a = unit
+//	           bit | W | Z, rand_b = sequence << 4Z | padding
+//
+// HLC-WIDs carry a free-form node name and have no compact form.
+
+var errNoCompactHLC = errors.New("HLC-WIDs carry a node name and have no compact form")
+
+type widFields struct {
+	tick int64
+	unit wid.TimeUnit
+	w    int
+	z    int
+	seq  uint64
+	pad  uint64
+}
+
+func seqBits(w int) int {
+	max := uint64(1)
+	for i := 0; i < w; i++ {
+		max *= 10
+	}
+	return bits.Len64(max - 1)
+}
+
+func fieldsOf(id string) (widFields, error) {
+	params, err := wid.DetectParams(id)
+	if err != nil {
+		return widFields{}, err
+	}
+	unit, w, z := params.TimeUnit, params.W, params.Z
+	if params.Kind != "wid" {
+		return widFields{}, errNoCompactHLC
+	}
+	p, err := wid.ParseWidWithUnit(id, w, z, unit)
+	if err != nil {
+		return widFields{}, err
+	}
+	f := widFields{unit: unit, w: w, z: z, seq: uint64(p.Sequence)}
+	if unit == wid.TimeUnitMs {
+		f.tick = p.Timestamp.UnixMilli()
+	} else {
+		f.tick = p.Timestamp.Unix()
+	}
+	if p.Padding != nil {
+		if f.pad, err = strconv.ParseUint(*p.Padding, 16, 64); err != nil || z > 16 {
+			return widFields{}, fmt.Errorf("Z=%d padding does not fit a compact form", z)
+		}
+	}
+	return f, nil
+}
+
+func (f widFields) String() string {
+	var ts string
+	if f.unit == wid.TimeUnitMs {
+		ts = time.UnixMilli(f.tick).UTC().Format("20060102T150405") + fmt.Sprintf("%03d", f.tick%1000)
+	} else {
+		ts = time.Unix(f.tick, 0).UTC().Format("20060102T150405")
+	}
+	id := fmt.Sprintf("%s.%0*dZ", ts, f.w, f.seq)
+	if f.z > 0 {
+		id += fmt.Sprintf("-%0*x", f.z, f.pad)
+	}
+	return id
+}
+
+func (f widFields) low(avail int) (uint64, error) {
+	if seqBits(f.w)+4*f.z > avail {
+		return 0, fmt.Errorf("W=%d with Z=%d needs more than %d bits", f.w, f.z, avail)
+	}
+	return f.seq<<(4*f.z) | f.pad, nil
+}
+
+func packUUID7(f widFields) (string, error) {
+	ms := f.tick
+	if f.unit == wid.TimeUnitSec {
+		ms *= 1000
+	}
+	if ms < 0 || ms >= 1<<48 {
+		return "", errors.New("timestamp does not fit 48 bits")
+	}
+	if f.z > 15 {
+		return "", fmt.Errorf("Z=%d does not fit uuid7", f.z)
+	}
+	lo, err := f.low(62)
+	if err != nil {
+		return "", err
+	}
+	randA := uint64(f.w)<<4 | uint64(f.z)
+	if f.unit == wid.TimeUnitMs {
+		randA |= 1 << 11
+	}
+	hi := uint64(ms)<<16 | 0x7<<12 | randA
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[0:8], hi)
+	binary.BigEndian.PutUint64(b[8:16], 0b10<<62|lo)
+	h := hex.EncodeToString(b[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32], nil
+}
+
+func unpackUUID7(s string) (widFields, error) {
+	raw, err := hex.DecodeString(strings.ReplaceAll(s, "-", ""))
+	if err != nil || len(raw) != 16 {
+		return widFields{}, errors.New("invalid UUID")
+	}
+	hi, lo := binary.BigEndian.Uint64(raw[0:8]), binary.BigEndian.Uint64(raw[8:16])
+	if hi>>12&0xf != 7 || lo>>62 != 0b10 {
+		return widFields{}, errors.New("not an RFC 9562 UUIDv7")
+	}
+	randA := hi & 0xfff
+	f := widFields{tick: int64(hi >> 16), unit: wid.TimeUnitSec, w: int(randA >> 4 & 0x1f), z: int(randA & 0xf)}
+	if randA>>11 != 0 {
+		f.unit = wid.TimeUnitMs
+	} else {
+		if f.tick%1000 != 0 {
+			return f, errors.New("UUIDv7 was not produced from a WID")
+		}
+		f.tick /= 1000
+	}
+	if f.w < 1 || f.w > wid.MaxW || seqBits(f.w)+4*f.z > 62 {
+		return f, errors.New("UUIDv7 was not produced from a WID")
+	}
+	lo &= 1<<62 - 1
+	f.seq = lo >> (4 * f.z)
+	if f.z > 0 {
+		f.pad = lo & (1<<(4*f.z) - 1)
+	}
+	return f, nil
+}
+
+// decodeCompact recovers the canonical WID from one of the compact forms.
+func decodeCompact(value, from string) (string, error) {
+	var f widFields
+	var err error
+	switch from {
+	case "base32":
+		return wid.FromCompact(value)
+	case "binaryhex":
+		raw, err := hex.DecodeString(value)
+		if err == nil && len(raw) != 16 {
+			err = errors.New("binaryhex form must be 32 hex characters")
+		}
+		if err != nil {
+			return "", err
+		}
+		return wid.DecodeBinary([16]byte(raw))
+	case "uuid7":
+		f, err = unpackUUID7(value)
+	default:
+		return "", errors.New("--from must be one of: base32, uuid7, binaryhex")
+	}
+	if err != nil {
+		return "", err
+	}
+	id := f.String()
+	if _, err := wid.ParseWidWithUnit(id, f.w, f.z, f.unit); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func cmdDecode(value string, o opts) int {
+	id, err := decodeCompact(strings.TrimSpace(value), o.from)
+	if err != nil {
+		errln(err.Error())
+		return 1
+	}
+	f, _ := fieldsOf(id)
+	p, _ := wid.ParseWidWithUnit(id, f.w, f.z, f.unit)
+	e := newEmitter(outputOr(o, "text"))
+	e.emitTable(
+		fmt.Sprintf("wid=%s\ntimestamp=%s\nsequence=%d\npadding=%s", id, p.Timestamp.In(o.loc).Format(time.RFC3339), p.Sequence, derefOr(p.Padding, "")),
+		field{"wid", id},
+		field{"timestamp", p.Timestamp.In(o.loc).Format(time.RFC3339)},
+		field{"sequence", p.Sequence},
+		field{"padding", p.Padding},
+		field{"time_unit", string(f.unit)},
+		field{"W", f.w},
+		field{"Z", f.z},
+	)
+	return 0
+}
+
+func derefOr(p *string, def string) string {
+	if p == nil {
+		return def
+	}
+	return *p
+}
+
+// snowflakeEpochMs is the Twitter Snowflake epoch (2010-11-04T01:42:54.657Z).
+const snowflakeEpochMs = 1288834974657
+
+// packSnowflake maps a WID onto a Snowflake-style int64: 41 bits of
+// milliseconds since the Snowflake epoch, 10 worker bits taken from the low
+// bits of the padding, and a 12-bit sequence. The mapping is one-way.
+func packSnowflake(f widFields) (string, error) {
+	ms := f.tick
+	if f.unit == wid.TimeUnitSec {
+		ms *= 1000
+	}
+	ms -= snowflakeEpochMs
+	if ms < 0 || ms >= 1<<41 {
+		return "", errors.New("timestamp is outside the Snowflake range")
+	}
+	if f.seq > 0xfff {
+		return "", fmt.Errorf("sequence %d does not fit the 12-bit Snowflake sequence", f.seq)
+	}
+	v := uint64(ms)<<22 | (f.pad&0x3ff)<<12 | f.seq
+	return strconv.FormatUint(v, 10), nil
+}
+
+// encodeCompact converts a canonical WID to the requested form.
+func encodeCompact(id, to string) (string, error) {
+	switch to {
+	case "base32":
+		return wid.ToCompact(id)
+	case "binaryhex":
+		b, err := wid.EncodeBinary(id)
+		if err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(b[:]), nil
+	}
+	f, err := fieldsOf(id)
+	if err != nil {
+		return "", err
+	}
+	switch to {
+	case "uuid7":
+		return packUUID7(f)
+	case "snowflake":
+		return packSnowflake(f)
+	default:
+		return "", errors.New("--to must be one of: base32, binaryhex, uuid7, snowflake")
+	}
+}
+
+// cmdEncode converts one ID, or every line of stdin when the ID is "-" or
+// omitted, so whole columns can be piped through.
+func cmdEncode(id string, o opts) int {
+	e := newEmitter(outputOr(o, "text"))
+	encodeOne := func(id string) bool {
+		out, err := encodeCompact(id, o.to)
+		if err != nil {
+			errln(fmt.Sprintf("%s: %v", id, err))
+			return false
+		}
+		e.emit(out, field{"wid", id}, field{o.to, out})
+		return true
+	}
+	if id != "" && id != "-" {
+		if encodeOne(id) {
+			return 0
+		}
+		return 1
+	}
+	rc := 0
+	sc := bufio.NewScanner(os.Stdin)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		if !encodeOne(line) {
+			rc = 1
+		}
+	}
+	if err := sc.Err(); err != nil {
+		errln(err.Error())
+		return 1
+	}
+	return rc
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// config is the parsed wid config file. Only the TOML subset wid needs is
+// understood: a top-level `default = "name"`, `[profile.<name>]` and
+// `[module.<name>]` tables, and `KEY = value` pairs with string, integer or
+// boolean values.
+type config struct {
+	path           string
+	defaultProfile string
+	sections       map[string]map[string]string
+}
+
+// profileKeys are the settings a profile may carry, in display order.
+var profileKeys = []string{"KIND", "NODE", "W", "Z", "T", "E", "R", "D", "L"}
+
+var profileDefaults = map[string]string{
+	"KIND": "wid", "NODE": "go", "W": "4", "Z": "6", "T": "sec",
+	"E": "state", "R": "auto", "D": "", "L": "3600",
+}
+
+// profileCanonicalKeys are the profile settings honored in KEY=VALUE mode.
+var profileCanonicalKeys = []string{"KIND", "NODE", "W", "Z", "T", "E", "R", "D", "L"}
+
+func configPath() string {
+	if p := strings.TrimSpace(os.Getenv("WID_CONFIG")); p != "" {
+		return p
+	}
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		base = filepath.Join(home, ".config")
+	}
+	return filepath.Join(base, "wid", "config.toml")
+}
+
+// loadConfig reads the config file; a missing file yields an empty config.
+func loadConfig() (*config, error) {
+	cfg := &config{path: configPath(), sections: map[string]map[string]string{}}
+	if cfg.path == "" {
+		return cfg, nil
+	}
+	b, err := os.ReadFile(cfg.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	section := ""
+	for n, raw := range strings.Split(string(b), "\n") {
+		line := strings.TrimSpace(stripTomlComment(raw))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("%s:%d: malformed table header", cfg.path, n+1)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if cfg.sections[section] == nil {
+				cfg.sections[section] = map[string]string{}
+			}
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected key = value", cfg.path, n+1)
+		}
+		k = strings.TrimSpace(k)
+		val, err := tomlValue(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", cfg.path, n+1, err)
+		}
+		if section == "" {
+			if k == "default" {
+				cfg.defaultProfile = val
+			}
+			continue
+		}
+		cfg.sections[section][k] = val
+	}
+	return cfg, nil
+}
+
+func stripTomlComment(line string) string {
+	inStr := byte(0)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case inStr != 0 && c == inStr:
+			inStr = 0
+		case inStr == 0 && (c == '"' || c == '\''):
+			inStr = c
+		case inStr == 0 && c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func tomlValue(v string) (string, error) {
+	if len(v) >= 2 && (v[0] == '"' && v[len(v)-1] == '"') {
+		s, err := strconv.Unquote(v)
+		if err != nil {
+			return "", errors.New("invalid string value")
+		}
+		return s, nil
+	}
+	if len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'' {
+		return v[1 : len(v)-1], nil
+	}
+	if v == "true" || v == "false" {
+		return v, nil
+	}
+	if _, err := strconv.Atoi(v); err == nil {
+		return v, nil
+	}
+	return "", fmt.Errorf("unsupported value %q", v)
+}
+
+func (c *config) profileNames() []string {
+	var names []string
+	for s := range c.sections {
+		if name, ok := strings.CutPrefix(s, "profile."); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *config) profile(name string) (map[string]string, bool) {
+	p, ok := c.sections["profile."+name]
+	return p, ok
+}
+
+// activeProfile resolves the profile to apply: an explicit name, then
+// $WID_PROFILE, then the config default. An empty name means none.
+func (c *config) activeProfile(explicit string) (string, map[string]string, error) {
+	name := explicit
+	if name == "" {
+		name = os.Getenv("WID_PROFILE")
+	}
+	if name == "" {
+		name = c.defaultProfile
+	}
+	if name == "" {
+		return "", nil, nil
+	}
+	p, ok := c.profile(name)
+	if !ok {
+		return "", nil, fmt.Errorf("unknown profile: %s", name)
+	}
+	return name, p, nil
+}
+
+// effectiveProfile overlays a profile on the built-in defaults.
+func effectiveProfile(p map[string]string) map[string]string {
+	out := make(map[string]string, len(profileDefaults))
+	for k, v := range profileDefaults {
+		out[k] = v
+	}
+	for k, v := range p {
+		out[k] = v
+	}
+	return out
+}
+
+// profileFlagArgs turns profile settings into subcommand flags that are
+// prepended to the user's flags, so explicit flags still win.
+func profileFlagArgs(p map[string]string) []string {
+	flags := map[string]string{"KIND": "--kind", "NODE": "--node", "W": "--W", "Z": "--Z", "T": "--time-unit"}
+	var out []string
+	for _, k := range profileKeys {
+		if v, ok := p[k]; ok && flags[k] != "" {
+			out = append(out, flags[k], v)
+		}
+	}
+	return out
+}
+
+// profileKVArgs turns profile settings into KEY=VALUE arguments for
+// canonical mode, prepended so explicit arguments still win.
+func profileKVArgs(p map[string]string) []string {
+	var out []string
+	for _, k := range profileCanonicalKeys {
+		if v, ok := p[k]; ok {
+			out = append(out, k+"="+v)
+		}
+	}
+	return out
+}
+
+func (c *config) setDefault(name string) error {
+	if _, ok := c.profile(name); !ok {
+		return fmt.Errorf("unknown profile: %s", name)
+	}
+	b, err := os.ReadFile(c.path)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(b), "\n")
+	line := fmt.Sprintf("default = %q", name)
+	replaced := false
+	for i, l := range lines {
+		t := strings.TrimSpace(l)
+		if strings.HasPrefix(t, "[") {
+			break
+		}
+		if k, _, ok := strings.Cut(t, "="); ok && strings.TrimSpace(k) == "default" {
+			lines[i] = line
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		lines = append([]string{line}, lines...)
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+func cmdProfile(args []string) int {
+	if len(args) == 0 {
+		errln("usage: wid profile list|show [name]|set-default <name>|diff <a> <b> [--output ...]")
+		return 1
+	}
+	sub := args[0]
+	var pos []string
+	output := "text"
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "--json":
+			output = "json"
+		case "--output", "-o":
+			if i+1 >= len(args) || !outputFormats[args[i+1]] {
+				errln("--output must be one of: text, json, ndjson, csv")
+				return 1
+			}
+			output = args[i+1]
+			i++
+		default:
+			pos = append(pos, args[i])
+		}
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		errln(err.Error())
+		return 1
+	}
+	e := newEmitter(output)
+	switch sub {
+	case "list":
+		active, _, _ := cfg.activeProfile("")
+		for _, name := range cfg.profileNames() {
+			mark := " "
+			if name == active {
+				mark = "*"
+			}
+			e.emit(mark+" "+name, field{"name", name}, field{"active", name == active})
+		}
+		return 0
+	case "show":
+		explicit := ""
+		if len(pos) > 0 {
+			explicit = pos[0]
+		}
+		name, p, err := cfg.activeProfile(explicit)
+		if err != nil {
+			errln(err.Error())
+			return 1
+		}
+		eff := effectiveProfile(p)
+		if output == "text" {
+			if name == "" {
+				name = "(built-in defaults)"
+			}
+			fmt.Printf("profile=%s\nconfig=%s\n", name, cfg.path)
+			for _, k := range profileKeys {
+				fmt.Printf("%s=%s\n", k, eff[k])
+			}
+			return 0
+		}
+		fields := []field{{"profile", name}, {"config", cfg.path}}
+		for _, k := range profileKeys {
+			fields = append(fields, field{k, eff[k]})
+		}
+		e.emit("", fields...)
+		return 0
+	case "set-default":
+		if len(pos) != 1 {
+			errln("usage: wid profile set-default <name>")
+			return 1
+		}
+		if err := cfg.setDefault(pos[0]); err != nil {
+			errln(err.Error())
+			return 1
+		}
+		fmt.Printf("default profile set to %s in %s\n", pos[0], cfg.path)
+		return 0
+	case "diff":
+		if len(pos) != 2 {
+			errln("usage: wid profile diff <a> <b>")
+			return 1
+		}
+		pa, ok := cfg.profile(pos[0])
+		if !ok {
+			errln("unknown profile: " + pos[0])
+			return 1
+		}
+		pb, ok := cfg.profile(pos[1])
+		if !ok {
+			errln("unknown profile: " + pos[1])
+			return 1
+		}
+		ea, eb := effectiveProfile(pa), effectiveProfile(pb)
+		for _, k := range profileKeys {
+			if ea[k] != eb[k] {
+				e.emit(fmt.Sprintf("%s: %s -> %s", k, ea[k], eb[k]), field{"key", k}, field{pos[0], ea[k]}, field{pos[1], eb[k]})
+			}
+		}
+		return 0
+	default:
+		errln("unknown profile command: " + sub)
+		return 1
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	wid "github.com/waldiez/wid/go"
+)
+
+// corpusShape is one combination of the parameters a corpus entry is
+// labeled with; parsers under test validate against exactly these.
+type corpusShape struct {
+	Kind   string `json:"kind"`
+	Unit   string `json:"time_unit"`
+	W      int    `json:"W"`
+	Z      int    `json:"Z"`
+	Padded bool   `json:"padded"`
+	Node   string `json:"node,omitempty"`
+	unit   wid.TimeUnit
+}
+
+// corpusParts is a valid ID split at its field boundaries so mutations can
+// damage exactly one of them.
+type corpusParts struct {
+	date, clock, seq, node, pad string
+}
+
+func (p corpusParts) join() string {
+	s := p.date + "T" + p.clock + "." + p.seq + "Z"
+	if p.node != "" {
+		s += "-" + p.node
+	}
+	if p.pad != "" {
+		s += "-" + p.pad
+	}
+	return s
+}
+
+// corpusMutations turn a valid ID into a near miss: one field wrong, the
+// rest intact. Each returns false when it does not apply to the shape.
+var corpusMutations = []struct {
+	name string
+	fn   func(r *rand.Rand, s corpusShape, p corpusParts) (string, bool)
+}{
+	{"month-13", func(_ *rand.Rand, _ corpusShape, p corpusParts) (string, bool) {
+		p.date = p.date[:4] + "13" + p.date[6:]
+		return p.join(), true
+	}},
+	{"day-32", func(_ *rand.Rand, _ corpusShape, p corpusParts) (string, bool) {
+		p.date = p.date[:6] + "32"
+		return p.join(), true
+	}},
+	{"feb-30", func(_ *rand.Rand, _ corpusShape, p corpusParts) (string, bool) {
+		p.date = p.date[:4] + "0230"
+		return p.join(), true
+	}},
+	{"hour-24", func(_ *rand.Rand, _ corpusShape, p corpusParts) (string, bool) {
+		p.clock = "24" + p.clock[2:]
+		return p.join(), true
+	}},
+	{"minute-60", func(_ *rand.Rand, _ corpusShape, p corpusParts) (string, bool) {
+		p.clock = p.clock[:2] + "60" + p.clock[4:]
+		return p.join(), true
+	}},
+	{"second-60", func(_ *rand.Rand, _ corpusShape, p corpusParts) (string, bool) {
+		p.clock = p.clock[:4] + "60" + p.clock[6:]
+		return p.join(), true
+	}},
+	{"wrong-unit-digits", func(_ *rand.Rand, s corpusShape, p corpusParts) (string, bool) {
+		if s.unit == wid.TimeUnitMs {
+			p.clock = p.clock[:6]
+		} else {
+			p.clock += "000"
+		}
+		return p.join(), true
+	}},
+	{"short-seq", func(_ *rand.Rand, s corpusShape, p corpusParts) (string, bool) {
+		if s.W < 2 {
+			return "", false
+		}
+		p.seq = p.seq[1:]
+		return p.join(), true
+	}},
+	{"long-seq", func(_ *rand.Rand, _ corpusShape, p corpusParts) (string, bool) {
+		p.seq = "0" + p.seq
+		return p.join(), true
+	}},
+	{"non-digit-seq", func(r *rand.Rand, _ corpusShape, p corpusParts) (string, bool) {
+		i := r.Intn(len(p.seq))
+		p.seq = p.seq[:i] + "a" + p.seq[i+1:]
+		return p.join(), true
+	}},
+	{"lowercase-t", func(_ *rand.Rand, _ corpusShape, p corpusParts) (string, bool) {
+		return strings.Replace(p.join(), "T", "t", 1), true
+	}},
+	{"lowercase-z", func(_ *rand.Rand, _ corpusShape, p corpusParts) (string, bool) {
+		return strings.Replace(p.join(), "Z", "z", 1), true
+	}},
+	{"missing-z", func(_ *rand.Rand, _ corpusShape, p corpusParts) (string, bool) {
+		return strings.Replace(p.join(), "Z", "", 1), true
+	}},
+	{"comma-separator", func(_ *rand.Rand, _ corpusShape, p corpusParts) (string, bool) {
+		return strings.Replace(p.join(), ".", ",", 1), true
+	}},
+	{"uppercase-pad", func(_ *rand.Rand, _ corpusShape, p corpusParts) (string, bool) {
+		up := strings.ToUpper(p.pad)
+		if up == p.pad {
+			return "", false
+		}
+		p.pad = up
+		return p.join(), true
+	}},
+	{"non-hex-pad", func(r *rand.Rand, _ corpusShape, p corpusParts) (string, bool) {
+		if p.pad == "" {
+			return "", false
+		}
+		i := r.Intn(len(p.pad))
+		p.pad = p.pad[:i] + "g" + p.pad[i+1:]
+		return p.join(), true
+	}},
+	{"short-pad", func(_ *rand.Rand, _ corpusShape, p corpusParts) (string, bool) {
+		if len(p.pad) < 2 {
+			return "", false
+		}
+		p.pad = p.pad[1:]
+		return p.join(), true
+	}},
+	{"empty-pad", func(_ *rand.Rand, s corpusShape, p corpusParts) (string, bool) {
+		if s.Kind == "hlc" {
+			return "", false
+		}
+		return p.join() + "-", true
+	}},
+	{"empty-node", func(_ *rand.Rand, s corpusShape, p corpusParts) (string, bool) {
+		if s.Kind != "hlc" {
+			return "", false
+		}
+		p.node = ""
+		return strings.Replace(p.join(), "Z", "Z-", 1), true
+	}},
+	{"space-in-node", func(_ *rand.Rand, s corpusShape, p corpusParts) (string, bool) {
+		if s.Kind != "hlc" {
+			return "", false
+		}
+		p.node = p.node[:1] + " " + p.node[1:]
+		return p.join(), true
+	}},
+	{"trailing-newline", func(_ *rand.Rand, _ corpusShape, p corpusParts) (string, bool) {
+		return p.join() + "\n", true
+	}},
+	{"leading-space", func(_ *rand.Rand, _ corpusShape, p corpusParts) (string, bool) {
+		return " " + p.join(), true
+	}},
+}
+
+var corpusNodes = []string{"go", "node1", "eu_west", "a", "db.primary", "edge42"}
+
+// corpusEntry draws a random shape and a valid ID for it.
+func corpusEntry(r *rand.Rand, w, z int) (corpusShape, corpusParts) {
+	s := corpusShape{Kind: "wid", unit: wid.TimeUnitSec, W: w, Z: z}
+	if r.Intn(2) == 1 {
+		s.Kind = "hlc"
+		s.Node = corpusNodes[r.Intn(len(corpusNodes))]
+	}
+	if r.Intn(2) == 1 {
+		s.unit = wid.TimeUnitMs
+	}
+	s.Unit = string(s.unit)
+	s.Padded = z > 0 && r.Intn(2) == 1
+	// 2000-01-01 .. 2099-12-31, uniform
+	sec := int64(946684800) + r.Int63n(3155760000)
+	t := time.Unix(sec, int64(r.Intn(1000))*int64(time.Millisecond)).UTC()
+	p := corpusParts{date: t.Format("20060102"), clock: t.Format("150405"), node: s.Node}
+	if s.unit == wid.TimeUnitMs {
+		p.clock += fmt.Sprintf("%03d", t.Nanosecond()/int(time.Millisecond))
+	}
+	limit := int64(1)
+	for i := 0; i < w; i++ {
+		limit *= 10
+	}
+	p.seq = fmt.Sprintf("%0*d", w, r.Int63n(limit))
+	if s.Padded {
+		const hexDigits = "0123456789abcdef"
+		b := make([]byte, z)
+		for i := range b {
+			b[i] = hexDigits[r.Intn(16)]
+		}
+		p.pad = string(b)
+	}
+	return s, p
+}
+
+func (s corpusShape) valid(id string) bool {
+	if s.Kind == "hlc" {
+		return wid.ValidateHlcWidWithUnit(id, s.W, s.Z, s.unit)
+	}
+	return wid.ValidateWidWithUnit(id, s.W, s.Z, s.unit)
+}
+
+type corpusRecord struct {
+	ID       string `json:"id"`
+	Valid    bool   `json:"valid"`
+	Mutation string `json:"mutation,omitempty"`
+	corpusShape
+}
+
+// cmdCorpus prints --valid valid and --invalid near-miss IDs as NDJSON,
+// reproducible from --seed. Every label is checked against this library's
+// validator before it is printed.
+func cmdCorpus(o opts) int {
+	if o.valid == 0 && o.invalid == 0 {
+		errln("corpus needs --valid <n> and/or --invalid <n>")
+		return 1
+	}
+	if o.w > wid.MaxW || o.z > wid.MaxZ {
+		errln("W/Z out of range")
+		return 1
+	}
+	r := rand.New(rand.NewSource(o.seed))
+	enc := json.NewEncoder(os.Stdout)
+	for i := 0; i < o.valid; i++ {
+		s, p := corpusEntry(r, o.w, o.z)
+		id := p.join()
+		if !s.valid(id) {
+			errln("internal error: generated ID does not validate: " + id)
+			return 1
+		}
+		_ = enc.Encode(corpusRecord{ID: id, Valid: true, corpusShape: s})
+	}
+	for i := 0; i < o.invalid; {
+		s, p := corpusEntry(r, o.w, o.z)
+		m := corpusMutations[r.Intn(len(corpusMutations))]
+		id, ok := m.fn(r, s, p)
+		if !ok || s.valid(id) {
+			continue
+		}
+		_ = enc.Encode(corpusRecord{ID: id, Valid: false, Mutation: m.name, corpusShape: s})
+		i++
+	}
+	return 0
+}
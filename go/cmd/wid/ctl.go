@@ -0,0 +1,446 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+func runtimeSock() string { return filepath.Join(runtimeDir(), "control.sock") }
+
+// loopControl carries the live-adjustable knobs of a service loop: pause
+// state and the tick interval. Changes wake a sleeping loop immediately.
+// IDs are issued under issueMu so a handoff can stop issuance atomically.
+type loopControl struct {
+	gen      canonGen
+	shape    string
+	issueMu  sync.Mutex
+	mu       sync.Mutex
+	paused   bool
+	stopping bool
+	interval int
+	wake     chan struct{}
+}
+
+// newLoopControl wraps g; shape identifies the generator (its SQL state
+// key) so a handoff is only accepted between identically shaped loops.
+func newLoopControl(g canonGen, shape string, interval int) *loopControl {
+	return &loopControl{gen: g, shape: shape, interval: interval, wake: make(chan struct{}, 1)}
+}
+
+// next issues the loop's next ID, or reports false once the loop is stopping.
+func (lc *loopControl) next() (string, bool) {
+	lc.issueMu.Lock()
+	defer lc.issueMu.Unlock()
+	if lc.stopped() {
+		return "", false
+	}
+	return lc.gen.Next(), true
+}
+
+// beginHandoff holds issuance and returns the generator state; no ID is
+// issued by this loop until endHandoff.
+func (lc *loopControl) beginHandoff() (int64, int) {
+	lc.issueMu.Lock()
+	return lc.gen.State()
+}
+
+// endHandoff stops the loop for good once the successor has taken over
+// (commit), or lets it issue again when the successor gave up.
+func (lc *loopControl) endHandoff(commit bool) {
+	if commit {
+		lc.stop()
+	}
+	lc.issueMu.Unlock()
+}
+
+func (lc *loopControl) poke() {
+	select {
+	case lc.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (lc *loopControl) setPaused(p bool) {
+	lc.mu.Lock()
+	lc.paused = p
+	lc.mu.Unlock()
+	lc.poke()
+}
+
+func (lc *loopControl) setInterval(sec int) {
+	lc.mu.Lock()
+	lc.interval = sec
+	lc.mu.Unlock()
+	lc.poke()
+}
+
+// stop ends the loop after the current tick, so its deferred cleanup runs.
+func (lc *loopControl) stop() {
+	lc.mu.Lock()
+	lc.stopping = true
+	lc.mu.Unlock()
+	lc.poke()
+}
+
+func (lc *loopControl) stopped() bool {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	return lc.stopping
+}
+
+func (lc *loopControl) state() (bool, int) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	return lc.paused, lc.interval
+}
+
+// waitResumed blocks while the loop is paused.
+func (lc *loopControl) waitResumed() {
+	for {
+		if paused, _ := lc.state(); !paused || lc.stopped() {
+			return
+		}
+		<-lc.wake
+	}
+}
+
+// sleep waits one interval; an interval change restarts the wait with the
+// new value and a pause extends it until resumed.
+func (lc *loopControl) sleep() {
+	for {
+		paused, sec := lc.state()
+		if paused {
+			lc.waitResumed()
+			return
+		}
+		if sec <= 0 || lc.stopped() {
+			return
+		}
+		t := time.NewTimer(time.Duration(sec) * time.Second)
+		select {
+		case <-t.C:
+			return
+		case <-lc.wake:
+			t.Stop()
+		}
+	}
+}
+
+// sleepFor rests d unless the loop is stopped or reconfigured meanwhile.
+func (lc *loopControl) sleepFor(d time.Duration) {
+	if d <= 0 || lc.stopped() {
+		return
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-lc.wake:
+	}
+}
+
+// serveControl listens on the daemon's unix control socket. Each connection
+// sends one command line and receives one JSON line back. The returned
+// function closes the listener and removes the socket.
+func serveControl(path string, lc *loopControl, st *daemonStatus) (func(), error) {
+	_ = os.Remove(path)
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	_ = os.Chmod(path, 0o600)
+	own, _ := os.Stat(path)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+				r := bufio.NewReader(conn)
+				line, err := r.ReadString('\n')
+				if err != nil && line == "" {
+					return
+				}
+				if shape, ok := strings.CutPrefix(strings.TrimSpace(line), "handoff"); ok {
+					serveHandoff(conn, r, strings.TrimSpace(shape), lc, st)
+					return
+				}
+				resp := handleControl(strings.TrimSpace(line), lc, st)
+				b, _ := json.Marshal(resp)
+				_, _ = conn.Write(append(b, '\n'))
+			}(conn)
+		}
+	}()
+	return func() {
+		_ = ln.Close()
+		// After a handoff the path may already belong to the successor.
+		if fi, err := os.Stat(path); err == nil && own != nil && os.SameFile(fi, own) {
+			_ = os.Remove(path)
+		}
+	}, nil
+}
+
+func handleControl(cmd string, lc *loopControl, st *daemonStatus) map[string]any {
+	fail := func(err error) map[string]any { return map[string]any{"ok": false, "cmd": cmd, "error": err.Error()} }
+	verb, rest, _ := strings.Cut(cmd, " ")
+	switch verb {
+	case "pause":
+		lc.setPaused(true)
+		st.setState("paused")
+	case "resume":
+		lc.setPaused(false)
+		st.setState("running")
+	case "rotate-logs":
+		if err := rotateLogs(); err != nil {
+			return fail(err)
+		}
+	case "stats":
+		var s map[string]any
+		_ = json.Unmarshal(st.marshal(), &s)
+		paused, sec := lc.state()
+		s["paused"], s["interval"] = paused, sec
+		return map[string]any{"ok": true, "cmd": cmd, "stats": s}
+	case "set":
+		k, v, ok := strings.Cut(strings.TrimSpace(rest), "=")
+		if !ok {
+			return fail(errors.New("usage: set KEY=VALUE"))
+		}
+		switch k {
+		case "L":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return fail(errors.New("invalid L"))
+			}
+			lc.setInterval(n)
+		default:
+			return fail(fmt.Errorf("unsupported key: %s (settable: L)", k))
+		}
+	default:
+		return fail(errors.New("unknown command (pause|resume|rotate-logs|stats|set L=<sec>|handoff)"))
+	}
+	return map[string]any{"ok": true, "cmd": cmd}
+}
+
+// serveHandoff is the predecessor's half of a two-phase handoff. The
+// successor names its generator shape, and a mismatch is refused before
+// anything stops. Otherwise issuance is held, the state sent, and the loop
+// stops only on the successor's "commit"; an "abort", a closed connection or
+// a timeout resumes it, as the successor issues nothing before its commit is
+// acknowledged.
+func serveHandoff(conn net.Conn, r *bufio.Reader, shape string, lc *loopControl, st *daemonStatus) {
+	reply := func(m map[string]any) error {
+		b, _ := json.Marshal(m)
+		_, err := conn.Write(append(b, '\n'))
+		return err
+	}
+	if shape != lc.shape {
+		_ = reply(map[string]any{"ok": false, "cmd": "handoff",
+			"error": fmt.Sprintf("predecessor generates %s, successor %s", lc.shape, shape)})
+		return
+	}
+	tick, seq := lc.beginHandoff()
+	commit := false
+	defer func() { lc.endHandoff(commit) }()
+	if reply(map[string]any{
+		"ok": true, "cmd": "handoff", "pid": os.Getpid(),
+		"last_tick": tick, "last_seq": seq, "shape": lc.shape,
+	}) != nil {
+		return
+	}
+	line, _ := r.ReadString('\n')
+	if strings.TrimSpace(line) != "commit" {
+		return
+	}
+	commit = true
+	st.setState("handed-off")
+	_ = reply(map[string]any{"ok": true, "cmd": "commit"})
+}
+
+// daemonLog is where the daemon's output goes. os.Stdout and os.Stderr are
+// one pipe, set up once at start and never reassigned; a single goroutine
+// appends each line read from it to the current service log, sealed when a
+// data key is configured (see atrest.go). Rotation only swaps the file
+// under mu, so no write races a swap or lands on a closed file.
+var daemonLog struct {
+	mu   sync.Mutex
+	f    *os.File
+	w    *os.File
+	done chan struct{}
+}
+
+// startDaemonLog routes the daemon's output through daemonLog. Output
+// written before it runs, and Go runtime crash reports, go straight to the
+// log file A=start opened, unsealed.
+func startDaemonLog() error {
+	if _, err := dataKeyAEAD(); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(runtimeLog(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	daemonLog.f, daemonLog.w, daemonLog.done = f, w, make(chan struct{})
+	os.Stdout, os.Stderr = w, w
+	go func() {
+		defer close(daemonLog.done)
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadBytes('\n')
+			if len(line) > 0 {
+				if rec, serr := sealRecord(bytes.TrimSuffix(line, []byte("\n"))); serr == nil {
+					daemonLog.mu.Lock()
+					_, _ = daemonLog.f.Write(append(rec, '\n'))
+					daemonLog.mu.Unlock()
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// flushDaemonLog writes out whatever output is still in the pipe before
+// the daemon exits.
+func flushDaemonLog() {
+	if daemonLog.w == nil {
+		return
+	}
+	_ = daemonLog.w.Close()
+	<-daemonLog.done
+}
+
+// rotateLogs moves service.log aside to service.log.1 and points the
+// daemon's output at a fresh log file.
+func rotateLogs() error {
+	if daemonLog.w == nil {
+		return errors.New("log rotation needs the daemon started by A=start")
+	}
+	if err := os.Rename(runtimeLog(), runtimeLog()+".1"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	f, err := os.OpenFile(runtimeLog(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	daemonLog.mu.Lock()
+	old := daemonLog.f
+	daemonLog.f = f
+	daemonLog.mu.Unlock()
+	return old.Close()
+}
+
+// requestHandoff takes over the generator state of the daemon currently
+// listening on the control socket (see serveHandoff). The predecessor holds
+// issuance while g is restored and stops only once told to commit; if
+// anything fails first it is told to abort and keeps running. It reports
+// false when no daemon is listening.
+func requestHandoff(g canonGen, shape string) (bool, error) {
+	conn, err := net.DialTimeout("unix", runtimeSock(), 2*time.Second)
+	if err != nil {
+		return false, nil
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := fmt.Fprintln(conn, "handoff "+shape); err != nil {
+		return false, err
+	}
+	dec := json.NewDecoder(conn)
+	var resp struct {
+		OK       bool   `json:"ok"`
+		Error    string `json:"error"`
+		LastTick int64  `json:"last_tick"`
+		LastSeq  int    `json:"last_seq"`
+	}
+	if err := dec.Decode(&resp); err != nil {
+		return false, fmt.Errorf("handoff: %v", err)
+	}
+	if !resp.OK {
+		return false, errors.New("handoff: " + resp.Error)
+	}
+	if err := restoreGen(g, resp.LastTick, resp.LastSeq); err != nil {
+		_, _ = fmt.Fprintln(conn, "abort")
+		return false, fmt.Errorf("handoff: %v", err)
+	}
+	if _, err := fmt.Fprintln(conn, "commit"); err != nil {
+		return false, fmt.Errorf("handoff: %v", err)
+	}
+	var ack struct {
+		OK bool `json:"ok"`
+	}
+	if err := dec.Decode(&ack); err != nil || !ack.OK {
+		return false, errors.New("handoff: predecessor did not confirm the commit")
+	}
+	return true, nil
+}
+
+// awaitHandoff waits for the predecessor to exit after handing off, failing
+// if the successor dies first.
+func awaitHandoff(cmd *exec.Cmd, oldPid int) error {
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	deadline := time.After(10 * time.Second)
+	for pidAlive(oldPid) {
+		select {
+		case err := <-exited:
+			return fmt.Errorf("wid-go start: successor exited during handoff (%v); pid=%d still running, see %s", err, oldPid, runtimeLog())
+		case <-deadline:
+			return fmt.Errorf("wid-go start: pid=%d did not exit after handoff", oldPid)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	return nil
+}
+
+// runCtl sends CMD= to the running daemon's control socket.
+func runCtl(c canon) int {
+	cmd := strings.TrimSpace(c.cmd)
+	if cmd == "" {
+		errln("A=ctl requires CMD=pause|resume|rotate-logs|stats|'set L=<sec>'")
+		return 1
+	}
+	conn, err := net.DialTimeout("unix", runtimeSock(), 2*time.Second)
+	if err != nil {
+		errln("wid-go ctl: daemon not reachable at " + runtimeSock() + ": " + err.Error())
+		return 1
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := fmt.Fprintln(conn, cmd); err != nil {
+		errln(err.Error())
+		return 1
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil && line == "" {
+		errln(err.Error())
+		return 1
+	}
+	fmt.Print(line)
+	var resp struct {
+		OK bool `json:"ok"`
+	}
+	if json.Unmarshal([]byte(line), &resp) != nil || !resp.OK {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	wid "github.com/waldiez/wid/go"
+)
+
+func cmdDiff(a, b string, o opts) int {
+	d, err := wid.Diff(a, b)
+	if err != nil {
+		errln(err.Error())
+		return 1
+	}
+	fields := []field{
+		{"a", d.A},
+		{"b", d.B},
+		{"kind", d.Kind},
+		{"elapsed", d.Elapsed.String()},
+		{"elapsed_ms", d.Elapsed.Milliseconds()},
+		{"same_tick", d.SameTick},
+		{"counter_delta", d.CounterDelta},
+		{"node_a", d.NodeA},
+		{"node_b", d.NodeB},
+		{"node_changed", d.NodeChanged},
+		{"same_generator", d.SameGenerator},
+		{"reason", d.Reason},
+	}
+	newEmitter(outputOr(o, "text")).emitTable(kvText(fields), fields...)
+	return 0
+}
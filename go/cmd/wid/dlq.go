@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// deliveryError reports records a transport gave up on after its retries.
+type deliveryError struct {
+	records []map[string]any
+	err     error
+}
+
+func (e *deliveryError) Error() string { return e.err.Error() }
+func (e *deliveryError) Unwrap() error { return e.err }
+
+func dlqPath(c canon) string {
+	return filepath.Join(dataDir(c), "dlq.ndjson")
+}
+
+// dlqPublisher persists records whose delivery failed to the on-disk DLQ so
+// they can be inspected with A=dlq-list and re-sent with A=dlq-replay.
+type dlqPublisher struct {
+	inner     publisher
+	path      string
+	transport string
+}
+
+func (p *dlqPublisher) publish(rec map[string]any) error {
+	return p.deadLetter(p.inner.publish(rec), rec)
+}
+
+func (p *dlqPublisher) close() error {
+	return p.deadLetter(p.inner.close(), nil)
+}
+
+func (p *dlqPublisher) deadLetter(err error, rec map[string]any) error {
+	if err == nil {
+		return nil
+	}
+	recs := []map[string]any{rec}
+	var de *deliveryError
+	if errors.As(err, &de) {
+		recs = de.records
+	} else if rec == nil {
+		return err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, r := range recs {
+		entry := map[string]any{
+			"dead_lettered_at": now,
+			"transport":        p.transport,
+			"error":            err.Error(),
+			"record":           r,
+		}
+		if werr := appendNDJSON(p.path, entry); werr != nil {
+			return fmt.Errorf("%v (dead-letter write failed: %v)", err, werr)
+		}
+	}
+	return fmt.Errorf("%w (%d record(s) dead-lettered to %s)", err, len(recs), p.path)
+}
+
+func runDLQList(c canon) int {
+	entries, err := readNDJSON(dlqPath(c))
+	if err != nil && !os.IsNotExist(err) {
+		errln(err.Error())
+		return 1
+	}
+	for _, e := range entries {
+		printJSON(e)
+	}
+	b, _ := json.Marshal(map[string]any{"dlq": dlqPath(c), "entries": len(entries)})
+	fmt.Fprintln(os.Stderr, string(b))
+	return 0
+}
+
+// runDLQReplay re-publishes dead-lettered records over R= and keeps only the
+// entries that fail again.
+func runDLQReplay(c canon) int {
+	path := dlqPath(c)
+	entries, err := readNDJSON(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("dlq empty")
+			return 0
+		}
+		errln(err.Error())
+		return 1
+	}
+	_, transport := parseStateTransport(c)
+	if transport == "auto" {
+		transport = "mqtt"
+	}
+	c.maxInflight = 0
+	pub, err := newPublisher(c, transport)
+	if err != nil {
+		errln(err.Error())
+		return 1
+	}
+	if dp, ok := pub.(*dlqPublisher); ok {
+		pub = dp.inner
+	}
+	var remaining []map[string]any
+	replayed := 0
+	for _, e := range entries {
+		rec, ok := e["record"].(map[string]any)
+		if !ok {
+			continue
+		}
+		if err := pub.publish(rec); err != nil {
+			e["error"] = err.Error()
+			remaining = append(remaining, e)
+			continue
+		}
+		replayed++
+	}
+	if err := pub.close(); err != nil {
+		// a batched tail that failed on flush stays in the DLQ
+		var de *deliveryError
+		if errors.As(err, &de) {
+			for _, r := range de.records {
+				remaining = append(remaining, map[string]any{
+					"dead_lettered_at": time.Now().UTC().Format(time.RFC3339),
+					"transport":        transport,
+					"error":            err.Error(),
+					"record":           r,
+				})
+			}
+			replayed -= len(de.records)
+		}
+	}
+	if err := rewriteNDJSON(path, remaining); err != nil {
+		errln(err.Error())
+		return 1
+	}
+	printJSON(map[string]any{"replayed": replayed, "remaining": len(remaining), "transport": transport})
+	if len(remaining) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func rewriteNDJSON(path string, recs []map[string]any) error {
+	if len(recs) == 0 {
+		err := os.Remove(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var sb strings.Builder
+	for _, r := range recs {
+		b, _ := json.Marshal(r)
+		b, err := sealRecord(b)
+		if err != nil {
+			return err
+		}
+		sb.Write(b)
+		sb.WriteByte('\n')
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(sb.String()), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// runDryRun describes what an action would do (directories and files
+// written, state keys touched, transports contacted) and exits without
+// performing any of it.
+func runDryRun(c canon) int {
+	var steps []string
+	step := func(format string, a ...any) { steps = append(steps, fmt.Sprintf(format, a...)) }
+	stateMode, transport := parseStateTransport(c)
+	action := c.a
+
+	switch c.a {
+	case "scaffold":
+		if strings.TrimSpace(c.d) == "" {
+			errln("D=<name> required for A=scaffold")
+			return 1
+		}
+		step("mkdir -p %s", filepath.Join(c.d, "state"))
+		step("mkdir -p %s", filepath.Join(c.d, "logs"))
+	case "start":
+		step("mkdir -p %s", runtimeDir())
+		step("check pid file %s for a running daemon (pid and start time)", runtimePid())
+		step("claim %s with O_EXCL", runtimePid())
+		step("append daemon output to %s", runtimeLog())
+		step("spawn: wid %s", strings.Join(daemonArgs(c), " "))
+		step("record the daemon pid and start time in %s", runtimePid())
+		c.a = "run"
+		describeServiceLoop(c, stateMode, transport, step)
+	case "stop":
+		step("send SIGTERM to the pid in %s and remove it", runtimePid())
+	case "run", "saf", "saf-wid", "wir", "wism", "wihp", "wipr", "duplex":
+		describeServiceLoop(c, stateMode, transport, step)
+	case "next", "stream":
+		if stateMode == "sql" {
+			step("mkdir -p %s", dataDir(c))
+			table := "wid_state"
+			if sealed, _ := sqlSealed(); sealed {
+				table = "wid_state_sealed (counters sealed with WID_DATA_KEY)"
+			}
+			step("sqlite3 %s: ensure table %s", sqlStatePath(c), table)
+			step("sqlite3 %s: compare-and-swap state key %s", sqlStatePath(c), sqlStateKey(c))
+		} else {
+			step("generate in memory; no files or transports touched")
+		}
+	case "dlq-replay":
+		if transport == "auto" {
+			transport = "mqtt"
+		}
+		step("read %s", dlqPath(c))
+		step("publish each dead-lettered record via R=%s%s", transport, urlSuffix(c, transport))
+		step("rewrite %s with the records that still fail", dlqPath(c))
+	case "hook":
+		step("run `sh -c %q` once per generated ID (N=%d, HOOK_CONCURRENCY=%d)", c.cmd, c.n, c.hookConc)
+	case "sign":
+		if strings.TrimSpace(c.out) != "" {
+			step("write signature to %s", c.out)
+		} else {
+			step("print signature to stdout; no files written")
+		}
+	default:
+		step("A=%s has no side effects beyond its output", c.a)
+	}
+
+	fmt.Printf("[dry-run] A=%s\n", action)
+	for _, s := range steps {
+		fmt.Printf("[dry-run]   %s\n", s)
+	}
+	return 0
+}
+
+func describeServiceLoop(c canon, stateMode, transport string, step func(string, ...any)) {
+	if transport == "auto" {
+		transport = "mqtt"
+	}
+	step("mkdir -p %s", dataDir(c))
+	count := "unbounded"
+	if c.n > 0 {
+		count = fmt.Sprintf("%d", c.n)
+	}
+	step("emit %s %s record(s) every %ds (W=%d Z=%d T=%s, state mode %s)", count, c.a, c.l, c.w, c.z, c.t, stateMode)
+	step("publish via R=%s%s", transport, urlSuffix(c, transport))
+	if c.maxInflight > 0 {
+		step("queue up to MAX_INFLIGHT=%d records (BACKPRESSURE=%s)", c.maxInflight, c.backpressure)
+		if c.backpressure == "spill" {
+			step("spill overflow to %s", filepath.Join(dataDir(c), "spill.ndjson"))
+		}
+	}
+	switch {
+	case c.a == "saf" || c.a == "saf-wid":
+		step("append each record to %s, forward it and record the ack offset in %s", safQueuePath(c), safAckPath(c))
+	case transport != "null":
+		step("dead-letter failed publishes to %s", dlqPath(c))
+	}
+}
+
+func urlSuffix(c canon, transport string) string {
+	if transport == "webhook" {
+		return " (POST " + c.url + ")"
+	}
+	return ""
+}
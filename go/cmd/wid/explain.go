@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	wid "github.com/waldiez/wid/go"
+)
+
+func ordinal(n int) string {
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+func cmdExplain(id string, o opts) int {
+	pw, ph, params, err := wid.ParseAny(id)
+	if err != nil {
+		if errors.Is(err, wid.ErrInvalidW) {
+			err = wid.ErrInvalidFormat
+		}
+		errln(fmt.Sprintf("cannot explain %q: %v", id, err))
+		return 1
+	}
+	kind, unit, w, z := params.Kind, params.TimeUnit, params.W, params.Z
+	var (
+		ts      time.Time
+		counter int
+		node    string
+		padding *string
+	)
+	if pw != nil {
+		ts, counter, padding = pw.Timestamp, pw.Sequence, pw.Padding
+	} else {
+		ts, counter, node, padding = ph.Timestamp, ph.LogicalCounter, ph.Node, ph.Padding
+	}
+
+	layout, period := "2006-01-02 15:04:05 MST", "second"
+	if unit == wid.TimeUnitMs {
+		layout, period = "2006-01-02 15:04:05.000 MST", "millisecond"
+	}
+	parts := []string{"generated " + ts.In(o.loc).Format(layout)}
+	if kind == "wid" {
+		parts = append(parts, fmt.Sprintf("%s ID in that %s (sequence %d of %d-digit width)", ordinal(counter+1), period, counter, w))
+	} else {
+		parts = append(parts, fmt.Sprintf("logical counter %d (%d-digit width)", counter, w), "node "+node)
+	}
+	if padding != nil {
+		parts = append(parts, fmt.Sprintf("%d-hex random suffix %s", z, *padding))
+	} else {
+		parts = append(parts, "no random suffix")
+	}
+	summary := fmt.Sprintf("%s (%s, %s precision): %s", id, strings.ToUpper(kind), unit, strings.Join(parts, ", "))
+
+	fields := []field{
+		{"id", id},
+		{"kind", kind},
+		{"time_unit", string(unit)},
+		{"W", w},
+		{"Z", z},
+		{"timestamp", ts.In(o.loc).Format(time.RFC3339Nano)},
+		{"counter", counter},
+		{"node", node},
+		{"padding", padding},
+		{"summary", summary},
+	}
+	newEmitter(outputOr(o, "text")).emit(summary, fields...)
+	return 0
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	wid "github.com/waldiez/wid/go"
+)
+
+var filterLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseFilterTime reads a --since/--until bound; values without an explicit
+// offset are interpreted in loc.
+func parseFilterTime(s string, loc *time.Location) (time.Time, error) {
+	for _, layout := range filterLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use RFC3339 or YYYY-MM-DD[ hh:mm[:ss]])", s)
+}
+
+// cmdFilter copies IDs from stdin to stdout when their embedded timestamp
+// falls within [--since, --until). Lines that are not WIDs are dropped.
+func cmdFilter(o opts) int {
+	var since, until time.Time
+	var err error
+	if o.since != "" {
+		if since, err = parseFilterTime(o.since, o.loc); err != nil {
+			errln(err.Error())
+			return 1
+		}
+	}
+	if o.until != "" {
+		if until, err = parseFilterTime(o.until, o.loc); err != nil {
+			errln(err.Error())
+			return 1
+		}
+	}
+	sc := bufio.NewScanner(os.Stdin)
+	for sc.Scan() {
+		id := strings.TrimSpace(sc.Text())
+		pw, ph, _, err := wid.ParseAny(id)
+		if err != nil {
+			continue
+		}
+		var ts time.Time
+		if pw != nil {
+			ts = pw.Timestamp
+		} else {
+			ts = ph.Timestamp
+		}
+		if !since.IsZero() && ts.Before(since) {
+			continue
+		}
+		if !until.IsZero() && !ts.Before(until) {
+			continue
+		}
+		fmt.Println(id)
+	}
+	if err := sc.Err(); err != nil {
+		errln(err.Error())
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// serveStatus exposes the loop's status on STATUS_ADDR at GET /status,
+// together with the build version and the daemon's clock for skew checks.
+func serveStatus(addr string, st *daemonStatus) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.Unmarshal(st.marshal(), &body)
+		body["impl"] = "wid-go"
+		body["version"] = version
+		body["go"] = runtime.Version()
+		body["now_ms"] = time.Now().UnixMilli()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(body)
+	})
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	select {
+	case err := <-errc:
+		return err
+	case <-time.After(100 * time.Millisecond):
+		return nil
+	}
+}
+
+// peerStatus is one row of A=fleet-status.
+type peerStatus struct {
+	Peer      string  `json:"peer"`
+	OK        bool    `json:"ok"`
+	Version   string  `json:"version,omitempty"`
+	Action    string  `json:"action,omitempty"`
+	State     string  `json:"state,omitempty"`
+	Emitted   int64   `json:"ids_emitted"`
+	Rate      float64 `json:"rate_per_sec"`
+	SkewMs    int64   `json:"skew_ms"`
+	Failures  int64   `json:"transport_failures"`
+	LastError string  `json:"last_error,omitempty"`
+}
+
+func peerStatusURL(peer string) string {
+	u := peer
+	if !strings.Contains(u, "://") {
+		u = "http://" + u
+	}
+	if !strings.Contains(strings.SplitN(u, "://", 2)[1], "/") {
+		u += "/status"
+	}
+	return u
+}
+
+func fetchPeerStatus(client *http.Client, peer string) peerStatus {
+	ps := peerStatus{Peer: peer}
+	t0 := time.Now()
+	resp, err := client.Get(peerStatusURL(peer))
+	if err != nil {
+		ps.LastError = err.Error()
+		return ps
+	}
+	defer resp.Body.Close()
+	t1 := time.Now()
+	if resp.StatusCode != http.StatusOK {
+		ps.LastError = fmt.Sprintf("HTTP %d", resp.StatusCode)
+		return ps
+	}
+	var body struct {
+		Version   string `json:"version"`
+		Action    string `json:"action"`
+		State     string `json:"state"`
+		StartedAt string `json:"started_at"`
+		Emitted   int64  `json:"ids_emitted"`
+		Failures  int64  `json:"transport_failures"`
+		LastError string `json:"last_error"`
+		NowMs     int64  `json:"now_ms"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		ps.LastError = "invalid status: " + err.Error()
+		return ps
+	}
+	ps.OK = true
+	ps.Version, ps.Action, ps.State = body.Version, body.Action, body.State
+	ps.Emitted, ps.Failures, ps.LastError = body.Emitted, body.Failures, body.LastError
+	// Skew is measured against the midpoint of the request round trip.
+	ps.SkewMs = body.NowMs - (t0.UnixMilli()+t1.UnixMilli())/2
+	if started, err := time.Parse(time.RFC3339, body.StartedAt); err == nil {
+		if up := time.UnixMilli(body.NowMs).Sub(started).Seconds(); up > 0 {
+			ps.Rate = float64(body.Emitted) / up
+		}
+	}
+	return ps
+}
+
+// runFleetStatus queries every PEERS= daemon concurrently and prints one row
+// per peer. It exits non-zero if any peer could not be reached.
+func runFleetStatus(c canon) int {
+	peers := splitList(c.peers)
+	if len(peers) == 0 {
+		errln("A=fleet-status requires PEERS=<host:port,...>")
+		return 1
+	}
+	client := &http.Client{Timeout: 3 * time.Second}
+	rows := make([]peerStatus, len(peers))
+	var wg sync.WaitGroup
+	for i, p := range peers {
+		wg.Add(1)
+		go func(i int, p string) {
+			defer wg.Done()
+			rows[i] = fetchPeerStatus(client, p)
+		}(i, p)
+	}
+	wg.Wait()
+
+	format := c.output
+	if format == "" {
+		format = "text"
+	}
+	e := newEmitter(format)
+	if format == "text" {
+		fmt.Printf("%-24s %-4s %-8s %-8s %10s %8s %9s %8s  %s\n", "PEER", "OK", "VERSION", "ACTION", "EMITTED", "RATE/S", "SKEW_MS", "FAILURES", "LAST_ERROR")
+	}
+	code := 0
+	for _, r := range rows {
+		if !r.OK {
+			code = 1
+		}
+		text := fmt.Sprintf("%-24s %-4t %-8s %-8s %10d %8.2f %9d %8d  %s",
+			r.Peer, r.OK, valueOrHash(r.Version), valueOrHash(r.Action), r.Emitted, r.Rate, r.SkewMs, r.Failures, r.LastError)
+		e.emit(text,
+			field{"peer", r.Peer}, field{"ok", r.OK}, field{"version", r.Version}, field{"action", r.Action},
+			field{"state", r.State}, field{"ids_emitted", r.Emitted}, field{"rate_per_sec", r.Rate},
+			field{"skew_ms", r.SkewMs}, field{"transport_failures", r.Failures}, field{"last_error", r.LastError})
+	}
+	return code
+}
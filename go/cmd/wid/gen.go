@@ -0,0 +1,34 @@
+package main
+
+import (
+	wid "github.com/waldiez/wid/go"
+)
+
+// canonGen is the generator surface canonical mode drives; KIND= selects a
+// plain WidGen or an HLCWidGen for NODE=.
+type canonGen interface {
+	Next() string
+	State() (int64, int)
+}
+
+func newCanonGen(c canon) (canonGen, error) {
+	if c.kind == "hlc" {
+		return wid.NewHLCWidGenWithUnit(c.node, c.w, c.z, c.t)
+	}
+	return wid.NewWidGenWithUnit(c.w, c.z, c.t)
+}
+
+// restoreGen resumes g from a persisted (tick, seq). A fresh SQL row stores
+// seq -1, which an HLC clock represents as counter 0 at that tick.
+func restoreGen(g canonGen, tick int64, seq int) error {
+	switch x := g.(type) {
+	case *wid.WidGen:
+		x.RestoreState(tick, seq)
+	case *wid.HLCWidGen:
+		if seq < 0 {
+			seq = 0
+		}
+		return x.RestoreState(tick, seq)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// errQueueFull is returned when a durable queue has reached MAX_QUEUE_BYTES.
+var errQueueFull = errors.New("queue byte limit (MAX_QUEUE_BYTES) reached")
+
+// guardrailCounts tallies guardrail interventions by kind; they are reported
+// in the daemon status and A=ctl CMD=stats.
+var guardrailCounts = struct {
+	mu sync.Mutex
+	n  map[string]int64
+}{n: map[string]int64{}}
+
+func noteGuardrail(kind string) {
+	guardrailCounts.mu.Lock()
+	guardrailCounts.n[kind]++
+	guardrailCounts.mu.Unlock()
+}
+
+func guardrailSnapshot() map[string]int64 {
+	guardrailCounts.mu.Lock()
+	defer guardrailCounts.mu.Unlock()
+	if len(guardrailCounts.n) == 0 {
+		return nil
+	}
+	out := make(map[string]int64, len(guardrailCounts.n))
+	for k, n := range guardrailCounts.n {
+		out[k] = n
+	}
+	return out
+}
+
+// queueHasRoom reports whether path can grow by n bytes under limit
+// (0 means unlimited).
+func queueHasRoom(path string, n int, limit int64) bool {
+	if limit <= 0 {
+		return true
+	}
+	var size int64
+	if fi, err := os.Stat(path); err == nil {
+		size = fi.Size()
+	}
+	return size+int64(n) <= limit
+}
+
+// rateLimiter spaces emissions at most rate per second (0 disables it).
+type rateLimiter struct {
+	gap  time.Duration
+	next time.Time
+}
+
+func newRateLimiter(rate float64) *rateLimiter {
+	if rate <= 0 {
+		return &rateLimiter{}
+	}
+	return &rateLimiter{gap: time.Duration(float64(time.Second) / rate)}
+}
+
+// wait blocks until the next emission is allowed.
+func (r *rateLimiter) wait() {
+	if r.gap == 0 {
+		return
+	}
+	now := time.Now()
+	if d := r.next.Sub(now); d > 0 {
+		noteGuardrail("rate_throttled")
+		time.Sleep(d)
+		now = r.next
+	}
+	r.next = now.Add(r.gap)
+}
+
+// enforceLogLimit rotates the daemon log once it exceeds limit bytes.
+func enforceLogLimit(limit int64) {
+	if limit <= 0 {
+		return
+	}
+	fi, err := os.Stat(runtimeLog())
+	if err != nil || fi.Size() <= limit {
+		return
+	}
+	if err := rotateLogs(); err != nil {
+		errln("log rotation: " + err.Error())
+		return
+	}
+	noteGuardrail("log_rotated")
+}
+
+// idleBackoff is how long the loop rests after consecutive transport
+// failures, so an unreachable broker does not turn L=0 into a busy loop.
+func idleBackoff(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	d := 100 * time.Millisecond << min(failures-1, 6)
+	return min(d, 5*time.Second)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// runHook generates IDs and runs CMD once per ID through `sh -c`, passing the
+// ID both as $WID and on stdin. HOOK_FAIL selects what a failing command does:
+// stop (default) halts generation, continue keeps going but exits 1, and
+// ignore keeps going and exits 0.
+func runHook(c canon) int {
+	if strings.TrimSpace(c.cmd) == "" {
+		errln("CMD=<command> required for A=hook")
+		return 1
+	}
+	if c.hookConc < 1 {
+		errln("HOOK_CONCURRENCY must be an integer >= 1")
+		return 1
+	}
+	switch c.hookFail {
+	case "stop", "continue", "ignore":
+	default:
+		errln("HOOK_FAIL must be stop, continue or ignore")
+		return 1
+	}
+	g, err := newCanonGen(c)
+	if err != nil {
+		errln(err.Error())
+		return 1
+	}
+
+	var (
+		wg      sync.WaitGroup
+		failed  atomic.Int64
+		stopped atomic.Bool
+	)
+	sem := make(chan struct{}, c.hookConc)
+	for i := 0; c.n == 0 || i < c.n; i++ {
+		sem <- struct{}{}
+		if stopped.Load() {
+			<-sem
+			break
+		}
+		id := g.Next()
+		wg.Add(1)
+		go func(idx int, id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := execHook(c.cmd, id, idx); err != nil {
+				failed.Add(1)
+				errln(fmt.Sprintf("hook failed for %s: %v", id, err))
+				if c.hookFail == "stop" {
+					stopped.Store(true)
+				}
+			}
+		}(i, id)
+	}
+	wg.Wait()
+	if failed.Load() > 0 && c.hookFail != "ignore" {
+		return 1
+	}
+	return 0
+}
+
+func execHook(command, id string, idx int) error {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Env = append(os.Environ(), "WID="+id, "WID_INDEX="+strconv.Itoa(idx))
+	cmd.Stdin = strings.NewReader(id + "\n")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	wid "github.com/waldiez/wid/go"
+)
+
+// sigEnvelope is the FORMAT=jws output of A=sign: a JWS with detached
+// payload (RFC 7515, appendix F) plus readable copies of the protected
+// header. The payload is the same "wid-sig-v1" message a bare signature
+// covers; the signing input is protected || "." || base64url(payload), so
+// the key ID and signing time are signed too. verify trusts only the
+// protected header and rejects envelopes whose copies disagree with it.
+type sigEnvelope struct {
+	Format    string `json:"format"`
+	WID       string `json:"wid"`
+	Alg       string `json:"alg"`
+	Kid       string `json:"kid,omitempty"`
+	SignedAt  string `json:"signed_at"`
+	Protected string `json:"protected"`
+	Signature string `json:"signature"`
+	TSA       string `json:"tsa_token,omitempty"`
+}
+
+type sigHeader struct {
+	Alg      string `json:"alg"`
+	Kid      string `json:"kid,omitempty"`
+	SignedAt string `json:"signed_at"`
+	WID      string `json:"wid"`
+}
+
+const envelopeFormat = "wid-jws-v1"
+
+func envelopeInput(protected string, msg []byte) []byte {
+	return []byte(protected + "." + b64urlEncode(msg))
+}
+
+// signingKeyInfo is the fingerprint and JWS algorithm of the KEY= signing
+// key. A PKCS#11 token, whose public half is not at hand, signs EdDSA with
+// no key ID.
+func signingKeyInfo(c canon) (kid, alg string, err error) {
+	if isPKCS11(c.key) {
+		return "", wid.AlgEdDSA, nil
+	}
+	pk, err := loadSigningKey(c.key)
+	if err != nil {
+		return "", "", err
+	}
+	alg, err = wid.SignatureAlgorithm(pk)
+	return wid.PublicKeyFingerprint(pk.Public()), alg, err
+}
+
+func runSignEnvelope(c canon, msg []byte) int {
+	kid, alg, err := signingKeyInfo(c)
+	if err != nil {
+		errln(err.Error())
+		return 1
+	}
+	env, err := buildEnvelope(c, msg, kid, alg, func(b []byte) ([]byte, error) { return signMessage(c, b) })
+	if err != nil {
+		errln(err.Error())
+		return 1
+	}
+	b, _ := json.Marshal(env)
+	return writeSignature(c, string(b))
+}
+
+// buildEnvelope signs msg for c.wid into an envelope, timestamped when TSA=
+// is set. alg is the JWS algorithm of the signing key.
+func buildEnvelope(c canon, msg []byte, kid, alg string, sign func([]byte) ([]byte, error)) (sigEnvelope, error) {
+	h := sigHeader{Alg: alg, Kid: kid, SignedAt: time.Now().UTC().Format(time.RFC3339), WID: c.wid}
+	hb, _ := json.Marshal(h)
+	protected := b64urlEncode(hb)
+	sig, err := sign(envelopeInput(protected, msg))
+	if err != nil {
+		return sigEnvelope{}, err
+	}
+	env := sigEnvelope{
+		Format: envelopeFormat, WID: h.WID, Alg: h.Alg, Kid: h.Kid, SignedAt: h.SignedAt,
+		Protected: protected, Signature: b64urlEncode(sig),
+	}
+	if strings.TrimSpace(c.tsa) != "" {
+		token, err := requestTimestamp(c.tsa, sig)
+		if err != nil {
+			return sigEnvelope{}, errors.New("timestamping failed: " + err.Error())
+		}
+		env.TSA = b64urlEncode(token)
+	}
+	return env, nil
+}
+
+// openEnvelope decodes an envelope and its protected header and checks the
+// two agree.
+func openEnvelope(raw string) (sigEnvelope, sigHeader, error) {
+	var env sigEnvelope
+	var h sigHeader
+	if err := json.Unmarshal([]byte(raw), &env); err != nil || env.Format != envelopeFormat {
+		return env, h, errors.New("SIG is not a " + envelopeFormat + " envelope")
+	}
+	hb, err := b64urlDecode(env.Protected)
+	if err != nil || json.Unmarshal(hb, &h) != nil {
+		return env, h, errors.New("invalid envelope protected header")
+	}
+	switch h.Alg {
+	case wid.AlgEdDSA, wid.AlgES256, wid.AlgPS256:
+	default:
+		return env, h, fmt.Errorf("unsupported envelope alg %q", h.Alg)
+	}
+	if env.WID != h.WID || env.Alg != h.Alg || env.Kid != h.Kid || env.SignedAt != h.SignedAt {
+		return env, h, errors.New("envelope fields do not match its protected header")
+	}
+	return env, h, nil
+}
+
+// verifyEnvelope checks one envelope. WID= is optional and, when given, must
+// match the envelope. ks holds the verifying keys, tried in the order the
+// envelope's kid suggests, or is nil to load KEY= for the envelope's WID
+// (certificate chains are checked at the WID's time).
+func verifyEnvelope(c canon, raw string, ks *wid.KeySet) manifestResult {
+	var r manifestResult
+	env, h, err := openEnvelope(raw)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	r.WID, r.SignedAt = h.WID, h.SignedAt
+	if w := strings.TrimSpace(c.wid); w != "" && w != h.WID {
+		r.Error = "envelope is for WID " + h.WID
+		return r
+	}
+	c.wid = h.WID
+	msg, err := buildSignVerifyMessage(c)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	if ks == nil {
+		if ks, r.Signer, err = loadVerifyKeySet(c); err != nil {
+			r.Error = err.Error()
+			return r
+		}
+	}
+	sig, err := b64urlDecode(env.Signature)
+	if err != nil {
+		r.Error = "invalid signature encoding"
+		return r
+	}
+	var ok bool
+	if r.Key, ok = ks.Verify(envelopeInput(env.Protected, msg), sig, h.Kid); !ok {
+		r.Error = "signature invalid"
+		return r
+	}
+	// the header's alg is signed, but must also be the verifying key's own
+	if alg, _ := wid.SignatureAlgorithm(ks.Key(r.Key)); alg != h.Alg {
+		r.Error = fmt.Sprintf("envelope alg %s does not match the %s key", h.Alg, alg)
+		return r
+	}
+	r.Alg = h.Alg
+	if env.TSA != "" {
+		token, err := b64urlDecode(env.TSA)
+		if err != nil {
+			r.Error = "invalid timestamp token encoding"
+			return r
+		}
+		at, _, err := verifyTimestamp(c, sig, token)
+		if err != nil {
+			r.Error = err.Error()
+			return r
+		}
+		r.Timestamp = at.UTC().Format(time.RFC3339Nano)
+	}
+	r.Valid = true
+	return r
+}
+
+// runVerifyEnvelope is A=verify for a FORMAT=jws SIG=.
+func runVerifyEnvelope(c canon, raw string) int {
+	r := verifyEnvelope(c, raw, nil)
+	if !r.Valid {
+		errln("Signature invalid: " + r.Error)
+		return 1
+	}
+	fmt.Println("Signature valid.")
+	fmt.Println("WID: " + r.WID)
+	fmt.Println("Signed at: " + r.SignedAt)
+	fmt.Println("Algorithm: " + r.Alg)
+	fmt.Println("Key: " + r.Key)
+	if r.Signer != "" {
+		fmt.Println("Signer: " + r.Signer)
+	}
+	if r.Timestamp != "" {
+		fmt.Println("Timestamp: " + r.Timestamp)
+	}
+	return 0
+}
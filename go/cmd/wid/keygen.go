@@ -0,0 +1,50 @@
+package main
+
+import (
+	wid "github.com/waldiez/wid/go"
+)
+
+// defaultKeyPath is where keygen writes without OUT= / --out. As with
+// ssh-keygen, the public key goes next to it with a ".pub" suffix.
+const defaultKeyPath = "wid_ed25519"
+
+// keygen writes a fresh Ed25519 keypair for A=sign/A=verify: the PKCS#8
+// private key at path (mode 0600, never overwritten) and the PKIX public key
+// at path+".pub".
+func keygen(path string, fingerprint bool, output string) int {
+	if path == "" {
+		path = defaultKeyPath
+	}
+	privPEM, pubPEM, err := wid.GenerateSigningKey(wid.KeyEd25519)
+	if err != nil {
+		errln(err.Error())
+		return 1
+	}
+	pubPath := path + ".pub"
+	if err := wid.WriteKeyPair(path, pubPath, privPEM, pubPEM); err != nil {
+		errln(err.Error())
+		return 1
+	}
+	text := "private_key=" + path + "\npublic_key=" + pubPath
+	fields := []field{{"private_key", path}, {"public_key", pubPath}, {"algorithm", string(wid.KeyEd25519)}}
+	if fingerprint {
+		pub, err := wid.ParsePublicKeyPEM(pubPEM)
+		if err != nil {
+			errln(err.Error())
+			return 1
+		}
+		fp := wid.PublicKeyFingerprint(pub)
+		text += "\nfingerprint=" + fp
+		fields = append(fields, field{"fingerprint", fp})
+	}
+	newEmitter(output).emitTable(text, fields...)
+	return 0
+}
+
+func runKeygen(c canon) int {
+	return keygen(c.out, c.fingerprint, outputOr(opts{output: c.output}, "text"))
+}
+
+func cmdKeygen(o opts) int {
+	return keygen(o.out, o.keyFp, outputOr(o, "text"))
+}
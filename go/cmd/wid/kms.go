@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	wid "github.com/waldiez/wid/go"
+)
+
+// KEY=awskms://, gcpkms:// and vault:// sign with a key held by a key
+// management service, so the private key never touches disk:
+//
+//	awskms://<key id|alias/name|arn>    AWS KMS, through the aws CLI
+//	gcpkms://projects/<p>/locations/<l>/keyRings/<r>/cryptoKeys/<k>/cryptoKeyVersions/<v>
+//	                                    Cloud KMS REST API; token from
+//	                                    $GOOGLE_OAUTH_ACCESS_TOKEN or gcloud
+//	vault://[<mount>/]<key>             Vault transit (mount "transit");
+//	                                    $VAULT_ADDR, $VAULT_TOKEN, $VAULT_NAMESPACE
+//
+// The service's public key decides the algorithm as for key files (see
+// wid.SignatureAlgorithm). ES256 and PS256 send only the SHA-256 digest;
+// Ed25519 (Cloud KMS, Vault) sends the message itself.
+type kmsSigner struct {
+	pub  crypto.PublicKey
+	sign func(data []byte, hash crypto.Hash) ([]byte, error)
+}
+
+func (s *kmsSigner) Public() crypto.PublicKey { return s.pub }
+
+// Sign returns a signature in the form crypto.Signer promises: ASN.1 for
+// ECDSA, raw for Ed25519 and RSA.
+func (s *kmsSigner) Sign(_ io.Reader, data []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.sign(data, opts.HashFunc())
+}
+
+var kmsSchemes = []string{"awskms://", "gcpkms://", "vault://"}
+
+func isKMSKey(key string) bool {
+	for _, s := range kmsSchemes {
+		if strings.HasPrefix(strings.TrimSpace(key), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadKMSSigner resolves a KMS key URI and fetches its public key.
+func loadKMSSigner(key string) (crypto.Signer, error) {
+	key = strings.TrimSpace(key)
+	var s *kmsSigner
+	var err error
+	switch {
+	case strings.HasPrefix(key, "awskms://"):
+		s, err = awsKMSSigner(strings.TrimLeft(strings.TrimPrefix(key, "awskms://"), "/"))
+	case strings.HasPrefix(key, "gcpkms://"):
+		s, err = gcpKMSSigner(strings.Trim(strings.TrimPrefix(key, "gcpkms://"), "/"))
+	default:
+		s, err = vaultSigner(strings.Trim(strings.TrimPrefix(key, "vault://"), "/"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := wid.SignatureAlgorithm(s.pub); err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	return s, nil
+}
+
+// awsKMSSigner signs through `aws kms sign` in DIGEST mode. AWS KMS holds
+// ECC_NIST_P256 and RSA keys.
+func awsKMSSigner(keyID string) (*kmsSigner, error) {
+	if keyID == "" {
+		return nil, errors.New("awskms:// needs a key id, alias or ARN")
+	}
+	if _, err := exec.LookPath("aws"); err != nil {
+		return nil, errors.New("KEY=awskms://... requires the aws CLI on PATH")
+	}
+	base := []string{"kms"}
+	if arn := strings.Split(keyID, ":"); len(arn) > 3 && arn[0] == "arn" {
+		base = append([]string{"--region", arn[3]}, base...)
+	}
+	var pk struct{ PublicKey string }
+	if err := runJSON("aws", append(base, "get-public-key", "--key-id", keyID, "--output", "json"), &pk); err != nil {
+		return nil, err
+	}
+	der, err := base64.StdEncoding.DecodeString(pk.PublicKey)
+	if err != nil {
+		return nil, errors.New("aws kms returned an invalid public key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	alg, _ := wid.SignatureAlgorithm(pub)
+	return &kmsSigner{pub: pub, sign: func(digest []byte, hash crypto.Hash) ([]byte, error) {
+		if hash != crypto.SHA256 {
+			return nil, errors.New("aws kms signs SHA-256 digests only")
+		}
+		dir, err := os.MkdirTemp("", "wid-kms-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+		in := filepath.Join(dir, "digest")
+		if err := os.WriteFile(in, digest, 0o600); err != nil {
+			return nil, err
+		}
+		algo := "ECDSA_SHA_256"
+		if alg == wid.AlgPS256 {
+			algo = "RSASSA_PSS_SHA_256"
+		}
+		var out struct{ Signature string }
+		if err := runJSON("aws", append(base, "sign", "--key-id", keyID, "--message", "fileb://"+in,
+			"--message-type", "DIGEST", "--signing-algorithm", algo, "--output", "json"), &out); err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.DecodeString(out.Signature)
+	}}, nil
+}
+
+// gcpKMSSigner signs with a Cloud KMS key version through the REST API.
+func gcpKMSSigner(name string) (*kmsSigner, error) {
+	if parts := strings.Split(name, "/"); len(parts) != 10 || parts[0] != "projects" || parts[8] != "cryptoKeyVersions" {
+		return nil, errors.New("gcpkms:// needs projects/<p>/locations/<l>/keyRings/<r>/cryptoKeys/<k>/cryptoKeyVersions/<v>")
+	}
+	hdr, err := gcpAuth()
+	if err != nil {
+		return nil, err
+	}
+	url := "https://cloudkms.googleapis.com/v1/" + name
+	var pk struct{ Pem string }
+	if err := kmsCall(http.MethodGet, url+"/publicKey", hdr, nil, &pk); err != nil {
+		return nil, err
+	}
+	pub, err := wid.ParseVerifyingKeyPEM([]byte(pk.Pem))
+	if err != nil {
+		return nil, err
+	}
+	return &kmsSigner{pub: pub, sign: func(data []byte, hash crypto.Hash) ([]byte, error) {
+		req := map[string]any{"data": data}
+		if hash == crypto.SHA256 {
+			req = map[string]any{"digest": map[string][]byte{"sha256": data}}
+		}
+		var out struct{ Signature []byte }
+		if err := kmsCall(http.MethodPost, url+":asymmetricSign", hdr, req, &out); err != nil {
+			return nil, err
+		}
+		return out.Signature, nil
+	}}, nil
+}
+
+// vaultSigner signs with a Vault transit key. PS256 asks for a salt as long
+// as the hash, which needs Vault 1.14 or newer.
+func vaultSigner(path string) (*kmsSigner, error) {
+	addr, mount, name, hdr, err := vaultConn(path)
+	if err != nil {
+		return nil, err
+	}
+	var kr struct {
+		Data struct {
+			Type          string `json:"type"`
+			LatestVersion int    `json:"latest_version"`
+			Keys          map[string]struct {
+				PublicKey string `json:"public_key"`
+			} `json:"keys"`
+		} `json:"data"`
+	}
+	if err := kmsCall(http.MethodGet, addr+"/v1/"+mount+"/keys/"+name, hdr, nil, &kr); err != nil {
+		return nil, err
+	}
+	raw := kr.Data.Keys[fmt.Sprint(kr.Data.LatestVersion)].PublicKey
+	var pub crypto.PublicKey
+	if kr.Data.Type == "ed25519" {
+		b, err := base64.StdEncoding.DecodeString(raw)
+		if err != nil || len(b) != ed25519.PublicKeySize {
+			return nil, errors.New("vault returned an invalid Ed25519 public key")
+		}
+		pub = ed25519.PublicKey(b)
+	} else {
+		var err error
+		if pub, err = wid.ParseVerifyingKeyPEM([]byte(raw)); err != nil {
+			return nil, fmt.Errorf("vault key type %q: %w", kr.Data.Type, err)
+		}
+	}
+	alg, _ := wid.SignatureAlgorithm(pub)
+	return &kmsSigner{pub: pub, sign: func(data []byte, hash crypto.Hash) ([]byte, error) {
+		req := map[string]any{"input": data, "key_version": kr.Data.LatestVersion}
+		if hash == crypto.SHA256 {
+			req["prehashed"], req["hash_algorithm"] = true, "sha2-256"
+		}
+		if alg == wid.AlgPS256 {
+			req["signature_algorithm"], req["salt_length"] = "pss", "hash"
+		}
+		var out struct {
+			Data struct {
+				Signature string `json:"signature"`
+			} `json:"data"`
+		}
+		if err := kmsCall(http.MethodPost, addr+"/v1/"+mount+"/sign/"+name, hdr, req, &out); err != nil {
+			return nil, err
+		}
+		// "vault:v<version>:<base64>"
+		parts := strings.SplitN(out.Data.Signature, ":", 3)
+		if len(parts) != 3 || parts[0] != "vault" {
+			return nil, errors.New("vault returned an invalid signature")
+		}
+		return base64.StdEncoding.DecodeString(parts[2])
+	}}, nil
+}
+
+// gcpAuth returns the Cloud KMS authorization header.
+func gcpAuth() (map[string]string, error) {
+	token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	if token == "" {
+		out, err := exec.Command("gcloud", "auth", "print-access-token").Output()
+		if err != nil {
+			return nil, errors.New("gcpkms:// needs $GOOGLE_OAUTH_ACCESS_TOKEN or a logged-in gcloud")
+		}
+		token = strings.TrimSpace(string(out))
+	}
+	return map[string]string{"Authorization": "Bearer " + token}, nil
+}
+
+// vaultConn splits a vault:// path into its transit mount and key name and
+// resolves the server address and request headers.
+func vaultConn(path string) (addr, mount, name string, hdr map[string]string, err error) {
+	mount, name = "transit", path
+	if i := strings.LastIndexByte(path, '/'); i >= 0 {
+		mount, name = path[:i], path[i+1:]
+	}
+	if name == "" {
+		return "", "", "", nil, errors.New("vault:// needs [<mount>/]<key>")
+	}
+	addr = strings.TrimRight(os.Getenv("VAULT_ADDR"), "/")
+	if addr == "" {
+		addr = "https://127.0.0.1:8200"
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			b, _ := os.ReadFile(filepath.Join(home, ".vault-token"))
+			token = strings.TrimSpace(string(b))
+		}
+	}
+	if token == "" {
+		return "", "", "", nil, errors.New("vault:// needs $VAULT_TOKEN (or ~/.vault-token)")
+	}
+	hdr = map[string]string{"X-Vault-Token": token}
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		hdr["X-Vault-Namespace"] = ns
+	}
+	return addr, mount, name, hdr, nil
+}
+
+// kmsDecrypt unwraps a data key that was encrypted with the KMS key at uri
+// (awskms://, gcpkms:// or vault://, as for KEY=). wrapped is the service's
+// own ciphertext: the base64 CiphertextBlob from `aws kms encrypt`, the
+// base64 ciphertext from Cloud KMS, or Vault's "vault:v<n>:..." string.
+// gcpkms:// names the crypto key; a trailing /cryptoKeyVersions/<v> is
+// ignored, since Cloud KMS picks the version from the ciphertext.
+func kmsDecrypt(uri, wrapped string) ([]byte, error) {
+	uri = strings.TrimSpace(uri)
+	switch {
+	case strings.HasPrefix(uri, "awskms://"):
+		keyID := strings.TrimLeft(strings.TrimPrefix(uri, "awskms://"), "/")
+		if _, err := exec.LookPath("aws"); err != nil {
+			return nil, errors.New("WID_DATA_KEY_KMS=awskms://... requires the aws CLI on PATH")
+		}
+		blob, err := base64.StdEncoding.DecodeString(wrapped)
+		if err != nil {
+			return nil, errors.New("awskms:// data key must be a base64 CiphertextBlob")
+		}
+		dir, err := os.MkdirTemp("", "wid-kms-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+		in := filepath.Join(dir, "blob")
+		if err := os.WriteFile(in, blob, 0o600); err != nil {
+			return nil, err
+		}
+		args := []string{"kms", "decrypt", "--ciphertext-blob", "fileb://" + in, "--output", "json"}
+		if keyID != "" {
+			args = append(args, "--key-id", keyID)
+			if arn := strings.Split(keyID, ":"); len(arn) > 3 && arn[0] == "arn" {
+				args = append([]string{"--region", arn[3]}, args...)
+			}
+		}
+		var out struct{ Plaintext string }
+		if err := runJSON("aws", args, &out); err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.DecodeString(out.Plaintext)
+	case strings.HasPrefix(uri, "gcpkms://"):
+		name := strings.Trim(strings.TrimPrefix(uri, "gcpkms://"), "/")
+		if i := strings.Index(name, "/cryptoKeyVersions/"); i >= 0 {
+			name = name[:i]
+		}
+		if parts := strings.Split(name, "/"); len(parts) != 8 || parts[0] != "projects" || parts[6] != "cryptoKeys" {
+			return nil, errors.New("gcpkms:// needs projects/<p>/locations/<l>/keyRings/<r>/cryptoKeys/<k>")
+		}
+		hdr, err := gcpAuth()
+		if err != nil {
+			return nil, err
+		}
+		var out struct{ Plaintext []byte }
+		if err := kmsCall(http.MethodPost, "https://cloudkms.googleapis.com/v1/"+name+":decrypt", hdr,
+			map[string]string{"ciphertext": wrapped}, &out); err != nil {
+			return nil, err
+		}
+		return out.Plaintext, nil
+	case strings.HasPrefix(uri, "vault://"):
+		addr, mount, name, hdr, err := vaultConn(strings.Trim(strings.TrimPrefix(uri, "vault://"), "/"))
+		if err != nil {
+			return nil, err
+		}
+		var out struct {
+			Data struct {
+				Plaintext string `json:"plaintext"`
+			} `json:"data"`
+		}
+		if err := kmsCall(http.MethodPost, addr+"/v1/"+mount+"/decrypt/"+name, hdr,
+			map[string]string{"ciphertext": wrapped}, &out); err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.DecodeString(out.Data.Plaintext)
+	}
+	return nil, errors.New("WID_DATA_KEY_KMS must be an awskms://, gcpkms:// or vault:// key URI")
+}
+
+// kmsCall sends a JSON request and decodes the JSON reply into out.
+func kmsCall(method, url string, hdr map[string]string, body, out any) error {
+	var rd io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rd = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, url, rd)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range hdr {
+		req.Header.Set(k, v)
+	}
+	resp, err := (&http.Client{Timeout: 15 * time.Second}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s %s: %s: %s", method, req.URL.Host, resp.Status, strings.TrimSpace(string(b)))
+	}
+	return json.Unmarshal(b, out)
+}
+
+// runJSON runs a command and decodes its JSON output into out.
+func runJSON(name string, args []string, out any) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = &stderr
+	b, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("%s failed: %v: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return json.Unmarshal(b, out)
+}
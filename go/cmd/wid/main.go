@@ -1,18 +1,21 @@
 package main
 
 import (
+	"bufio"
+	"crypto"
 	"crypto/ed25519"
 	"crypto/hmac"
 	"crypto/sha256"
 	"crypto/subtle"
-	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -28,36 +31,113 @@ type opts struct {
 	w        int
 	z        int
 	timeUnit wid.TimeUnit
+	unitSet  bool
 	count    int
-	json     bool
+	output   string
+	loc      *time.Location
+	since    string
+	until    string
+	from     string
+	to       string
+	redacted bool
+	gap      time.Duration
+	by       time.Duration
+	every    time.Duration
+	checksum bool
+	ns       string
+	layout   string
+	escNode  bool
+	out      string
+	keyFp    bool
+
+	coordinator string
+	worker      string
+	workers     int
+	state       string
+	dataDir     string
+
+	valid   int
+	invalid int
+	seed    int64
 }
 
 type canon struct {
-	a            string
-	w            int
-	l            int
-	d            string
-	i            string
-	e            string
-	z            int
-	t            wid.TimeUnit
-	r            string
-	m            bool
-	n            int
-	wid          string
-	key          string
-	sig          string
-	data         string
-	out          string
-	mode         string
-	code         string
-	digits       int
-	maxAgeSec    int
-	maxFutureSec int
+	a             string
+	w             int
+	l             int
+	d             string
+	i             string
+	e             string
+	z             int
+	t             wid.TimeUnit
+	r             string
+	m             bool
+	n             int
+	wid           string
+	key           string
+	sig           string
+	data          string
+	out           string
+	mode          string
+	code          string
+	format        string
+	in            string
+	digits        int
+	maxAgeSec     int
+	maxFutureSec  int
+	keyTTLSec     int
+	keyPin        string
+	cmd           string
+	hookConc      int
+	hookFail      string
+	url           string
+	webhookSecret string
+	batch         int
+	retries       int
+	backoffMs     int
+	maxInflight   int
+	backpressure  string
+	dryRun        bool
+	ca            string
+	identity      string
+	issuer        string
+	tsa           string
+	tsaCA         string
+	keys          string
+	sigs          string
+	threshold     int
+	bundle        string
+	token         string
+	expSec        int
+	chainKey      string
+	head          string
+	moduleOpts    string
+	output        string
+	fingerprint   bool
+	statusAddr    string
+	peers         string
+	handoff       bool
+	maxRate       float64
+	maxQueueBytes int64
+	maxLogBytes   int64
+	manifest      string
+	windowSec     int
+	stepSec       int
+	uri           bool
+	keyFormat     string
+	kind          string
+	node          string
+	rate          float64
+	durationSec   float64
+	jitterMs      float64
+	jumpSec       float64
+	jumpAtSec     float64
+	policy        string
+	seed          int64
 }
 
 var localServiceTransports = map[string]bool{
-	"mqtt": true, "ws": true, "redis": true, "null": true, "stdout": true,
+	"mqtt": true, "ws": true, "redis": true, "null": true, "stdout": true, "webhook": true,
 }
 
 func main() {
@@ -68,6 +148,16 @@ func main() {
 	}
 
 	if args[0] == "__daemon" {
+		daemonMode = true
+		if err := startDaemonLog(); err != nil {
+			errln("service log: " + err.Error())
+			exit(1)
+		}
+		if secret, ok := os.LookupEnv(daemonSecretEnv); ok {
+			// Kept out of the environment hooks and transports inherit.
+			_ = os.Unsetenv(daemonSecretEnv)
+			args = append(args, "WEBHOOK_SECRET="+secret)
+		}
 		exit(runCanonical(args[1:]))
 		return
 	}
@@ -87,6 +177,15 @@ func main() {
 	case "selftest":
 		exit(runSelftest())
 		return
+	case "profile":
+		exit(cmdProfile(args[1:]))
+	case "version", "--version":
+		o, err := parseOpts(args[1:], false)
+		if err != nil {
+			errln(err.Error())
+			os.Exit(1)
+		}
+		exit(cmdVersion(o))
 	case "completion":
 		if len(args) < 2 {
 			fmt.Fprintln(os.Stderr, "usage: wid completion bash|zsh|fish")
@@ -130,6 +229,75 @@ func main() {
 			os.Exit(1)
 		}
 		exit(cmdParse(args[1], o))
+	case "explain":
+		if len(args) < 2 {
+			errln("explain requires an id")
+			os.Exit(1)
+		}
+		o, err := parseOpts(args[2:], false)
+		if err != nil {
+			errln(err.Error())
+			os.Exit(1)
+		}
+		exit(cmdExplain(args[1], o))
+	case "diff":
+		if len(args) < 3 {
+			errln("diff requires two ids")
+			os.Exit(1)
+		}
+		o, err := parseOpts(args[3:], false)
+		if err != nil {
+			errln(err.Error())
+			os.Exit(1)
+		}
+		exit(cmdDiff(args[1], args[2], o))
+	case "decode":
+		if len(args) < 2 {
+			errln("decode requires a value")
+			os.Exit(1)
+		}
+		o, err := parseOpts(args[2:], false)
+		if err != nil {
+			errln(err.Error())
+			os.Exit(1)
+		}
+		exit(cmdDecode(args[1], o))
+	case "encode":
+		id, rest := "", args[1:]
+		if len(rest) > 0 && (rest[0] == "-" || !strings.HasPrefix(rest[0], "-")) {
+			id, rest = rest[0], rest[1:]
+		}
+		o, err := parseOpts(rest, false)
+		if err != nil {
+			errln(err.Error())
+			os.Exit(1)
+		}
+		exit(cmdEncode(id, o))
+	case "filter":
+		o, err := parseOpts(args[1:], false)
+		if err != nil {
+			errln(err.Error())
+			os.Exit(1)
+		}
+		exit(cmdFilter(o))
+	case "stats":
+		o, err := parseOpts(args[1:], false)
+		if err != nil {
+			errln(err.Error())
+			os.Exit(1)
+		}
+		exit(cmdStats(o))
+	case "watch":
+		path, rest := "", args[1:]
+		if len(rest) > 0 && !strings.HasPrefix(rest[0], "--") {
+			path, rest = rest[0], rest[1:]
+		}
+		o, err := parseOpts(rest, false)
+		if err != nil {
+			errln(err.Error())
+			os.Exit(1)
+		}
+		exit(cmdWatch(path, o))
 	case "healthcheck":
 		o, err := parseOpts(args[1:], false)
 		if err != nil {
@@ -144,6 +312,27 @@ func main() {
 			os.Exit(1)
 		}
 		exit(cmdBench(o))
+	case "bucket":
+		o, err := parseOpts(args[1:], false)
+		if err != nil {
+			errln(err.Error())
+			os.Exit(1)
+		}
+		exit(cmdBucket(o))
+	case "corpus":
+		o, err := parseOpts(args[1:], false)
+		if err != nil {
+			errln(err.Error())
+			os.Exit(1)
+		}
+		exit(cmdCorpus(o))
+	case "keygen":
+		o, err := parseOpts(args[1:], false)
+		if err != nil {
+			errln(err.Error())
+			os.Exit(1)
+		}
+		exit(cmdKeygen(o))
 	default:
 		errln("unknown command: " + args[0])
 		os.Exit(2)
@@ -160,6 +349,10 @@ func hasKVArg(args []string) bool {
 }
 
 func parseOpts(args []string, allowCount bool) (opts, error) {
+	args, err := withProfileFlags(args)
+	if err != nil {
+		return opts{}, err
+	}
 	o := opts{
 		kind:     "wid",
 		node:     "go",
@@ -167,7 +360,10 @@ func parseOpts(args []string, allowCount bool) (opts, error) {
 		z:        6,
 		timeUnit: wid.TimeUnitSec,
 		count:    0,
-		json:     false,
+		output:   "",
+		loc:      time.UTC,
+		seed:     1,
+		by:       time.Hour,
 	}
 	for i := 0; i < len(args); i++ {
 		switch args[i] {
@@ -212,6 +408,7 @@ func parseOpts(args []string, allowCount bool) (opts, error) {
 				return o, err
 			}
 			o.timeUnit = u
+			o.unitSet = true
 			i++
 		case "--count":
 			if !allowCount {
@@ -227,7 +424,163 @@ func parseOpts(args []string, allowCount bool) (opts, error) {
 			o.count = n
 			i++
 		case "--json":
-			o.json = true
+			o.output = "json"
+		case "--no-color":
+			noColor = true
+		case "--tz":
+			if i+1 >= len(args) {
+				return o, errors.New("missing value for --tz")
+			}
+			loc, err := time.LoadLocation(args[i+1])
+			if err != nil {
+				return o, fmt.Errorf("unknown time zone: %s", args[i+1])
+			}
+			o.loc = loc
+			i++
+		case "--local":
+			o.loc = time.Local
+		case "--redacted":
+			o.redacted = true
+		case "--checksum":
+			o.checksum = true
+		case "--escape-node":
+			o.escNode = true
+		case "--fingerprint":
+			o.keyFp = true
+		case "--out":
+			if i+1 >= len(args) {
+				return o, errors.New("missing value for --out")
+			}
+			o.out = args[i+1]
+			i++
+		case "--namespace":
+			if i+1 >= len(args) {
+				return o, errors.New("missing value for --namespace")
+			}
+			if !wid.ValidNamespace(args[i+1]) {
+				return o, errors.New(wid.ErrInvalidNamespace.Error())
+			}
+			o.ns = args[i+1]
+			i++
+		case "--coordinator", "--worker", "--state", "--data-dir":
+			if i+1 >= len(args) {
+				return o, fmt.Errorf("missing value for %s", args[i])
+			}
+			switch args[i] {
+			case "--coordinator":
+				o.coordinator = args[i+1]
+			case "--worker":
+				o.worker = args[i+1]
+			case "--state":
+				if args[i+1] != "memory" && args[i+1] != "sql" {
+					return o, errors.New("--state must be memory or sql")
+				}
+				o.state = args[i+1]
+			default:
+				o.dataDir = args[i+1]
+			}
+			i++
+		case "--workers":
+			if i+1 >= len(args) {
+				return o, errors.New("missing value for --workers")
+			}
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n < 1 {
+				return o, errors.New("invalid integer for --workers")
+			}
+			o.workers = n
+			i++
+		case "--valid", "--invalid":
+			if i+1 >= len(args) {
+				return o, fmt.Errorf("missing value for %s", args[i])
+			}
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n < 0 {
+				return o, fmt.Errorf("invalid integer for %s", args[i])
+			}
+			if args[i] == "--valid" {
+				o.valid = n
+			} else {
+				o.invalid = n
+			}
+			i++
+		case "--seed":
+			if i+1 >= len(args) {
+				return o, errors.New("missing value for --seed")
+			}
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil {
+				return o, errors.New("invalid integer for --seed")
+			}
+			o.seed = n
+			i++
+		case "--gap":
+			if i+1 >= len(args) {
+				return o, errors.New("missing value for --gap")
+			}
+			d, err := time.ParseDuration(args[i+1])
+			if err != nil || d < 0 {
+				return o, errors.New("invalid duration for --gap")
+			}
+			o.gap = d
+			i++
+		case "--every":
+			if i+1 >= len(args) {
+				return o, errors.New("missing value for --every")
+			}
+			d, err := time.ParseDuration(args[i+1])
+			if err != nil || d <= 0 {
+				return o, errors.New("invalid duration for --every")
+			}
+			o.every = d
+			i++
+		case "--by":
+			if i+1 >= len(args) {
+				return o, errors.New("missing value for --by")
+			}
+			d, err := time.ParseDuration(args[i+1])
+			if err != nil || d <= 0 {
+				return o, errors.New("invalid duration for --by")
+			}
+			o.by = d
+			i++
+		case "--layout":
+			if i+1 >= len(args) {
+				return o, errors.New("missing value for --layout")
+			}
+			o.layout = args[i+1]
+			i++
+		case "--from":
+			if i+1 >= len(args) {
+				return o, errors.New("missing value for --from")
+			}
+			o.from = args[i+1]
+			i++
+		case "--to":
+			if i+1 >= len(args) {
+				return o, errors.New("missing value for --to")
+			}
+			o.to = args[i+1]
+			i++
+		case "--since", "--until":
+			if i+1 >= len(args) {
+				return o, fmt.Errorf("missing value for %s", args[i])
+			}
+			if args[i] == "--since" {
+				o.since = args[i+1]
+			} else {
+				o.until = args[i+1]
+			}
+			i++
+		case "--output", "-o":
+			if i+1 >= len(args) {
+				return o, errors.New("missing value for --output")
+			}
+			if !outputFormats[args[i+1]] {
+				return o, errors.New("--output must be one of: text, json, ndjson, csv")
+			}
+			o.output = args[i+1]
+			i++
 		default:
 			return o, fmt.Errorf("unknown flag: %s", args[i])
 		}
@@ -241,20 +594,56 @@ func parseOpts(args []string, allowCount bool) (opts, error) {
 	if o.z < 0 || o.count < 0 {
 		return o, errors.New("Z/count must be >= 0")
 	}
+	if o.kind == "hlc" && o.escNode {
+		node, err := wid.EscapeNode(o.node)
+		if err != nil {
+			return o, errors.New("invalid node")
+		}
+		o.node = node
+	}
 	if o.kind == "hlc" && !wid.IsValidNode(o.node) {
 		return o, errors.New("invalid node")
 	}
 	return o, nil
 }
 
+// withProfileFlags strips --profile and prepends the active profile's
+// settings as flags.
+func withProfileFlags(args []string) ([]string, error) {
+	explicit := ""
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--profile" {
+			if i+1 >= len(args) {
+				return nil, errors.New("missing value for --profile")
+			}
+			explicit = args[i+1]
+			i++
+			continue
+		}
+		rest = append(rest, args[i])
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	_, p, err := cfg.activeProfile(explicit)
+	if err != nil {
+		return nil, err
+	}
+	return append(profileFlagArgs(p), rest...), nil
+}
+
 func cmdNext(o opts) int {
+	e := newEmitter(outputOr(o, "text"))
 	if o.kind == "wid" {
-		g, err := wid.NewWidGenWithUnit(o.w, o.z, o.timeUnit)
+		g, err := newWidGen(o)
 		if err != nil {
 			errln(err.Error())
 			return 1
 		}
-		fmt.Println(g.Next())
+		g.SetChecksum(o.checksum)
+		emitID(e, g.Next())
 		return 0
 	}
 	g, err := wid.NewHLCWidGenWithUnit(o.node, o.w, o.z, o.timeUnit)
@@ -262,19 +651,22 @@ func cmdNext(o opts) int {
 		errln(err.Error())
 		return 1
 	}
-	fmt.Println(g.Next())
+	g.SetChecksum(o.checksum)
+	emitID(e, g.Next())
 	return 0
 }
 
 func cmdStream(o opts) int {
+	e := newEmitter(outputOr(o, "text"))
 	if o.kind == "wid" {
-		g, err := wid.NewWidGenWithUnit(o.w, o.z, o.timeUnit)
+		g, err := newWidGen(o)
 		if err != nil {
 			errln(err.Error())
 			return 1
 		}
+		g.SetChecksum(o.checksum)
 		for i := 0; o.count == 0 || i < o.count; i++ {
-			fmt.Println(g.Next())
+			emitID(e, g.Next())
 		}
 		return 0
 	}
@@ -283,24 +675,142 @@ func cmdStream(o opts) int {
 		errln(err.Error())
 		return 1
 	}
+	g.SetChecksum(o.checksum)
 	for i := 0; o.count == 0 || i < o.count; i++ {
-		fmt.Println(g.Next())
+		emitID(e, g.Next())
 	}
 	return 0
 }
 
-func cmdValidate(id string, o opts) int {
-	ok := false
+// newWidGen returns the WID generator for o, namespaced with --namespace.
+func newWidGen(o opts) (*wid.WidGen, error) {
+	if o.ns != "" {
+		return wid.NewWidGenWithNamespace(o.ns, o.w, o.z, o.timeUnit)
+	}
+	return wid.NewWidGenWithUnit(o.w, o.z, o.timeUnit)
+}
+
+func outputOr(o opts, def string) string {
+	if o.output == "" {
+		return def
+	}
+	return o.output
+}
+
+func emitID(e *emitter, id string) {
+	e.emit(id, field{"wid", id})
+}
+
+// detectUnit tries ms then sec when --time-unit was not given; the
+// timestamp's digit count means at most one of them can match. It returns
+// the unit to use and whether it was auto-detected.
+func detectUnit(o opts, matches func(wid.TimeUnit) bool) (wid.TimeUnit, bool) {
+	if o.unitSet {
+		return o.timeUnit, false
+	}
+	for _, u := range []wid.TimeUnit{wid.TimeUnitMs, wid.TimeUnitSec} {
+		if matches(u) {
+			return u, true
+		}
+	}
+	return o.timeUnit, false
+}
+
+// noteDetectedUnit reports an auto-detected unit on stderr for text output
+// (structured output carries it as time_unit_detected).
+func noteDetectedUnit(o opts, u wid.TimeUnit, detected bool) {
+	if detected && outputOr(o, "text") == "text" {
+		fmt.Fprintf(os.Stderr, "time unit: %s (auto-detected)\n", u)
+	}
+}
+
+// unitFields are the extra structured fields reported when the unit was not
+// given explicitly.
+func unitFields(o opts, unit wid.TimeUnit, detected bool) []field {
+	if o.unitSet {
+		return nil
+	}
+	return []field{{"time_unit", string(unit)}, {"time_unit_detected", detected}}
+}
+
+func validateWithUnit(id string, o opts, unit wid.TimeUnit) bool {
+	// TTL-bearing IDs (<id>~<seconds>) are rejected once expired.
+	switch {
+	case o.redacted && o.kind == "wid":
+		return wid.ValidateRedactedWid(id, o.w, o.z, unit)
+	case o.redacted:
+		return wid.ValidateRedactedHlcWid(id, o.w, o.z, unit)
+	case o.kind == "wid":
+		return wid.ValidateWidWithTTL(id, o.w, o.z, unit, time.Now())
+	default:
+		return wid.ValidateHlcWidWithTTL(id, o.w, o.z, unit, time.Now())
+	}
+}
+
+// invalidReason explains why validateWithUnit rejected id.
+func invalidReason(id string, o opts, unit wid.TimeUnit) string {
+	if o.redacted {
+		return "not a valid redacted ID"
+	}
+	base, _, hasTTL, err := wid.SplitTTL(id)
+	if err != nil {
+		return err.Error()
+	}
 	if o.kind == "wid" {
-		ok = wid.ValidateWidWithUnit(id, o.w, o.z, o.timeUnit)
+		err = wid.ValidateWidDetailed(base, o.w, o.z, unit)
 	} else {
-		ok = wid.ValidateHlcWidWithUnit(id, o.w, o.z, o.timeUnit)
+		err = wid.ValidateHlcWidDetailed(base, o.w, o.z, unit)
 	}
+	if err == nil && hasTTL {
+		return "TTL expired"
+	}
+	if err == nil {
+		return "invalid"
+	}
+	return err.Error()
+}
+
+func cmdValidate(id string, o opts) int {
+	// With --checksum the check character is verified and stripped first,
+	// then with --namespace the "_<ns>" suffix.
+	base, preErr := id, error(nil)
+	if o.checksum {
+		if base, preErr = wid.VerifyChecksum(id); preErr != nil {
+			preErr = fmt.Errorf("checksum: %w", preErr)
+		}
+	}
+	if o.ns != "" && preErr == nil {
+		var found bool
+		if base, found = strings.CutSuffix(base, wid.NamespaceSeparator+o.ns); !found {
+			preErr = errors.New("namespace: missing _" + o.ns + " suffix")
+		}
+	}
+	unit, detected := detectUnit(o, func(u wid.TimeUnit) bool { return preErr == nil && validateWithUnit(base, o, u) })
+	ok := preErr == nil && validateWithUnit(base, o, unit)
+	noteDetectedUnit(o, unit, detected)
+	fields := []field{
+		{"id", id},
+		{"valid", ok},
+		{"kind", o.kind},
+		{"time_unit", string(unit)},
+	}
+	if !o.unitSet {
+		fields = append(fields, field{"time_unit_detected", detected})
+	}
+	if !ok {
+		reason := fmt.Sprint(preErr)
+		if preErr == nil {
+			reason = invalidReason(base, o, unit)
+		}
+		fields = append(fields, field{"error", reason})
+		if outputOr(o, "text") == "text" {
+			fmt.Fprintln(os.Stderr, "invalid: "+reason)
+		}
+	}
+	newEmitter(outputOr(o, "text")).emit(strconv.FormatBool(ok), fields...)
 	if ok {
-		fmt.Println("true")
 		return 0
 	}
-	fmt.Println("false")
 	return 1
 }
 
@@ -311,53 +821,72 @@ func cmdParse(id string, o opts) int {
 		}
 		return *p
 	}
+	e := newEmitter(outputOr(o, "text"))
+	// --namespace opts in to the "_<ns>" suffix, which must then be o.ns.
+	parseWid := func(u wid.TimeUnit) (*wid.ParsedWid, error) {
+		if o.ns == "" {
+			return wid.ParseWidWithUnit(id, o.w, o.z, u)
+		}
+		p, err := wid.ParseNamespacedWid(id, o.w, o.z, u)
+		if err == nil && p.Namespace != o.ns {
+			return nil, wid.ErrInvalidNamespace
+		}
+		return p, err
+	}
+	unit, detected := detectUnit(o, func(u wid.TimeUnit) bool {
+		if o.kind == "wid" {
+			_, err := parseWid(u)
+			return err == nil
+		}
+		_, err := wid.ParseHlcWidWithUnit(id, o.w, o.z, u)
+		return err == nil
+	})
+	noteDetectedUnit(o, unit, detected)
 	if o.kind == "wid" {
-		p, err := wid.ParseWidWithUnit(id, o.w, o.z, o.timeUnit)
+		p, err := parseWid(unit)
 		if err != nil {
 			fmt.Println("null")
 			return 1
 		}
-		ts := p.Timestamp.UTC().Format(time.RFC3339)
-		if o.json {
-			payload := map[string]any{
-				"raw":       p.Raw,
-				"timestamp": ts,
-				"sequence":  p.Sequence,
-				"padding":   p.Padding,
-			}
-			b, _ := json.Marshal(payload)
-			fmt.Println(string(b))
-		} else {
-			fmt.Printf("raw=%s\n", p.Raw)
-			fmt.Printf("timestamp=%s\n", ts)
-			fmt.Printf("sequence=%d\n", p.Sequence)
-			fmt.Printf("padding=%s\n", padStr(p.Padding))
+		ts := p.Timestamp.In(o.loc).Format(time.RFC3339)
+		text := fmt.Sprintf("raw=%s\ntimestamp=%s\nsequence=%d\npadding=%s", p.Raw, ts, p.Sequence, padStr(p.Padding))
+		fields := []field{
+			{"raw", p.Raw},
+			{"timestamp", ts},
+			{"sequence", p.Sequence},
+			{"padding", p.Padding},
+		}
+		if p.Namespace != "" {
+			text += "\nnamespace=" + p.Namespace
+			fields = append(fields, field{"namespace", p.Namespace})
 		}
+		e.emitTable(text, append(fields, unitFields(o, unit, detected)...)...)
 		return 0
 	}
-	p, err := wid.ParseHlcWidWithUnit(id, o.w, o.z, o.timeUnit)
+	p, err := wid.ParseHlcWidWithUnit(id, o.w, o.z, unit)
 	if err != nil {
 		fmt.Println("null")
 		return 1
 	}
-	ts := p.Timestamp.UTC().Format(time.RFC3339)
-	if o.json {
-		payload := map[string]any{
-			"raw":             p.Raw,
-			"timestamp":       ts,
-			"logical_counter": p.LogicalCounter,
-			"node":            p.Node,
-			"padding":         p.Padding,
+	ts := p.Timestamp.In(o.loc).Format(time.RFC3339)
+	text := fmt.Sprintf("raw=%s\ntimestamp=%s\nlogical_counter=%d\nnode=%s\npadding=%s", p.Raw, ts, p.LogicalCounter, p.Node, padStr(p.Padding))
+	fields := []field{
+		{"raw", p.Raw},
+		{"timestamp", ts},
+		{"logical_counter", p.LogicalCounter},
+		{"node", p.Node},
+		{"padding", p.Padding},
+	}
+	if o.escNode {
+		name, err := wid.UnescapeNode(p.Node)
+		if err != nil {
+			errln(err.Error())
+			return 1
 		}
-		b, _ := json.Marshal(payload)
-		fmt.Println(string(b))
-	} else {
-		fmt.Printf("raw=%s\n", p.Raw)
-		fmt.Printf("timestamp=%s\n", ts)
-		fmt.Printf("logical_counter=%d\n", p.LogicalCounter)
-		fmt.Printf("node=%s\n", p.Node)
-		fmt.Printf("padding=%s\n", padStr(p.Padding))
+		text += "\nnode_name=" + name
+		fields = append(fields, field{"node_name", name})
 	}
+	e.emitTable(text, append(fields, unitFields(o, unit, detected)...)...)
 	return 0
 }
 
@@ -381,20 +910,15 @@ func cmdHealthcheck(o opts) int {
 		sample = g.Next()
 		ok = wid.ValidateHlcWidWithUnit(sample, o.w, o.z, o.timeUnit)
 	}
-	if o.json {
-		payload := map[string]any{
-			"ok":        ok,
-			"kind":      o.kind,
-			"W":         o.w,
-			"Z":         o.z,
-			"time_unit": string(o.timeUnit),
-			"sample_id": sample,
-		}
-		b, _ := json.Marshal(payload)
-		fmt.Println(string(b))
-	} else {
-		fmt.Printf("ok=%v kind=%s sample=%s\n", ok, o.kind, sample)
-	}
+	newEmitter(outputOr(o, "text")).emit(
+		fmt.Sprintf("ok=%v kind=%s sample=%s", ok, o.kind, sample),
+		field{"ok", ok},
+		field{"kind", o.kind},
+		field{"W", o.w},
+		field{"Z", o.z},
+		field{"time_unit", string(o.timeUnit)},
+		field{"sample_id", sample},
+	)
 	if ok {
 		return 0
 	}
@@ -402,6 +926,12 @@ func cmdHealthcheck(o opts) int {
 }
 
 func cmdBench(o opts) int {
+	switch {
+	case o.coordinator != "":
+		return runBenchCoordinator(o)
+	case o.worker != "":
+		return runBenchWorker(o)
+	}
 	n := o.count
 	if n <= 0 {
 		n = 100000
@@ -430,18 +960,19 @@ func cmdBench(o opts) int {
 	if secs <= 0 {
 		secs = 1e-9
 	}
-	payload := map[string]any{
-		"impl":        "go",
-		"kind":        o.kind,
-		"W":           o.w,
-		"Z":           o.z,
-		"time_unit":   string(o.timeUnit),
-		"n":           n,
-		"seconds":     secs,
-		"ids_per_sec": float64(n) / secs,
-	}
-	b, _ := json.Marshal(payload)
-	fmt.Println(string(b))
+	rate := float64(n) / secs
+	// bench has always reported JSON, so that stays its default format
+	newEmitter(outputOr(o, "json")).emit(
+		fmt.Sprintf("impl=go kind=%s n=%d seconds=%.6f ids_per_sec=%.0f", o.kind, n, secs, rate),
+		field{"impl", "go"},
+		field{"kind", o.kind},
+		field{"W", o.w},
+		field{"Z", o.z},
+		field{"time_unit", string(o.timeUnit)},
+		field{"n", n},
+		field{"seconds", secs},
+		field{"ids_per_sec", rate},
+	)
 	return 0
 }
 
@@ -455,6 +986,18 @@ func runCanonical(args []string) int {
 		printActions()
 		return 0
 	}
+	if c.dryRun {
+		return runDryRun(c)
+	}
+	if c.a == "keygen" {
+		return runKeygen(c)
+	}
+	if c.a == "token" {
+		return runToken(c)
+	}
+	if c.a == "token-verify" {
+		return runTokenVerify(c)
+	}
 	if c.a == "sign" {
 		return runSign(c)
 	}
@@ -464,6 +1007,24 @@ func runCanonical(args []string) int {
 	if c.a == "w-otp" {
 		return runWOtp(c)
 	}
+	if c.a == "paseto" {
+		return runPaseto(c)
+	}
+	if c.a == "chain-verify" {
+		return runChainVerify(c)
+	}
+	if c.chainKey != "" && (c.a == "next" || c.a == "stream") {
+		return runChained(c)
+	}
+	if c.a == "hook" {
+		return runHook(c)
+	}
+	if c.a == "observe" {
+		return runObserve(c)
+	}
+	if c.a == "simulate" {
+		return runSimulate(c)
+	}
 	stateMode, _ := parseStateTransport(c)
 	if stateMode == "sql" && (c.a == "next" || c.a == "stream") {
 		switch c.a {
@@ -475,11 +1036,11 @@ func runCanonical(args []string) int {
 	}
 	switch c.a {
 	case "next":
-		return cmdNext(opts{kind: "wid", w: c.w, z: c.z, timeUnit: c.t})
+		return cmdNext(opts{kind: c.kind, node: c.node, w: c.w, z: c.z, timeUnit: c.t})
 	case "stream":
-		return cmdStream(opts{kind: "wid", w: c.w, z: c.z, timeUnit: c.t, count: c.n})
+		return cmdStream(opts{kind: c.kind, node: c.node, w: c.w, z: c.z, timeUnit: c.t, count: c.n})
 	case "healthcheck":
-		return cmdHealthcheck(opts{kind: "wid", w: c.w, z: c.z, timeUnit: c.t, json: true})
+		return cmdHealthcheck(opts{kind: c.kind, node: c.node, w: c.w, z: c.z, timeUnit: c.t, output: "json"})
 	default:
 		return runNativeOrchestration(c)
 	}
@@ -512,44 +1073,140 @@ func buildSignVerifyMessage(c canon) ([]byte, error) {
 	return msg, nil
 }
 
-func loadEd25519PrivateKey(path string) (ed25519.PrivateKey, error) {
+// loadSigningKey reads a private key for A=sign: PKCS#8, SEC 1 or PKCS#1 PEM
+// (see wid.ParseSigningKeyPEM), or OpenSSH (id_ed25519). Passphrase-protected
+// OpenSSH keys are decrypted in memory after a passphrase prompt on the
+// terminal. KEY=ssh-agent[:<fingerprint|comment>] signs with a key
+// held by ssh-agent instead, and a KMS URI (see kmsSigner) with a remote one.
+func loadSigningKey(path string) (crypto.Signer, error) {
+	if match, ok := sshAgentKey(path); ok {
+		return wid.NewSSHAgentSigner("", match)
+	}
+	if isKMSKey(path) {
+		return loadKMSSigner(path)
+	}
 	b, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	blk, _ := pem.Decode(b)
-	if blk == nil {
-		return nil, errors.New("failed to parse PEM private key")
+	pk, err := wid.ParseSigningKeyPEM(b)
+	if errors.Is(err, wid.ErrEncryptedSSHKey) {
+		dk, err := decryptSSHKey(path)
+		if err != nil {
+			return nil, err
+		}
+		return dk, nil
 	}
-	keyAny, err := x509.ParsePKCS8PrivateKey(blk.Bytes)
+	return pk, err
+}
+
+// loadEd25519PrivateKey is loadSigningKey for the Ed25519-only formats
+// (tokens, PASETO v4.public).
+func loadEd25519PrivateKey(path string) (ed25519.PrivateKey, error) {
+	k, err := loadSigningKey(path)
 	if err != nil {
 		return nil, err
 	}
-	pk, ok := keyAny.(ed25519.PrivateKey)
+	pk, ok := k.(ed25519.PrivateKey)
 	if !ok {
-		return nil, errors.New("loaded key is not an Ed25519 private key")
+		return nil, wid.ErrNotEd25519PrivateKey
 	}
 	return pk, nil
 }
 
+// loadPublicKey reads a PKIX PEM public key of any supported algorithm or an
+// OpenSSH id_ed25519.pub line, or takes the public half of an ssh-agent or
+// KMS key.
+func loadPublicKey(path string) (crypto.PublicKey, error) {
+	if match, ok := sshAgentKey(path); ok {
+		s, err := wid.NewSSHAgentSigner("", match)
+		if err != nil {
+			return nil, err
+		}
+		return s.Public(), nil
+	}
+	if isKMSKey(path) {
+		s, err := loadKMSSigner(path)
+		if err != nil {
+			return nil, err
+		}
+		return s.Public(), nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(b)), "ssh-") {
+		return wid.ParseSSHPublicKey(b)
+	}
+	return wid.ParseVerifyingKeyPEM(b)
+}
+
+// loadEd25519PublicKey is loadPublicKey for the Ed25519-only formats.
 func loadEd25519PublicKey(path string) (ed25519.PublicKey, error) {
+	k, err := loadPublicKey(path)
+	if err != nil {
+		return nil, err
+	}
+	pk, ok := k.(ed25519.PublicKey)
+	if !ok {
+		return nil, wid.ErrNotEd25519PublicKey
+	}
+	return pk, nil
+}
+
+// sshAgentKey reports whether KEY= selects an ssh-agent key, and returns
+// the fingerprint or comment after "ssh-agent:", if any.
+func sshAgentKey(key string) (string, bool) {
+	key = strings.TrimSpace(key)
+	if key == "ssh-agent" {
+		return "", true
+	}
+	match, ok := strings.CutPrefix(key, "ssh-agent:")
+	return match, ok
+}
+
+// decryptSSHKey opens a passphrase-protected OpenSSH key in memory, asking
+// for the passphrase on the terminal.
+func decryptSSHKey(path string) (ed25519.PrivateKey, error) {
 	b, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 	blk, _ := pem.Decode(b)
 	if blk == nil {
-		return nil, errors.New("failed to parse PEM public key")
+		return nil, wid.ErrInvalidSSHKey
 	}
-	keyAny, err := x509.ParsePKIXPublicKey(blk.Bytes)
+	pass, err := readPassphrase(fmt.Sprintf("Enter passphrase for %s: ", path))
 	if err != nil {
 		return nil, err
 	}
-	pk, ok := keyAny.(ed25519.PublicKey)
-	if !ok {
-		return nil, errors.New("loaded key is not an Ed25519 public key")
+	return wid.ParseOpenSSHPrivateKeyWithPassphrase(blk.Bytes, pass)
+}
+
+// readPassphrase prompts on the controlling terminal with echo off.
+func readPassphrase(prompt string) ([]byte, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, errors.New("passphrase-protected OpenSSH key needs a terminal to prompt on")
+	}
+	defer tty.Close()
+	stty := func(arg string) error {
+		cmd := exec.Command("stty", arg)
+		cmd.Stdin = tty
+		return cmd.Run()
+	}
+	fmt.Fprint(tty, prompt)
+	if err := stty("-echo"); err != nil {
+		return nil, errors.New("cannot turn off terminal echo for the passphrase prompt")
+	}
+	line, err := bufio.NewReader(tty).ReadString('\n')
+	_ = stty("echo")
+	fmt.Fprintln(tty)
+	if err != nil && line == "" {
+		return nil, err
 	}
-	return pk, nil
+	return []byte(strings.TrimRight(line, "\r\n")), nil
 }
 
 func runSign(c canon) int {
@@ -557,18 +1214,51 @@ func runSign(c canon) int {
 		errln("KEY=<private_key_path> required for A=sign")
 		return 1
 	}
+	if isSigstore(c) {
+		return runSigstoreSign(c)
+	}
+	if strings.EqualFold(c.mode, "batch") {
+		return runSignBatch(c)
+	}
 	msg, err := buildSignVerifyMessage(c)
 	if err != nil {
 		errln(err.Error())
 		return 1
 	}
-	pk, err := loadEd25519PrivateKey(c.key)
+	if c.format == "jws" {
+		return runSignEnvelope(c, msg)
+	}
+	sig, err := signMessage(c, msg)
 	if err != nil {
 		errln(err.Error())
 		return 1
 	}
-	sig := ed25519.Sign(pk, msg)
 	enc := b64urlEncode(sig)
+	if strings.TrimSpace(c.tsa) != "" {
+		token, err := requestTimestamp(c.tsa, sig)
+		if err != nil {
+			errln("timestamping failed: " + err.Error())
+			return 1
+		}
+		enc += "." + b64urlEncode(token)
+	}
+	return writeSignature(c, enc)
+}
+
+// signMessage signs msg with the KEY= private key file or PKCS#11 token.
+func signMessage(c canon, msg []byte) ([]byte, error) {
+	if isPKCS11(c.key) {
+		return pkcs11Sign(c.key, msg)
+	}
+	pk, err := loadSigningKey(c.key)
+	if err != nil {
+		return nil, err
+	}
+	return wid.SignMessage(pk, msg)
+}
+
+// writeSignature prints a signature, or writes it to OUT=.
+func writeSignature(c canon, enc string) int {
 	if strings.TrimSpace(c.out) != "" {
 		if err := os.WriteFile(c.out, []byte(enc), 0o644); err != nil {
 			errln(err.Error())
@@ -581,31 +1271,76 @@ func runSign(c canon) int {
 }
 
 func runVerify(c canon) int {
+	if c.manifest != "" {
+		return runManifestVerify(c)
+	}
+	if c.keys != "" || c.sigs != "" || c.bundle != "" {
+		return runThresholdVerify(c)
+	}
 	if strings.TrimSpace(c.key) == "" {
 		errln("KEY=<public_key_path> required for A=verify")
 		return 1
 	}
+	if strings.EqualFold(c.mode, "batch") {
+		return runVerifyBatch(c)
+	}
 	if strings.TrimSpace(c.sig) == "" {
 		errln("SIG=<signature_string> required for A=verify")
 		return 1
 	}
+	if isSigstore(c) {
+		return runSigstoreVerify(c)
+	}
+	// SIG may name a file (e.g. the OUT= of A=sign); a "<sig>.<token>"
+	// value carries an RFC 3161 timestamp token, and a JSON object is a
+	// FORMAT=jws envelope.
+	rawSig := strings.TrimSpace(c.sig)
+	if b, err := os.ReadFile(rawSig); err == nil {
+		rawSig = strings.TrimSpace(string(b))
+	}
+	if strings.HasPrefix(rawSig, "{") {
+		return runVerifyEnvelope(c, rawSig)
+	}
 	msg, err := buildSignVerifyMessage(c)
 	if err != nil {
 		errln(err.Error())
 		return 1
 	}
-	pk, err := loadEd25519PublicKey(c.key)
+	ks, signer, err := loadVerifyKeySet(c)
 	if err != nil {
 		errln(err.Error())
 		return 1
 	}
-	sig, err := b64urlDecode(c.sig)
+	sigPart, tokenPart, stamped := strings.Cut(rawSig, ".")
+	sig, err := b64urlDecode(sigPart)
 	if err != nil {
 		errln("invalid signature encoding")
 		return 1
 	}
-	if ed25519.Verify(pk, msg, sig) {
+	if kid, ok := ks.Verify(msg, sig, ""); ok {
+		var stampedAt time.Time
+		var tsaName string
+		if stamped {
+			token, err := b64urlDecode(tokenPart)
+			if err != nil {
+				errln("invalid timestamp token encoding")
+				return 1
+			}
+			if stampedAt, tsaName, err = verifyTimestamp(c, sig, token); err != nil {
+				errln(err.Error())
+				return 1
+			}
+		}
 		fmt.Println("Signature valid.")
+		if ks.Len() > 1 {
+			fmt.Println("Key: " + kid)
+		}
+		if signer != "" {
+			fmt.Println("Signer: " + signer)
+		}
+		if stamped {
+			fmt.Printf("Timestamp: %s (TSA: %s)\n", stampedAt.UTC().Format(time.RFC3339Nano), tsaName)
+		}
 		return 0
 	}
 	errln("Signature invalid.")
@@ -682,20 +1417,40 @@ func computeWOtp(secret, widValue string, digits int) string {
 	return fmt.Sprintf("%0*d", digits, code)
 }
 
+// wotpWindow is the index of the WINDOW_SEC-long window containing ms.
+func wotpWindow(ms int64, windowSec int) int64 {
+	size := int64(windowSec) * 1000
+	if ms < 0 {
+		return (ms - size + 1) / size
+	}
+	return ms / size
+}
+
+// wotpWindowMessage is the HMAC input for a windowed code: the window's start
+// as a UTC seconds timestamp, framed with the window length so codes for
+// different WINDOW_SEC values never coincide.
+func wotpWindowMessage(window int64, windowSec int) string {
+	start := time.UnixMilli(window * int64(windowSec) * 1000).UTC()
+	return fmt.Sprintf("wotp-window-v1:%d:%s", windowSec, start.Format("20060102T150405"))
+}
+
 func runWOtp(c canon) int {
 	mode := strings.ToLower(strings.TrimSpace(c.mode))
 	if mode == "" {
 		mode = "gen"
 	}
+	if mode == "secret" {
+		return runWOtpSecret(c)
+	}
 	if mode != "gen" && mode != "verify" {
-		errln("MODE must be gen or verify for A=w-otp")
+		errln("MODE must be gen, verify or secret for A=w-otp")
 		return 1
 	}
 	if strings.TrimSpace(c.key) == "" {
 		errln("KEY=<secret_or_path> required for A=w-otp")
 		return 1
 	}
-	secret, err := resolveWOtpSecret(c.key)
+	secret, err := resolveWOtpKey(c)
 	if err != nil {
 		errln(err.Error())
 		return 1
@@ -718,48 +1473,164 @@ func runWOtp(c canon) int {
 	}
 	widValue := strings.TrimSpace(c.wid)
 	if widValue == "" && mode == "gen" {
-		g, err := wid.NewWidGenWithUnit(c.w, c.z, c.t)
+		g, err := newCanonGen(c)
 		if err != nil {
 			errln(err.Error())
 			return 1
 		}
 		widValue = g.Next()
 	}
-	if widValue == "" {
-		errln("WID=<wid_string> required for A=w-otp MODE=verify")
+	if widValue == "" && c.windowSec == 0 {
+		errln("WID=<wid_string> required for A=w-otp MODE=verify")
+		return 1
+	}
+	if c.stepSec > 0 && c.windowSec > 0 {
+		errln("STEP and WINDOW_SEC cannot be combined for A=w-otp")
+		return 1
+	}
+	if c.windowSec > 0 {
+		return runWOtpWindowed(c, secret, widValue, digits)
+	}
+	if c.stepSec > 0 {
+		return runWOtpStepped(c, secret, widValue, digits)
+	}
+	otp := computeWOtp(secret, widValue, digits)
+	if mode == "gen" {
+		b, _ := json.Marshal(map[string]any{"wid": widValue, "otp": otp, "digits": digits})
+		fmt.Println(string(b))
+		return 0
+	}
+	if strings.TrimSpace(c.code) == "" {
+		errln("CODE=<otp_code> required for A=w-otp MODE=verify")
+		return 1
+	}
+	if !wotpCheckAge(c, widValue) {
+		return 1
+	}
+	if subtle.ConstantTimeCompare([]byte(c.code), []byte(otp)) == 1 {
+		fmt.Println("OTP valid.")
+		return 0
+	}
+	errln("OTP invalid.")
+	return 1
+}
+
+// wotpCheckAge applies MAX_AGE_SEC and MAX_FUTURE_SEC to the WID's timestamp
+// on verify, in every w-otp variant. It reports a rejection and returns false.
+func wotpCheckAge(c canon, widValue string) bool {
+	if c.maxAgeSec == 0 && c.maxFutureSec == 0 {
+		return true
+	}
+	widMs, err := wotpWidTickMs(widValue)
+	if err != nil {
+		errln("WID timestamp is invalid for time-window verification")
+		return false
+	}
+	window := wid.StrictOptions{
+		MaxAge:    time.Duration(c.maxAgeSec) * time.Second,
+		MaxFuture: time.Duration(c.maxFutureSec) * time.Second,
+	}
+	switch window.CheckTime(time.UnixMilli(widMs)) {
+	case wid.ErrTooFuture:
+		errln("OTP invalid: WID timestamp is too far in the future")
+		return false
+	case wid.ErrTooOld:
+		errln("OTP invalid: WID timestamp is too old")
+		return false
+	}
+	return true
+}
+
+// runWOtpWindowed derives the code from the WID truncated to WINDOW_SEC and,
+// on verify, accepts the window before and after the verifier's own, so a
+// small clock offset between issuer and verifier does not fail the check.
+// Without WID= a verifier tries the windows around its current time. With
+// WID=, MAX_AGE_SEC and MAX_FUTURE_SEC apply as in plain verify.
+func runWOtpWindowed(c canon, secret, widValue string, digits int) int {
+	nowWin := wotpWindow(time.Now().UTC().UnixMilli(), c.windowSec)
+	win := nowWin
+	if widValue != "" {
+		ms, err := wotpWidTickMs(widValue)
+		if err != nil {
+			errln(err.Error())
+			return 1
+		}
+		win = wotpWindow(ms, c.windowSec)
+	}
+	if strings.ToLower(strings.TrimSpace(c.mode)) != "verify" {
+		otp := computeWOtp(secret, wotpWindowMessage(win, c.windowSec), digits)
+		printJSON(map[string]any{"wid": widValue, "otp": otp, "digits": digits, "window_sec": c.windowSec, "window": win})
+		return 0
+	}
+	if strings.TrimSpace(c.code) == "" {
+		errln("CODE=<otp_code> required for A=w-otp MODE=verify")
+		return 1
+	}
+	if win < nowWin-1 {
+		errln("OTP invalid: WID window is too old")
+		return 1
+	}
+	if win > nowWin+1 {
+		errln("OTP invalid: WID window is too far in the future")
+		return 1
+	}
+	// MAX_AGE_SEC/MAX_FUTURE_SEC bound the WID, so they need one; the
+	// MAX_FUTURE_SEC default alone is not a request for that.
+	if widValue == "" && c.maxAgeSec > 0 {
+		errln("MAX_AGE_SEC with WINDOW_SEC requires WID=")
+		return 1
+	}
+	if widValue != "" && !wotpCheckAge(c, widValue) {
 		return 1
 	}
-	otp := computeWOtp(secret, widValue, digits)
-	if mode == "gen" {
-		b, _ := json.Marshal(map[string]any{"wid": widValue, "otp": otp, "digits": digits})
-		fmt.Println(string(b))
+	candidates := []int64{win}
+	if widValue == "" {
+		candidates = []int64{nowWin, nowWin - 1, nowWin + 1}
+	}
+	for _, w := range candidates {
+		otp := computeWOtp(secret, wotpWindowMessage(w, c.windowSec), digits)
+		if subtle.ConstantTimeCompare([]byte(c.code), []byte(otp)) == 1 {
+			fmt.Println("OTP valid.")
+			return 0
+		}
+	}
+	errln("OTP invalid.")
+	return 1
+}
+
+// wotpStepMessage is the HMAC input for a STEP code: the WID and the
+// verifier-clock step counter floor(now/STEP), framed with the step length.
+func wotpStepMessage(widValue string, step int64, stepSec int) string {
+	return fmt.Sprintf("wotp-step-v1:%d:%s:%d", stepSec, widValue, step)
+}
+
+// runWOtpStepped binds the code to the WID and to the current STEP-long time
+// step, TOTP style, so it expires on its own however old the WID is. verify
+// accepts the step before and after its own as slack for clock offset and
+// delivery delay, and applies MAX_AGE_SEC and MAX_FUTURE_SEC to the WID as
+// plain verify does.
+func runWOtpStepped(c canon, secret, widValue string, digits int) int {
+	now := time.Now().UTC().Unix()
+	step := now / int64(c.stepSec)
+	if strings.ToLower(strings.TrimSpace(c.mode)) != "verify" {
+		otp := computeWOtp(secret, wotpStepMessage(widValue, step, c.stepSec), digits)
+		expires := time.Unix((step+1)*int64(c.stepSec), 0).UTC()
+		printJSON(map[string]any{"wid": widValue, "otp": otp, "digits": digits, "step_sec": c.stepSec, "step": step, "expires_at": expires.Format(time.RFC3339)})
 		return 0
 	}
 	if strings.TrimSpace(c.code) == "" {
 		errln("CODE=<otp_code> required for A=w-otp MODE=verify")
 		return 1
 	}
-	if c.maxAgeSec > 0 || c.maxFutureSec > 0 {
-		widMs, err := wotpWidTickMs(widValue)
-		if err != nil {
-			errln("WID timestamp is invalid for time-window verification")
-			return 1
-		}
-		nowMs := time.Now().UTC().UnixMilli()
-		delta := nowMs - widMs
-		if delta < 0 {
-			if -delta > int64(c.maxFutureSec)*1000 {
-				errln("OTP invalid: WID timestamp is too far in the future")
-				return 1
-			}
-		} else if c.maxAgeSec > 0 && delta > int64(c.maxAgeSec)*1000 {
-			errln("OTP invalid: WID timestamp is too old")
-			return 1
-		}
+	if !wotpCheckAge(c, widValue) {
+		return 1
 	}
-	if subtle.ConstantTimeCompare([]byte(c.code), []byte(otp)) == 1 {
-		fmt.Println("OTP valid.")
-		return 0
+	for _, s := range []int64{step, step - 1, step + 1} {
+		otp := computeWOtp(secret, wotpStepMessage(widValue, s, c.stepSec), digits)
+		if subtle.ConstantTimeCompare([]byte(c.code), []byte(otp)) == 1 {
+			fmt.Println("OTP valid.")
+			return 0
+		}
 	}
 	errln("OTP invalid.")
 	return 1
@@ -788,24 +1659,108 @@ func sqlStatePath(c canon) string {
 // implementation tag): all six implementations share one row per generator
 // shape, so mixing languages on the same database cannot mint duplicate WIDs.
 func sqlStateKey(c canon) string {
+	if c.kind == "hlc" {
+		// HLC-WIDs embed the node, so each node keeps its own clock row.
+		return fmt.Sprintf("hlc:%d:%d:%s:%s", c.w, c.z, c.t, c.node)
+	}
 	return fmt.Sprintf("wid:%d:%d:%s", c.w, c.z, c.t)
 }
 
+// sqlSealed reports whether E=sql keeps its counters sealed in
+// wid_state_sealed, which it does whenever a data key is configured (see
+// atrest.go).
+func sqlSealed() (bool, error) {
+	aead, err := dataKeyAEAD()
+	return aead != nil, err
+}
+
 func sqlEnsureState(dbPath string, key string) error {
+	sealed, err := sqlSealed()
+	if err != nil {
+		return err
+	}
+	if sealed {
+		err = sqlEnsureSealedState(dbPath, key)
+	} else {
+		// Once the counters are sealed, a fresh plaintext row would restart
+		// them, so refuse instead of creating one.
+		escaped := sqlEscapeSingle(key)
+		sql := "CREATE TABLE IF NOT EXISTS wid_state (k TEXT PRIMARY KEY, last_tick INTEGER NOT NULL, last_seq INTEGER NOT NULL);" +
+			fmt.Sprintf("INSERT OR IGNORE INTO wid_state(k,last_tick,last_seq) SELECT '%s',0,-1 "+
+				"WHERE NOT EXISTS (SELECT 1 FROM sqlite_master WHERE type='table' AND name='wid_state_sealed');", escaped) +
+			"SELECT count(*) FROM sqlite_master WHERE type='table' AND name='wid_state_sealed';"
+		var out string
+		if out, err = sqliteExec(dbPath, sql); err == nil && out == "1" {
+			err = errors.New(dbPath + " holds sealed state; set WID_DATA_KEY")
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return os.Chmod(dbPath, 0o600)
+}
+
+// sqlEnsureSealedState creates key's wid_state_sealed row. A plaintext
+// wid_state row left from before the key was configured is carried over
+// and then deleted, with secure_delete so its counters do not linger in
+// free pages.
+func sqlEnsureSealedState(dbPath string, key string) error {
 	escaped := sqlEscapeSingle(key)
-	sql := "CREATE TABLE IF NOT EXISTS wid_state (k TEXT PRIMARY KEY, last_tick INTEGER NOT NULL, last_seq INTEGER NOT NULL);" +
-		fmt.Sprintf("INSERT OR IGNORE INTO wid_state(k,last_tick,last_seq) VALUES('%s',0,-1);", escaped)
-	_, err := sqliteExec(dbPath, sql)
+	n, err := sqliteExec(dbPath, "CREATE TABLE IF NOT EXISTS wid_state_sealed (k TEXT PRIMARY KEY, v TEXT NOT NULL);"+
+		fmt.Sprintf("SELECT count(*) FROM wid_state_sealed WHERE k='%s';", escaped))
+	if err != nil || n == "1" {
+		return err
+	}
+	tick, seq, migrate := int64(0), -1, false
+	if t, err := sqliteExec(dbPath, "SELECT name FROM sqlite_master WHERE type='table' AND name='wid_state';"); err != nil {
+		return err
+	} else if t != "" {
+		raw, err := sqliteExec(dbPath, fmt.Sprintf("SELECT last_tick || '|' || last_seq FROM wid_state WHERE k='%s';", escaped))
+		if err != nil {
+			return err
+		}
+		if raw != "" {
+			if tick, seq, err = sqlParseState(raw); err != nil {
+				return err
+			}
+			migrate = true
+		}
+	}
+	v, err := sealStateRow(key, tick, seq)
+	if err != nil {
+		return err
+	}
+	sql := fmt.Sprintf("PRAGMA secure_delete=ON;BEGIN IMMEDIATE;INSERT OR IGNORE INTO wid_state_sealed(k,v) VALUES('%s','%s');", escaped, v)
+	if migrate {
+		sql += fmt.Sprintf("DELETE FROM wid_state WHERE k='%s';", escaped)
+	}
+	_, err = sqliteExec(dbPath, sql+"COMMIT;")
 	return err
 }
 
 func sqlLoadState(dbPath string, key string) (int64, int, error) {
 	escaped := sqlEscapeSingle(key)
+	sealed, err := sqlSealed()
+	if err != nil {
+		return 0, 0, err
+	}
+	if sealed {
+		v, err := sqliteExec(dbPath, fmt.Sprintf("SELECT v FROM wid_state_sealed WHERE k='%s';", escaped))
+		if err != nil {
+			return 0, 0, err
+		}
+		return openStateRow(key, v)
+	}
 	sql := fmt.Sprintf("SELECT last_tick || '|' || last_seq FROM wid_state WHERE k='%s';", escaped)
 	raw, err := sqliteExec(dbPath, sql)
 	if err != nil {
 		return 0, 0, err
 	}
+	return sqlParseState(raw)
+}
+
+// sqlParseState parses a "<last_tick>|<last_seq>" pair.
+func sqlParseState(raw string) (int64, int, error) {
 	parts := strings.SplitN(raw, "|", 2)
 	if len(parts) != 2 {
 		return 0, 0, errors.New("invalid sql state row")
@@ -823,14 +1778,33 @@ func sqlLoadState(dbPath string, key string) (int64, int, error) {
 
 func sqlCompareAndSwapState(dbPath string, key string, oldTick int64, oldSeq int, newTick int64, newSeq int) (bool, error) {
 	escaped := sqlEscapeSingle(key)
-	sql := fmt.Sprintf(
-		"UPDATE wid_state SET last_tick=%d,last_seq=%d WHERE k='%s' AND last_tick=%d AND last_seq=%d;SELECT changes();",
-		newTick,
-		newSeq,
-		escaped,
-		oldTick,
-		oldSeq,
-	)
+	sealed, err := sqlSealed()
+	if err != nil {
+		return false, err
+	}
+	var sql string
+	if sealed {
+		// Sealing is deterministic per row and counters, so the stored
+		// value stands in for the old pair.
+		oldV, err := sealStateRow(key, oldTick, oldSeq)
+		if err != nil {
+			return false, err
+		}
+		newV, err := sealStateRow(key, newTick, newSeq)
+		if err != nil {
+			return false, err
+		}
+		sql = fmt.Sprintf("UPDATE wid_state_sealed SET v='%s' WHERE k='%s' AND v='%s';SELECT changes();", newV, escaped, oldV)
+	} else {
+		sql = fmt.Sprintf(
+			"UPDATE wid_state SET last_tick=%d,last_seq=%d WHERE k='%s' AND last_tick=%d AND last_seq=%d;SELECT changes();",
+			newTick,
+			newSeq,
+			escaped,
+			oldTick,
+			oldSeq,
+		)
+	}
 	raw, err := sqliteExec(dbPath, sql)
 	if err != nil {
 		return false, err
@@ -839,32 +1813,43 @@ func sqlCompareAndSwapState(dbPath string, key string, oldTick int64, oldSeq int
 }
 
 func sqlAllocateNextWid(c canon) (string, error) {
-	dbPath := sqlStatePath(c)
-	key := sqlStateKey(c)
-	if err := sqlEnsureState(dbPath, key); err != nil {
-		return "", err
+	id, _, err := sqlAllocateNextWidCounted(c)
+	return id, err
+}
+
+// sqlStore is the wid_state row for one generator shape as a wid.StateStore.
+type sqlStore struct {
+	dbPath, key string
+}
+
+func (s sqlStore) Load() (wid.GenState, error) {
+	tick, seq, err := sqlLoadState(s.dbPath, s.key)
+	return wid.GenState{Tick: tick, Seq: seq}, err
+}
+
+func (s sqlStore) CompareAndSwap(old, next wid.GenState) (bool, error) {
+	return sqlCompareAndSwapState(s.dbPath, s.key, old.Tick, old.Seq, next.Tick, next.Seq)
+}
+
+// sqlAllocateNextWidCounted is sqlAllocateNextWid that also reports how many
+// compare-and-swap attempts lost to a concurrent writer.
+func sqlAllocateNextWidCounted(c canon) (string, int, error) {
+	store := sqlStore{dbPath: sqlStatePath(c), key: sqlStateKey(c)}
+	if err := sqlEnsureState(store.dbPath, store.key); err != nil {
+		return "", 0, err
+	}
+	var g *wid.PersistentGen
+	var err error
+	if c.kind == "hlc" {
+		g, err = wid.NewPersistentHLCWidGen(store, c.node, c.w, c.z, c.t)
+	} else {
+		g, err = wid.NewPersistentWidGen(store, c.w, c.z, c.t)
 	}
-	for i := 0; i < 64; i++ {
-		lastTick, lastSeq, err := sqlLoadState(dbPath, key)
-		if err != nil {
-			return "", err
-		}
-		g, err := wid.NewWidGenWithUnit(c.w, c.z, c.t)
-		if err != nil {
-			return "", err
-		}
-		g.RestoreState(lastTick, lastSeq)
-		id := g.Next()
-		nextTick, nextSeq := g.State()
-		ok, err := sqlCompareAndSwapState(dbPath, key, lastTick, lastSeq, nextTick, nextSeq)
-		if err != nil {
-			return "", err
-		}
-		if ok {
-			return id, nil
-		}
+	if err != nil {
+		return "", 0, err
 	}
-	return "", errors.New("sql allocation contention: retry budget exhausted")
+	id, err := g.NextE()
+	return id, int(g.Conflicts()), err
 }
 
 func runCanonicalSQLNext(c canon) int {
@@ -899,8 +1884,35 @@ func runCanonicalSQLStream(c canon) int {
 	return 0
 }
 
+// withProfileKVs strips PROFILE= and prepends the active profile's settings
+// as KEY=VALUE arguments.
+func withProfileKVs(args []string) ([]string, error) {
+	explicit := ""
+	var rest []string
+	for _, a := range args {
+		if v, ok := strings.CutPrefix(a, "PROFILE="); ok {
+			explicit = v
+			continue
+		}
+		rest = append(rest, a)
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	_, p, err := cfg.activeProfile(explicit)
+	if err != nil {
+		return nil, err
+	}
+	return append(profileKVArgs(p), rest...), nil
+}
+
 func parseCanonical(args []string) (canon, error) {
-	c := canon{a: "next", w: 4, l: 3600, d: "", i: "auto", e: "state", z: 6, t: wid.TimeUnitSec, r: "auto", m: false, n: 0, wid: "", key: "", sig: "", data: "", out: "", mode: "", code: "", digits: 6, maxAgeSec: 0, maxFutureSec: 5}
+	c := canon{a: "next", w: 4, l: 3600, d: "", i: "auto", e: "state", z: 6, t: wid.TimeUnitSec, r: "auto", m: false, n: 0, wid: "", key: "", sig: "", data: "", out: "", mode: "", code: "", digits: 6, maxAgeSec: 0, maxFutureSec: 5, keyTTLSec: 300, cmd: "", hookConc: 1, hookFail: "stop", batch: 1, retries: 3, backoffMs: 200, maxInflight: 0, backpressure: "block", kind: "wid", node: "go", durationSec: 60, jumpAtSec: -1, policy: "borrow", seed: 1}
+	args, err := withProfileKVs(args)
+	if err != nil {
+		return c, err
+	}
 	for _, arg := range args {
 		kv := strings.SplitN(arg, "=", 2)
 		if len(kv) != 2 {
@@ -946,8 +1958,11 @@ func parseCanonical(args []string) (canon, error) {
 		case "R":
 			c.r = v
 		case "M":
-			s := strings.ToLower(v)
-			c.m = s == "1" || s == "true" || s == "yes" || s == "on" || s == "y"
+			c.m = truthy(v)
+		case "DRY_RUN":
+			c.dryRun = truthy(v)
+		case "FINGERPRINT":
+			c.fingerprint = truthy(v)
 		case "N":
 			n, err := strconv.Atoi(v)
 			if err != nil {
@@ -964,16 +1979,155 @@ func parseCanonical(args []string) (canon, error) {
 			c.data = v
 		case "OUT":
 			c.out = v
+		case "CA":
+			c.ca = v
+		case "IDENTITY":
+			c.identity = v
+		case "ISSUER":
+			c.issuer = v
+		case "TSA":
+			c.tsa = v
+		case "TSA_CA":
+			c.tsaCA = v
+		case "KEYS":
+			c.keys = v
+		case "SIGS":
+			c.sigs = v
+		case "BUNDLE":
+			c.bundle = v
+		case "TOKEN":
+			c.token = v
+		case "CHAIN_KEY":
+			c.chainKey = v
+		case "HEAD":
+			c.head = v
+		case "MODULE_OPTS":
+			c.moduleOpts = v
+		case "STATUS_ADDR":
+			c.statusAddr = v
+		case "PEERS":
+			c.peers = v
+		case "HANDOFF":
+			c.handoff = truthy(v)
+		case "KIND":
+			if v != "wid" && v != "hlc" {
+				return c, errors.New("KIND must be wid or hlc")
+			}
+			c.kind = v
+		case "NODE":
+			if !wid.IsValidNode(v) {
+				return c, errors.New("invalid NODE")
+			}
+			c.node = v
+		case "WINDOW_SEC":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return c, errors.New("invalid WINDOW_SEC")
+			}
+			c.windowSec = n
+		case "URI":
+			c.uri = truthy(v)
+		case "KEY_FORMAT":
+			if v != "" && v != "text" && v != "base32" {
+				return c, errors.New("KEY_FORMAT must be text or base32")
+			}
+			c.keyFormat = v
+		case "STEP":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return c, errors.New("invalid STEP")
+			}
+			c.stepSec = n
+		case "MANIFEST":
+			c.manifest = v
+		case "RATE", "DURATION_SEC", "JITTER_MS", "JUMP_SEC", "JUMP_AT_SEC":
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil || math.IsNaN(f) || math.IsInf(f, 0) || (f < 0 && k != "JUMP_SEC") {
+				return c, errors.New("invalid " + k)
+			}
+			switch k {
+			case "RATE":
+				c.rate = f
+			case "DURATION_SEC":
+				c.durationSec = f
+			case "JITTER_MS":
+				c.jitterMs = f
+			case "JUMP_SEC":
+				c.jumpSec = f
+			case "JUMP_AT_SEC":
+				c.jumpAtSec = f
+			}
+		case "POLICY":
+			c.policy = strings.ToLower(v)
+		case "SEED":
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return c, errors.New("invalid SEED")
+			}
+			c.seed = n
+		case "MAX_RATE":
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil || f < 0 {
+				return c, errors.New("invalid MAX_RATE")
+			}
+			c.maxRate = f
+		case "MAX_QUEUE_BYTES", "MAX_LOG_BYTES":
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n < 0 {
+				return c, errors.New("invalid " + k)
+			}
+			if k == "MAX_QUEUE_BYTES" {
+				c.maxQueueBytes = n
+			} else {
+				c.maxLogBytes = n
+			}
+		case "INSTANCE":
+			if !validInstanceName(v) {
+				return c, errors.New("invalid INSTANCE (letters, digits, '-', '_', '.')")
+			}
+			runtimeInstance = v
+		case "OUTPUT":
+			if v != "" && !outputFormats[v] {
+				return c, errors.New("OUTPUT must be one of: text, json, ndjson, csv")
+			}
+			c.output = v
+		case "EXP_SEC":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return c, errors.New("invalid EXP_SEC")
+			}
+			c.expSec = n
+		case "THRESHOLD":
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return c, errors.New("invalid THRESHOLD")
+			}
+			c.threshold = n
 		case "MODE":
 			c.mode = v
 		case "CODE":
 			c.code = v
+		case "IN":
+			c.in = v
+		case "FORMAT":
+			if v != "" && v != "raw" && v != "jws" {
+				return c, errors.New("FORMAT must be raw or jws")
+			}
+			c.format = v
 		case "DIGITS":
 			n, err := strconv.Atoi(v)
 			if err != nil {
 				return c, errors.New("invalid DIGITS")
 			}
 			c.digits = n
+		case "KEY_TTL_SEC":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return c, errors.New("invalid KEY_TTL_SEC")
+			}
+			c.keyTTLSec = n
+		case "KEY_PIN":
+			c.keyPin = v
 		case "MAX_AGE_SEC":
 			n, err := strconv.Atoi(v)
 			if err != nil {
@@ -986,6 +2140,46 @@ func parseCanonical(args []string) (canon, error) {
 				return c, errors.New("invalid MAX_FUTURE_SEC")
 			}
 			c.maxFutureSec = n
+		case "CMD":
+			c.cmd = v
+		case "HOOK_CONCURRENCY":
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return c, errors.New("invalid HOOK_CONCURRENCY")
+			}
+			c.hookConc = n
+		case "HOOK_FAIL":
+			c.hookFail = strings.ToLower(v)
+		case "URL":
+			c.url = v
+		case "WEBHOOK_SECRET":
+			c.webhookSecret = v
+		case "BATCH":
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return c, errors.New("invalid BATCH")
+			}
+			c.batch = n
+		case "RETRIES":
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return c, errors.New("invalid RETRIES")
+			}
+			c.retries = n
+		case "BACKOFF_MS":
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return c, errors.New("invalid BACKOFF_MS")
+			}
+			c.backoffMs = n
+		case "MAX_INFLIGHT":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return c, errors.New("invalid MAX_INFLIGHT")
+			}
+			c.maxInflight = n
+		case "BACKPRESSURE":
+			c.backpressure = strings.ToLower(v)
 		default:
 			return c, fmt.Errorf("unknown key: %s", k)
 		}
@@ -1020,6 +2214,11 @@ func parseCanonical(args []string) (canon, error) {
 	return c, nil
 }
 
+func truthy(v string) bool {
+	s := strings.ToLower(v)
+	return s == "1" || s == "true" || s == "yes" || s == "on" || s == "y"
+}
+
 func defaultForKey(k string) string {
 	switch k {
 	case "A":
@@ -1050,6 +2249,38 @@ func defaultForKey(k string) string {
 		return "0"
 	case "MAX_FUTURE_SEC":
 		return "5"
+	case "KEY_TTL_SEC":
+		return "300"
+	case "HOOK_CONCURRENCY":
+		return "1"
+	case "HOOK_FAIL":
+		return "stop"
+	case "THRESHOLD", "EXP_SEC":
+		return "0"
+	case "BATCH":
+		return "1"
+	case "RETRIES":
+		return "3"
+	case "BACKOFF_MS":
+		return "200"
+	case "MAX_INFLIGHT":
+		return "0"
+	case "BACKPRESSURE":
+		return "block"
+	case "KIND":
+		return "wid"
+	case "NODE":
+		return "go"
+	case "DRY_RUN", "HANDOFF", "FINGERPRINT", "URI":
+		return "false"
+	case "MAX_RATE", "MAX_QUEUE_BYTES", "MAX_LOG_BYTES", "WINDOW_SEC", "STEP", "RATE", "JITTER_MS", "JUMP_SEC":
+		return "0"
+	case "DURATION_SEC":
+		return "60"
+	case "POLICY":
+		return "borrow"
+	case "SEED":
+		return "1"
 	default:
 		return ""
 	}
@@ -1057,7 +2288,7 @@ func defaultForKey(k string) string {
 
 func isTransport(s string) bool {
 	switch s {
-	case "auto", "mqtt", "ws", "redis", "null", "stdout":
+	case "auto", "mqtt", "ws", "redis", "null", "stdout", "webhook":
 		return true
 	default:
 		return false
@@ -1083,10 +2314,6 @@ func parseStateTransport(c canon) (string, string) {
 	return stateMode, transport
 }
 
-func runtimeDir() string { return filepath.Clean(".local/wid/go") }
-func runtimePid() string { return filepath.Join(runtimeDir(), "service.pid") }
-func runtimeLog() string { return filepath.Join(runtimeDir(), "service.log") }
-
 func dataDir(c canon) string {
 	if strings.TrimSpace(c.d) == "" {
 		return filepath.Clean(".local/services")
@@ -1094,22 +2321,6 @@ func dataDir(c canon) string {
 	return filepath.Clean(c.d)
 }
 
-func readPid(path string) (int, bool) {
-	b, err := os.ReadFile(path)
-	if err != nil {
-		return 0, false
-	}
-	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
-	if err != nil || pid <= 0 {
-		return 0, false
-	}
-	return pid, true
-}
-
-func pidAlive(pid int) bool {
-	return syscall.Kill(pid, 0) == nil
-}
-
 func runNativeOrchestration(c canon) int {
 	switch c.a {
 	case "discover":
@@ -1119,8 +2330,9 @@ func runNativeOrchestration(c canon) int {
 			"actions": []string{
 				"discover", "scaffold", "run", "start", "stop", "status", "logs",
 				"saf", "saf-wid", "wir", "wism", "wihp", "wipr", "duplex",
+				"ctl", "fleet-status", "dlq-list", "dlq-replay", "state-export", "state-import",
 			},
-			"transports": []string{"auto", "mqtt", "ws", "redis", "null", "stdout"},
+			"transports": transportNames,
 		}
 		printJSON(payload)
 		return 0
@@ -1146,9 +2358,21 @@ func runNativeOrchestration(c canon) int {
 	case "stop":
 		return runStop()
 	case "status":
-		return runStatus()
+		return runStatus(c)
 	case "logs":
 		return runLogs()
+	case "fleet-status":
+		return runFleetStatus(c)
+	case "ctl":
+		return runCtl(c)
+	case "state-export":
+		return runStateExport(c)
+	case "state-import":
+		return runStateImport(c)
+	case "dlq-list":
+		return runDLQList(c)
+	case "dlq-replay":
+		return runDLQReplay(c)
 	default:
 		errln("unknown A=" + c.a)
 		return 1
@@ -1172,7 +2396,7 @@ func runServiceLoop(c canon, action string) int {
 		logLevel = "INFO"
 	}
 
-	g, err := wid.NewWidGenWithUnit(c.w, c.z, c.t)
+	g, err := newCanonGen(c)
 	if err != nil {
 		errln(err.Error())
 		return 1
@@ -1181,78 +2405,224 @@ func runServiceLoop(c canon, action string) int {
 	if max <= 0 {
 		max = int(^uint(0) >> 1)
 	}
+	mc, err := loadModuleConfig(c, action)
+	if err != nil {
+		errln(err.Error())
+		return 1
+	}
+	if mc.interval >= 0 {
+		c.l = mc.interval
+	}
+
+	pub, err := newPublisher(c, transport)
+	if err != nil {
+		errln(err.Error())
+		return 1
+	}
+	st := newDaemonStatus(action, transport)
+	st.save()
+	lc := newLoopControl(g, sqlStateKey(c), c.l)
+	if daemonMode && c.handoff {
+		// Take over the predecessor's sequence before binding its socket.
+		if _, err := requestHandoff(g, sqlStateKey(c)); err != nil {
+			errln(err.Error())
+			return 1
+		}
+	}
+	if daemonMode {
+		stopCtl, err := serveControl(runtimeSock(), lc, st)
+		if err != nil {
+			errln("control socket: " + err.Error())
+		} else {
+			defer stopCtl()
+		}
+		sigc := make(chan os.Signal, 1)
+		signal.Notify(sigc, syscall.SIGTERM, os.Interrupt)
+		defer signal.Stop(sigc)
+		go func() {
+			if _, ok := <-sigc; ok {
+				lc.stop()
+			}
+		}()
+	}
+	if c.statusAddr != "" {
+		if err := serveStatus(c.statusAddr, st); err != nil {
+			errln("status endpoint: " + err.Error())
+			return 1
+		}
+	}
+	defer func() {
+		if err := pub.close(); err != nil {
+			errln(err.Error())
+			st.transportResult(err)
+		}
+		st.setState("exited")
+		st.save()
+	}()
 
+	rl := newRateLimiter(c.maxRate)
+	failures := 0
 	for i := 1; i <= max; i++ {
-		id := g.Next()
-		if transport != "null" {
-			switch action {
-			case "saf-wid", "wism", "wihp", "wipr":
-				printJSON(map[string]any{
-					"impl":      "go",
-					"action":    action,
-					"tick":      i,
-					"transport": transport,
-					"W":         c.w,
-					"Z":         c.z,
-					"time_unit": string(c.t),
-					"wid":       id,
-					"interval":  c.l,
-					"log_level": logLevel,
-					"data_dir":  dd,
-				})
-			case "duplex":
-				bTransport := "ws"
-				if c.i != "auto" && localServiceTransports[c.i] {
-					bTransport = c.i
-				}
-				printJSON(map[string]any{
-					"impl":        "go",
-					"action":      "duplex",
-					"tick":        i,
-					"a_transport": transport,
-					"b_transport": bTransport,
-					"interval":    c.l,
-					"data_dir":    dd,
-				})
-			default:
-				printJSON(map[string]any{
-					"impl":       "go",
-					"action":     action,
-					"tick":       i,
-					"transport":  transport,
-					"interval":   c.l,
-					"log_level":  logLevel,
-					"data_dir":   dd,
-					"state_mode": stateMode,
-				})
+		lc.waitResumed()
+		rl.wait()
+		id, ok := lc.next()
+		if !ok {
+			break
+		}
+		_, interval := lc.state()
+		var rec map[string]any
+		switch action {
+		case "saf-wid", "wism", "wihp", "wipr":
+			rec = map[string]any{
+				"impl":      "go",
+				"action":    action,
+				"tick":      i,
+				"transport": transport,
+				"W":         c.w,
+				"Z":         c.z,
+				"time_unit": string(c.t),
+				"wid":       id,
+				"interval":  interval,
+				"log_level": logLevel,
+				"data_dir":  dd,
+			}
+		case "duplex":
+			bTransport := "ws"
+			if c.i != "auto" && localServiceTransports[c.i] {
+				bTransport = c.i
+			}
+			rec = map[string]any{
+				"impl":        "go",
+				"action":      "duplex",
+				"tick":        i,
+				"a_transport": transport,
+				"b_transport": bTransport,
+				"interval":    interval,
+				"data_dir":    dd,
 			}
+		default:
+			rec = map[string]any{
+				"impl":       "go",
+				"action":     action,
+				"tick":       i,
+				"transport":  transport,
+				"interval":   interval,
+				"log_level":  logLevel,
+				"data_dir":   dd,
+				"state_mode": stateMode,
+			}
+		}
+		if err := mc.apply(rec); err != nil {
+			errln(err.Error())
+			return 1
+		}
+		err := pub.publish(rec)
+		if err != nil {
+			errln(err.Error())
+		}
+		st.record(id, err)
+		st.save()
+		if daemonMode {
+			enforceLogLimit(c.maxLogBytes)
+		}
+		if err != nil {
+			failures++
+			noteGuardrail("idle_backoff")
+			lc.sleepFor(idleBackoff(failures))
+		} else {
+			failures = 0
 		}
-		if i < max && c.l > 0 {
-			time.Sleep(time.Duration(c.l) * time.Second)
+		if i < max {
+			lc.sleep()
 		}
 	}
 	return 0
 }
 
 func runStart(c canon) int {
-	_ = os.MkdirAll(runtimeDir(), 0o755)
-	if pid, ok := readPid(runtimePid()); ok && pidAlive(pid) {
-		fmt.Printf("wid-go start: already-running pid=%d log=%s\n", pid, runtimeLog())
+	if err := os.MkdirAll(runtimeDir(), 0o755); err != nil {
+		errln("failed to create runtime dir: " + err.Error())
+		return 1
+	}
+	oldPid, running := runningDaemon()
+	if running && !c.handoff {
+		fmt.Printf("wid-go start: already-running pid=%d log=%s\n", oldPid, runtimeLog())
 		return 0
 	}
+	c.handoff = running
+	if !running {
+		// Reserve the pid file before spawning; a concurrent start loses here.
+		if err := claimPidFile(); err != nil {
+			pid, _ := runningDaemon()
+			fmt.Printf("wid-go start: already-running pid=%d log=%s\n", pid, runtimeLog())
+			return 0
+		}
+	}
 
 	exe, err := os.Executable()
 	if err != nil {
 		errln("failed to resolve executable: " + err.Error())
 		return 1
 	}
-	logf, err := os.OpenFile(runtimeLog(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	logf, err := os.OpenFile(runtimeLog(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
 	if err != nil {
 		errln("failed to open log: " + err.Error())
 		return 1
 	}
 	defer logf.Close()
 
+	cmd := exec.Command(exe, daemonArgs(c)...)
+	cmd.Env = daemonEnv(c)
+	cmd.Stdout = logf
+	cmd.Stderr = logf
+	cmd.Stdin = nil
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		if !c.handoff {
+			_ = os.Remove(runtimePid())
+		}
+		errln("failed to start daemon: " + err.Error())
+		return 1
+	}
+	if c.handoff {
+		if err := awaitHandoff(cmd, oldPid); err != nil {
+			errln(err.Error())
+			return 1
+		}
+	}
+	if err := writePidFile(cmd.Process.Pid); err != nil {
+		errln("failed to write pid file: " + err.Error())
+		return 1
+	}
+	if c.handoff {
+		fmt.Printf("wid-go start: handed off pid=%d -> pid=%d log=%s\n", oldPid, cmd.Process.Pid, runtimeLog())
+		return 0
+	}
+	fmt.Printf("wid-go start: started pid=%d log=%s\n", cmd.Process.Pid, runtimeLog())
+	return 0
+}
+
+// daemonSecretEnv carries WEBHOOK_SECRET to the __daemon child, which would
+// otherwise show it to anyone running ps.
+const daemonSecretEnv = "WID_DAEMON_WEBHOOK_SECRET"
+
+// daemonEnv is the environment A=start launches the __daemon child with.
+func daemonEnv(c canon) []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, daemonSecretEnv+"=") {
+			env = append(env, kv)
+		}
+	}
+	if strings.TrimSpace(c.url) != "" && strings.TrimSpace(c.webhookSecret) != "" {
+		env = append(env, daemonSecretEnv+"="+c.webhookSecret)
+	}
+	return env
+}
+
+// daemonArgs is the __daemon command line A=start launches for c; see
+// daemonEnv for WEBHOOK_SECRET.
+func daemonArgs(c canon) []string {
 	args := []string{
 		"__daemon",
 		fmt.Sprintf("A=%s", "run"),
@@ -1267,36 +2637,50 @@ func runStart(c canon) int {
 		fmt.Sprintf("M=%t", c.m),
 		fmt.Sprintf("N=%d", c.n),
 	}
-
-	cmd := exec.Command(exe, args...)
-	cmd.Stdout = logf
-	cmd.Stderr = logf
-	cmd.Stdin = nil
-	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
-	if err := cmd.Start(); err != nil {
-		errln("failed to start daemon: " + err.Error())
-		return 1
+	if c.kind == "hlc" {
+		args = append(args, "KIND=hlc", "NODE="+c.node)
 	}
-	_ = os.WriteFile(runtimePid(), []byte(fmt.Sprintf("%d\n", cmd.Process.Pid)), 0o644)
-	fmt.Printf("wid-go start: started pid=%d log=%s\n", cmd.Process.Pid, runtimeLog())
-	return 0
-}
-
-func runStatus() int {
-	pid, ok := readPid(runtimePid())
-	if ok && pidAlive(pid) {
-		fmt.Printf("wid-go status=running pid=%d log=%s\n", pid, runtimeLog())
-		return 0
+	if strings.TrimSpace(c.url) != "" {
+		args = append(args,
+			fmt.Sprintf("URL=%s", c.url),
+			fmt.Sprintf("BATCH=%d", c.batch),
+			fmt.Sprintf("RETRIES=%d", c.retries),
+			fmt.Sprintf("BACKOFF_MS=%d", c.backoffMs),
+		)
 	}
-	_ = os.Remove(runtimePid())
-	fmt.Println("wid-go status=stopped")
-	return 0
+	if c.handoff {
+		args = append(args, "HANDOFF=true")
+	}
+	if runtimeInstance != "default" {
+		args = append(args, "INSTANCE="+runtimeInstance)
+	}
+	if c.maxRate > 0 {
+		args = append(args, "MAX_RATE="+strconv.FormatFloat(c.maxRate, 'f', -1, 64))
+	}
+	if c.maxQueueBytes > 0 {
+		args = append(args, fmt.Sprintf("MAX_QUEUE_BYTES=%d", c.maxQueueBytes))
+	}
+	if c.maxLogBytes > 0 {
+		args = append(args, fmt.Sprintf("MAX_LOG_BYTES=%d", c.maxLogBytes))
+	}
+	if c.statusAddr != "" {
+		args = append(args, "STATUS_ADDR="+c.statusAddr)
+	}
+	if strings.TrimSpace(c.moduleOpts) != "" {
+		args = append(args, "MODULE_OPTS="+c.moduleOpts)
+	}
+	if c.maxInflight > 0 {
+		args = append(args,
+			fmt.Sprintf("MAX_INFLIGHT=%d", c.maxInflight),
+			fmt.Sprintf("BACKPRESSURE=%s", c.backpressure),
+		)
+	}
+	return args
 }
 
 func runStop() int {
-	pid, ok := readPid(runtimePid())
-	if !ok || !pidAlive(pid) {
-		_ = os.Remove(runtimePid())
+	pid, ok := runningDaemon()
+	if !ok {
 		fmt.Println("wid-go stop: not running")
 		return 0
 	}
@@ -1315,7 +2699,17 @@ func runLogs() int {
 		fmt.Println("wid-go logs: empty")
 		return 0
 	}
-	fmt.Print(string(b))
+	for _, line := range strings.SplitAfter(string(b), "\n") {
+		if body := strings.TrimSuffix(line, "\n"); strings.HasPrefix(body, sealedPrefix) {
+			plain, err := openRecord([]byte(body))
+			if err != nil {
+				errln(err.Error())
+				return 1
+			}
+			line = string(plain) + "\n"
+		}
+		fmt.Print(line)
+	}
 	return 0
 }
 
@@ -1363,15 +2757,15 @@ func printCompletion(shell string) {
 	case "bash":
 		os.Stdout.WriteString(`_wid_complete() {
   local cur="${COMP_WORDS[COMP_CWORD]}"
-  local cmds="next stream healthcheck validate parse help-actions bench selftest completion"
+  local cmds="next stream healthcheck validate parse explain filter bucket decode encode diff stats watch help-actions bench keygen selftest version profile completion"
   if [[ "$cur" == *=* ]]; then
     local key="${cur%%=*}" val="${cur#*=}" vals=""
     case "$key" in
-      A) vals="next stream healthcheck sign verify w-otp discover scaffold run start stop status logs saf saf-wid wir wism wihp wipr duplex help-actions" ;;
+      A) vals="next stream healthcheck keygen sign verify token token-verify w-otp paseto chain-verify hook observe simulate discover scaffold run start stop status ctl fleet-status logs saf saf-wid wir wism wihp wipr duplex dlq-list dlq-replay state-export state-import help-actions" ;;
       T) vals="sec ms" ;;
       I) vals="auto sh bash" ;;
       E) vals="state stateless sql" ;;
-      R) vals="auto mqtt ws redis null stdout" ;;
+      R) vals="auto mqtt ws redis null stdout webhook" ;;
       M) vals="true false" ;;
     esac
     local IFS=$'\n'
@@ -1387,16 +2781,16 @@ complete -o nospace -F _wid_complete wid
 		os.Stdout.WriteString(`#compdef wid
 _wid_complete() {
   local cur="${words[-1]}"
-  local -a cmds=(next stream healthcheck validate parse help-actions bench selftest completion)
+  local -a cmds=(next stream healthcheck validate parse explain filter bucket decode encode diff stats watch help-actions bench keygen selftest version profile completion)
   if [[ "$cur" == *=* ]]; then
     local key="${cur%%=*}"
     local -a vals=()
     case "$key" in
-      A) vals=(next stream healthcheck sign verify w-otp discover scaffold run start stop status logs saf saf-wid wir wism wihp wipr duplex help-actions) ;;
+      A) vals=(next stream healthcheck keygen sign verify token token-verify w-otp paseto chain-verify hook observe simulate discover scaffold run start stop status ctl fleet-status logs saf saf-wid wir wism wihp wipr duplex dlq-list dlq-replay state-export state-import help-actions) ;;
       T) vals=(sec ms) ;;
       I) vals=(auto sh bash) ;;
       E) vals=(state stateless sql) ;;
-      R) vals=(auto mqtt ws redis null stdout) ;;
+      R) vals=(auto mqtt ws redis null stdout webhook) ;;
       M) vals=(true false) ;;
     esac
     compadd -P "${key}=" -- "${vals[@]}"
@@ -1408,18 +2802,28 @@ _wid_complete "$@"
 `)
 	case "fish":
 		os.Stdout.WriteString(`complete -c wid -e
-complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse help-actions bench selftest completion' -a next -d 'Emit one WID'
-complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse help-actions bench selftest completion' -a stream -d 'Stream WIDs continuously'
-complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse help-actions bench selftest completion' -a healthcheck -d 'Generate and validate a sample WID'
-complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse help-actions bench selftest completion' -a validate -d 'Validate a WID string'
-complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse help-actions bench selftest completion' -a parse -d 'Parse a WID string'
-complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse help-actions bench selftest completion' -a help-actions -d 'Show canonical action matrix'
-complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse help-actions bench selftest completion' -a completion -d 'Print shell completion script'
-complete -c wid -f -a 'A=next A=stream A=healthcheck A=sign A=verify A=w-otp A=start A=stop A=status A=logs A=help-actions' -d 'Action'
+complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse explain filter bucket decode encode diff stats watch help-actions bench keygen selftest version profile completion' -a next -d 'Emit one WID'
+complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse explain filter bucket decode encode diff stats watch help-actions bench keygen selftest version profile completion' -a stream -d 'Stream WIDs continuously'
+complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse explain filter bucket decode encode diff stats watch help-actions bench keygen selftest version profile completion' -a healthcheck -d 'Generate and validate a sample WID'
+complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse explain filter bucket decode encode diff stats watch help-actions bench keygen selftest version profile completion' -a validate -d 'Validate a WID string'
+complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse explain filter bucket decode encode diff stats watch help-actions bench keygen selftest version profile completion' -a parse -d 'Parse a WID string'
+complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse explain filter bucket decode encode diff stats watch help-actions bench keygen selftest version profile completion' -a explain -d 'Describe a WID in plain words'
+complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse explain filter bucket decode encode diff stats watch help-actions bench keygen selftest version profile completion' -a filter -d 'Filter WIDs on stdin by time'
+complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse explain filter bucket decode encode diff stats watch help-actions bench keygen selftest version profile completion' -a bucket -d 'Print the time bucket key of WIDs on stdin'
+complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse explain filter bucket decode encode diff stats watch help-actions bench keygen selftest version profile completion' -a keygen -d 'Generate an Ed25519 signing keypair'
+complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse explain filter bucket decode encode diff stats watch help-actions bench keygen selftest version profile completion' -a decode -d 'Decode a compact WID form'
+complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse explain filter bucket decode encode diff stats watch help-actions bench keygen selftest version profile completion' -a encode -d 'Encode WIDs to a compact form'
+complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse explain filter bucket decode encode diff stats watch help-actions bench keygen selftest version profile completion' -a diff -d 'Compare two WIDs'
+complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse explain filter bucket decode encode diff stats watch help-actions bench keygen selftest version profile completion' -a stats -d 'Report gaps and anomalies in WIDs on stdin'
+complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse explain filter bucket decode encode diff stats watch help-actions bench keygen selftest version profile completion' -a watch -d 'Report anomalies live while following a file or stdin'
+complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse explain filter bucket decode encode diff stats watch help-actions bench keygen selftest version profile completion' -a help-actions -d 'Show canonical action matrix'
+complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse explain filter bucket decode encode diff stats watch help-actions bench keygen selftest version profile completion' -a completion -d 'Print shell completion script'
+complete -c wid -f -n 'not __fish_seen_subcommand_from next stream healthcheck validate parse explain filter bucket decode encode diff stats watch help-actions bench keygen selftest version profile completion' -a version -d 'Show version and capabilities'
+complete -c wid -f -a 'A=next A=stream A=healthcheck A=keygen A=sign A=verify A=token A=token-verify A=w-otp A=paseto A=chain-verify A=hook A=observe A=simulate A=start A=stop A=status A=ctl A=fleet-status A=logs A=state-export A=state-import A=help-actions' -d 'Action'
 complete -c wid -f -a 'T=sec T=ms' -d 'Time unit'
 complete -c wid -f -a 'I=auto I=sh I=bash' -d 'Input source'
 complete -c wid -f -a 'E=state E=stateless E=sql' -d 'State mode'
-complete -c wid -f -a 'R=auto R=mqtt R=ws R=redis R=null R=stdout' -d 'Transport'
+complete -c wid -f -a 'R=auto R=mqtt R=ws R=redis R=null R=stdout R=webhook' -d 'Transport'
 complete -c wid -f -a 'M=true M=false' -d 'Milliseconds mode'
 complete -c wid -f -a 'W=' -d 'Sequence width'
 complete -c wid -f -a 'Z=' -d 'Padding length'
@@ -1436,39 +2840,111 @@ func printHelp() {
 	fmt.Fprintln(os.Stderr, "wid - WID/HLC-WID generator CLI")
 	fmt.Fprintln(os.Stderr)
 	fmt.Fprintln(os.Stderr, "Usage:")
-	fmt.Fprintln(os.Stderr, "  wid next [--kind wid|hlc] [--node <name>] [--W <n>] [--Z <n>] [--time-unit sec|ms]")
-	fmt.Fprintln(os.Stderr, "  wid stream [--kind wid|hlc] [--node <name>] [--W <n>] [--Z <n>] [--time-unit sec|ms] [--count <n>]")
-	fmt.Fprintln(os.Stderr, "  wid validate <id> [--kind wid|hlc] [--W <n>] [--Z <n>] [--time-unit sec|ms]")
-	fmt.Fprintln(os.Stderr, "  wid parse <id> [--kind wid|hlc] [--W <n>] [--Z <n>] [--time-unit sec|ms] [--json]")
-	fmt.Fprintln(os.Stderr, "  wid healthcheck [--kind wid|hlc] [--node <name>] [--W <n>] [--Z <n>] [--time-unit sec|ms] [--json]")
-	fmt.Fprintln(os.Stderr, "  wid bench [--kind wid|hlc] [--node <name>] [--W <n>] [--Z <n>] [--time-unit sec|ms] [--count <n>]")
+	fmt.Fprintln(os.Stderr, "  wid next [--kind wid|hlc] [--node <name>] [--W <n>] [--Z <n>] [--time-unit sec|ms] [--checksum] [--namespace <ns>] [--escape-node] [--output text|json|ndjson|csv]")
+	fmt.Fprintln(os.Stderr, "  wid stream [--kind wid|hlc] [--node <name>] [--W <n>] [--Z <n>] [--time-unit sec|ms] [--count <n>] [--checksum] [--namespace <ns>] [--escape-node] [--output text|json|ndjson|csv]")
+	fmt.Fprintln(os.Stderr, "  wid validate <id> [--kind wid|hlc] [--W <n>] [--Z <n>] [--time-unit sec|ms] [--redacted] [--checksum] [--namespace <ns>] [--output text|json|ndjson|csv]")
+	fmt.Fprintln(os.Stderr, "  wid parse <id> [--kind wid|hlc] [--W <n>] [--Z <n>] [--time-unit sec|ms] [--namespace <ns>] [--tz <zone>|--local] [--escape-node] [--output text|json|ndjson|csv]")
+	fmt.Fprintln(os.Stderr, "  --escape-node percent-escapes hyphens in --node (node format v2), so pod names can be nodes; parse --escape-node decodes it")
+	fmt.Fprintln(os.Stderr, "  --checksum appends an ISO 7064 MOD 37-2 check character (next/stream) or verifies and strips it (validate)")
+	fmt.Fprintln(os.Stderr, "  validate/parse without --time-unit try ms, then sec, and report the detected unit")
+	fmt.Fprintln(os.Stderr, "  wid explain <id> [--tz <zone>|--local] [--output text|json|ndjson|csv]")
+	fmt.Fprintln(os.Stderr, "  wid decode <value> --from base32|uuid7|binaryhex [--output text|json|ndjson|csv]")
+	fmt.Fprintln(os.Stderr, "  wid encode [<id>|-] --to base32|binaryhex|uuid7|snowflake  (no id or '-': one ID per stdin line)")
+	fmt.Fprintln(os.Stderr, "  wid filter [--since <time>] [--until <time>] [--tz <zone>|--local]  (IDs on stdin)")
+	fmt.Fprintln(os.Stderr, "  wid keygen [--out <path>] [--fingerprint] [--output text|json|ndjson|csv]  (default --out wid_ed25519; public key at <path>.pub)")
+	fmt.Fprintln(os.Stderr, "  wid bucket [--by <duration>] [--layout <go time layout>] [--output text|json|ndjson|csv]  (IDs on stdin; partition key per ID, default --by 1h)")
+	fmt.Fprintln(os.Stderr, "  wid healthcheck [--kind wid|hlc] [--node <name>] [--W <n>] [--Z <n>] [--time-unit sec|ms] [--output text|json|ndjson|csv]")
+	fmt.Fprintln(os.Stderr, "  wid bench [--kind wid|hlc] [--node <name>] [--W <n>] [--Z <n>] [--time-unit sec|ms] [--count <n>] [--output text|json|ndjson|csv]")
+	fmt.Fprintln(os.Stderr, "  wid bench --coordinator <addr> [--workers <n>] [--state memory|sql] [--data-dir <dir>] | wid bench --worker <addr> [--node <name>]")
+	fmt.Fprintln(os.Stderr, "  wid corpus [--valid <n>] [--invalid <n>] [--seed <s>] [--W <n>] [--Z <n>]  (labeled valid and near-miss IDs as NDJSON, reproducible from the seed)")
 	fmt.Fprintln(os.Stderr, "  wid selftest")
+	fmt.Fprintln(os.Stderr, "  wid version [--json]")
+	fmt.Fprintln(os.Stderr, "  wid profile list|show [name]|set-default <name>|diff <a> <b>")
+	fmt.Fprintln(os.Stderr, "  wid diff <a> <b> [--output text|json|ndjson|csv]  (elapsed time, counter delta, node change, same-generator verdict)")
+	fmt.Fprintln(os.Stderr, "  wid stats [--gap <duration>] [--tz <zone>|--local] [--output text|json|ndjson|csv]  (IDs on stdin; gaps, bursts, regressions, node flaps)")
+	fmt.Fprintln(os.Stderr, "  wid watch [<file>|-] [--gap <duration>] [--every <duration>] [--tz <zone>|--local] [--output text|json|ndjson|csv]  (stats, live: follows the file like tail -F, reports stalls longer than --gap, summary on stderr every --every)")
+	fmt.Fprintln(os.Stderr, "  --profile <name> (or PROFILE=<name>, $WID_PROFILE) applies a [profile.<name>] from $WID_CONFIG")
+	fmt.Fprintln(os.Stderr, "  --json is shorthand for --output json; --no-color (or NO_COLOR) disables terminal colors")
 	fmt.Fprintln(os.Stderr)
 	fmt.Fprintln(os.Stderr, "Canonical mode:")
-	fmt.Fprintln(os.Stderr, "  wid W=# A=# L=# D=# I=# E=# Z=# T=sec|ms R=auto|mqtt|ws|redis|null|stdout N=#")
-	fmt.Fprintln(os.Stderr, "  wid A=w-otp MODE=gen|verify KEY=<secret|path> [WID=<wid>] [CODE=<otp>] [DIGITS=6] [MAX_AGE_SEC=0] [MAX_FUTURE_SEC=5]")
+	fmt.Fprintln(os.Stderr, "  wid W=# A=# L=# D=# I=# E=# Z=# T=sec|ms R=auto|mqtt|ws|redis|null|stdout|webhook N=#")
+	fmt.Fprintln(os.Stderr, "  wid A=observe NODE=<name> [E=sql] reads RFC 3339 times, Unix epochs (s/ms) or HLC-WIDs on stdin")
+	fmt.Fprintln(os.Stderr, "  wid A=simulate RATE=<ids/sec> [DURATION_SEC=60] [JITTER_MS=0] [JUMP_SEC=<+/-s>] [JUMP_AT_SEC=<s>] [POLICY=borrow|wait] [SEED=1]")
+	fmt.Fprintln(os.Stderr, "    models W/Z/T against the workload: sequence exhaustion, future drift vs MAX_FUTURE_SEC, recommended W/T")
+	fmt.Fprintln(os.Stderr, "  KIND=wid|hlc [NODE=<name>] selects HLC-WIDs for next/stream, E=sql state and service loops")
+	fmt.Fprintln(os.Stderr, "  R=webhook URL=<http(s)://...> [WEBHOOK_SECRET=<secret|path>] [BATCH=1] [RETRIES=3] [BACKOFF_MS=200]")
+	fmt.Fprintln(os.Stderr, "  Transport queueing: [MAX_INFLIGHT=0] [BACKPRESSURE=block|drop-oldest|spill]")
+	fmt.Fprintln(os.Stderr, "  A=status [OUTPUT=text|json] adds uptime, IDs emitted, last ID/error and transport health from the daemon's status.json")
+	fmt.Fprintln(os.Stderr, "  A=ctl CMD=pause|resume|rotate-logs|stats|'set L=<sec>' adjusts the running daemon over its control socket")
+	fmt.Fprintln(os.Stderr, "  Daemon pid/log/socket live in $XDG_RUNTIME_DIR (or $XDG_STATE_HOME, ~/.local/state)/wid/go/<INSTANCE=default>; one an older release started in .local/wid/go is still found there")
+	fmt.Fprintln(os.Stderr, "  A=start HANDOFF=true upgrades a running daemon: the new one takes its generator state and the old one exits")
+	fmt.Fprintln(os.Stderr, "  STATUS_ADDR=<host:port> serves GET /status from a service loop; A=fleet-status PEERS=<host:port,...> [OUTPUT=json] aggregates them")
+	fmt.Fprintln(os.Stderr, "  A=state-export [OUT=<file>] / A=state-import [DATA=<file>|stdin] move persistent state between hosts; imports never move a generator backwards")
+	fmt.Fprintln(os.Stderr, "  Guardrails: [MAX_RATE=<ids/sec>] [MAX_QUEUE_BYTES=<n>] (SAF queue, spill) [MAX_LOG_BYTES=<n>] (daemon log rotation)")
+	fmt.Fprintln(os.Stderr, "  Service modules: MODULE_OPTS='{\"topic\":...,\"template\":...,\"interval\":...}' overrides [module.<name>] in $WID_CONFIG")
+	fmt.Fprintln(os.Stderr, "  wid A=keygen [OUT=<path>] [FINGERPRINT=true]  (Ed25519: PKCS#8 private key at OUT, mode 0600; PKIX public key at OUT.pub)")
+	fmt.Fprintln(os.Stderr, "  wid A=sign|verify WID=<wid> KEY=<path> [SIG=<sig>] [DATA=<path>] [OUT=<path>]  (KEY: PEM, or OpenSSH id_ed25519 / id_ed25519.pub)")
+	fmt.Fprintln(os.Stderr, "  KEY=ssh-agent[:<fingerprint|comment>] signs (and verifies) with an ssh-ed25519 key from ssh-agent ($SSH_AUTH_SOCK)")
+	fmt.Fprintln(os.Stderr, "  KEY=awskms://<key>|gcpkms://projects/.../cryptoKeyVersions/<v>|vault://[<mount>/]<key> signs in a KMS; the private key never touches disk")
+	fmt.Fprintln(os.Stderr, "  KEY may be Ed25519 (EdDSA), ECDSA P-256 (ES256) or RSA >= 2048 bits (PS256); FORMAT=jws records the alg")
+	fmt.Fprintln(os.Stderr, "  wid A=token KEY=<private> [WID=<wid>] prints <wid>.<sig>; A=token-verify KEY=<public> TOKEN=<token> [MAX_AGE_SEC=0] [MAX_FUTURE_SEC=5] prints the WID")
+	fmt.Fprintln(os.Stderr, "  A=verify|token-verify KEY=<dir>|<jwks.json> tries every key of a rotated key set; a FORMAT=jws kid picks the key first")
+	fmt.Fprintln(os.Stderr, "  KEY=https://.../jwks.json fetches the key set, cached under D= for KEY_TTL_SEC=300; KEY_PIN=<fp>[,...] keeps only keys with those fingerprints")
+	fmt.Fprintln(os.Stderr, "  A=verify KEY=<cert_chain.pem> [CA=<bundle.pem>] checks the chain at the WID's timestamp and prints the signer")
+	fmt.Fprintln(os.Stderr, "  A=sign KEY='pkcs11:token=<t>;object=<label>?module-path=<lib.so>' signs on a PKCS#11 token via pkcs11-tool")
+	fmt.Fprintln(os.Stderr, "  KEY=sigstore signs keylessly via cosign: A=sign OUT=<bundle>; A=verify SIG=<bundle> IDENTITY=<signer> ISSUER=<oidc_url>")
+	fmt.Fprintln(os.Stderr, "  A=verify KEYS=<path,...> SIGS=<sig,...> THRESHOLD=<m> (or BUNDLE=<file.json>) checks M-of-N signatures, JSON per key")
+	fmt.Fprintln(os.Stderr, "  A=verify KEY=<path> MANIFEST=<file> verifies wid<TAB>sig[<TAB>data] lines concurrently; JSON per line, summary on stderr")
+	fmt.Fprintln(os.Stderr, "  A=sign FORMAT=jws emits a JSON envelope (wid, alg, kid, signed_at, detached-payload JWS signature); A=verify accepts it as SIG=")
+	fmt.Fprintln(os.Stderr, "  A=sign|verify MODE=batch [IN=<file|->] signs WIDs / verifies envelopes, sign records or wid<TAB>sig lines; NDJSON per line, summary on stderr")
+	fmt.Fprintln(os.Stderr, "  A=sign TSA=<url> adds an RFC 3161 timestamp token to the signature; A=verify checks it [TSA_CA=<bundle.pem>]")
+	fmt.Fprintln(os.Stderr, "  wid A=w-otp MODE=gen|verify|secret KEY=<secret|path> [WID=<wid>] [CODE=<otp>] [DIGITS=6] [MAX_AGE_SEC=0] [MAX_FUTURE_SEC=5]")
+	fmt.Fprintln(os.Stderr, "  A=w-otp WINDOW_SEC=<n> derives the code from the WID's window; verify accepts the adjacent windows (WID= optional; Go-only, see CRYPTO_SPEC.md)")
+	fmt.Fprintln(os.Stderr, "  A=w-otp MODE=secret [OUT=wid_wotp.secret] [URI=true NODE=<node> ISSUER=<name>] writes a 256-bit secret (0600) and prints an otpauth://wotp/ URI")
+	fmt.Fprintln(os.Stderr, "  A=w-otp KEY_FORMAT=base32 HMACs with the base32-decoded KEY bytes, matching an otpauth URI's secret= (Go-only, see CRYPTO_SPEC.md)")
+	fmt.Fprintln(os.Stderr, "  A=w-otp STEP=<sec> binds the code to the WID and the current time step, so it expires; verify accepts +/-1 step (Go-only, see CRYPTO_SPEC.md)")
+	fmt.Fprintln(os.Stderr, "  wid A=paseto MODE=issue|verify KEY=<path> [WID=<wid>] [TOKEN=<v4.public...>] [EXP_SEC=0]  (PASETO v4.public with a wid claim)")
+	fmt.Fprintln(os.Stderr, "  wid A=next|stream CHAIN_KEY=<secret|path> prints <wid>\\t<tag>, tag = HMAC(key, prev_tag || wid), chained across runs")
+	fmt.Fprintln(os.Stderr, "  wid A=chain-verify CHAIN_KEY=<secret|path> [HEAD=<hex>]  (<wid>\\t<tag> lines on stdin)")
+	fmt.Fprintln(os.Stderr, "  wid A=hook CMD=<command> [N=#] [HOOK_CONCURRENCY=1] [HOOK_FAIL=stop|continue|ignore]")
 	fmt.Fprintln(os.Stderr, "  For A=stream: N=0 means infinite stream")
 	fmt.Fprintln(os.Stderr, "  E supports: state | stateless | sql")
+	fmt.Fprintln(os.Stderr, "  WID_DATA_KEY=<32 bytes hex|base64> (or WID_DATA_KEY_FILE) encrypts DLQ/spill/SAF queue files, chain head, SAF and spill acks, E=sql counters and the daemon log with AES-256-GCM")
+	fmt.Fprintln(os.Stderr, "  WID_DATA_KEY_KMS=awskms://...|gcpkms://...|vault://... unwraps WID_DATA_KEY(_FILE) with that KMS key")
+	fmt.Fprintln(os.Stderr, "  DRY_RUN=1 prints the paths, state keys and transports an action would touch, without side effects")
 }
 
 func printActions() {
 	fmt.Println(`wid action matrix
 
 Core ID:
-  A=next | A=stream | A=healthcheck | A=sign | A=verify | A=w-otp
+  A=next | A=stream | A=healthcheck | A=keygen | A=sign | A=verify | A=token | A=token-verify | A=w-otp | A=paseto | A=chain-verify
+
+Integrations:
+  A=hook     (runs CMD once per generated ID; ID in $WID and on stdin)
+  A=observe  (merges peer times from stdin into the HLC for NODE=; E=sql persists it)
+  A=simulate (models RATE=, jitter and clock jumps against W/T; recommends parameters)
 
 Service lifecycle (native):
   A=discover | A=scaffold | A=run | A=start | A=stop | A=status | A=logs
+  A=ctl (CMD=pause|resume|rotate-logs|stats|'set L=<sec>')
+  A=fleet-status (PEERS=<host:port,...>)
 
 Service modules (native):
-  A=saf      (alias: raf)
-  A=saf-wid  (aliases: waf, wraf)
+  A=saf      (alias: raf; store-and-forward via <data>/saf.queue.ndjson)
+  A=saf-wid  (aliases: waf, wraf; same queue semantics)
   A=wir      (alias: witr)
   A=wism     (alias: wim)
   A=wihp     (alias: wih)
   A=wipr     (alias: wip)
   A=duplex
 
+Dead-letter queue (records that exhausted transport retries):
+  A=dlq-list | A=dlq-replay
+
+State backup (SQL rows, DLQ, chain head, SAF queue; checksummed):
+  A=state-export [OUT=<file>] | A=state-import [DATA=<file>]
+
 Help:
   A=help-actions
 
@@ -1477,4 +2953,7 @@ State mode:
 }
 
 func errln(s string) { fmt.Fprintln(os.Stderr, "error:", s) }
-func exit(code int)  { os.Exit(code) }
+func exit(code int) {
+	flushDaemonLog()
+	os.Exit(code)
+}
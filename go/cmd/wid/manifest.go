@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	wid "github.com/waldiez/wid/go"
+)
+
+// manifestEntry is one `wid<TAB>sig[<TAB>data]` line of a MANIFEST= file.
+type manifestEntry struct {
+	line int
+	wid  string
+	sig  string
+	data string
+}
+
+type manifestResult struct {
+	Line      int    `json:"line"`
+	WID       string `json:"wid"`
+	Valid     bool   `json:"valid"`
+	Key       string `json:"key,omitempty"`
+	Alg       string `json:"alg,omitempty"`
+	Signer    string `json:"signer,omitempty"`
+	SignedAt  string `json:"signed_at,omitempty"`
+	Timestamp string `json:"timestamp,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func readManifest(path string) ([]manifestEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	base := filepath.Dir(path)
+	var out []manifestEntry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	n := 0
+	for sc.Scan() {
+		n++
+		line := strings.TrimRight(sc.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Split(line, "\t")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("%s:%d: expected wid<TAB>sig[<TAB>data]", path, n)
+		}
+		e := manifestEntry{line: n, wid: strings.TrimSpace(parts[0]), sig: strings.TrimSpace(parts[1])}
+		if len(parts) == 3 && strings.TrimSpace(parts[2]) != "" {
+			// Data paths are relative to the manifest.
+			e.data = strings.TrimSpace(parts[2])
+			if !filepath.IsAbs(e.data) {
+				e.data = filepath.Join(base, e.data)
+			}
+		}
+		out = append(out, e)
+	}
+	return out, sc.Err()
+}
+
+// runManifestVerify verifies every manifest entry under KEY= concurrently.
+// Per-line results are printed as JSON in manifest order and a summary goes
+// to stderr; the exit code is 0 only when every entry verifies.
+func runManifestVerify(c canon) int {
+	entries, err := readManifest(c.manifest)
+	if err != nil {
+		errln(err.Error())
+		return 1
+	}
+	if strings.TrimSpace(c.key) == "" {
+		errln("KEY=<public_key_path> required for A=verify MANIFEST=")
+		return 1
+	}
+	sharedKey, err := loadSharedKeys(c)
+	if err != nil {
+		errln(err.Error())
+		return 1
+	}
+
+	results := make([]manifestResult, len(entries))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = verifyManifestEntry(c, entries[i], sharedKey)
+			}
+		}()
+	}
+	for i := range entries {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	valid := 0
+	for _, r := range results {
+		if r.Valid {
+			valid++
+		}
+		printJSON(r)
+	}
+	summary, _ := json.Marshal(map[string]any{
+		"manifest": c.manifest, "entries": len(results), "valid": valid, "invalid": len(results) - valid,
+	})
+	fmt.Fprintln(os.Stderr, string(summary))
+	if valid != len(results) {
+		return 1
+	}
+	return 0
+}
+
+// loadSharedKeys loads KEY= once for a run over many WIDs. It returns nil
+// for a certificate chain, which is checked at each WID's own time instead.
+func loadSharedKeys(c canon) (*wid.KeySet, error) {
+	b, err := os.ReadFile(c.key)
+	if err == nil && strings.Contains(string(b), "-----BEGIN CERTIFICATE-----") {
+		return nil, nil
+	}
+	ks, _, err := loadVerifyKeySet(c)
+	return ks, err
+}
+
+func verifyManifestEntry(c canon, e manifestEntry, ks *wid.KeySet) manifestResult {
+	r := manifestResult{Line: e.line, WID: e.wid}
+	ec := c
+	ec.wid, ec.data = e.wid, e.data
+	msg, err := buildSignVerifyMessage(ec)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	if ks == nil {
+		if ks, r.Signer, err = loadVerifyKeySet(ec); err != nil {
+			r.Error = err.Error()
+			return r
+		}
+	}
+	sigPart, tokenPart, stamped := strings.Cut(e.sig, ".")
+	sig, err := b64urlDecode(sigPart)
+	if err != nil {
+		r.Error = "invalid signature encoding"
+		return r
+	}
+	var ok bool
+	if r.Key, ok = ks.Verify(msg, sig, ""); !ok {
+		r.Error = "signature invalid"
+		return r
+	}
+	if stamped {
+		token, err := b64urlDecode(tokenPart)
+		if err != nil {
+			r.Error = "invalid timestamp token encoding"
+			return r
+		}
+		at, _, err := verifyTimestamp(c, sig, token)
+		if err != nil {
+			r.Error = err.Error()
+			return r
+		}
+		r.Timestamp = at.UTC().Format(time.RFC3339Nano)
+	}
+	r.Valid = true
+	return r
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// moduleConfig is the per-module configuration of a service loop, merged from
+// the `[module.<name>]` config section and MODULE_OPTS (JSON wins). The keys
+// topic, template and interval are understood by the loop itself; everything
+// else (thresholds and the like) is passed through on each record as "opts".
+type moduleConfig struct {
+	topic    string
+	tmpl     *template.Template
+	interval int
+	extra    map[string]any
+}
+
+func loadModuleConfig(c canon, action string) (*moduleConfig, error) {
+	opts := map[string]any{}
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range cfg.sections["module."+action] {
+		opts[k] = configScalar(v)
+	}
+	if strings.TrimSpace(c.moduleOpts) != "" {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(c.moduleOpts), &m); err != nil {
+			return nil, fmt.Errorf("invalid MODULE_OPTS: %v", err)
+		}
+		for k, v := range m {
+			opts[k] = v
+		}
+	}
+	mc := &moduleConfig{interval: -1, extra: map[string]any{}}
+	for k, v := range opts {
+		switch k {
+		case "topic":
+			mc.topic = fmt.Sprint(v)
+		case "template":
+			t, err := template.New(action).Option("missingkey=zero").Parse(fmt.Sprint(v))
+			if err != nil {
+				return nil, fmt.Errorf("module %s: invalid template: %v", action, err)
+			}
+			mc.tmpl = t
+		case "interval":
+			n, err := strconv.Atoi(fmt.Sprint(v))
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("module %s: invalid interval", action)
+			}
+			mc.interval = n
+		default:
+			mc.extra[k] = v
+		}
+	}
+	return mc, nil
+}
+
+// apply decorates a record with the module's topic, options and rendered
+// payload template (executed against the record's fields).
+func (mc *moduleConfig) apply(rec map[string]any) error {
+	if mc.topic != "" {
+		rec["topic"] = mc.topic
+	}
+	if len(mc.extra) > 0 {
+		rec["opts"] = mc.extra
+	}
+	if mc.tmpl != nil {
+		var sb strings.Builder
+		if err := mc.tmpl.Execute(&sb, rec); err != nil {
+			return err
+		}
+		rec["payload"] = sb.String()
+	}
+	return nil
+}
+
+// configScalar restores the integer and boolean types the TOML subset
+// flattens to strings, so config and MODULE_OPTS values serialize alike.
+func configScalar(v string) any {
+	if v == "true" || v == "false" {
+		return v == "true"
+	}
+	if n, err := strconv.Atoi(v); err == nil {
+		return n
+	}
+	return v
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	wid "github.com/waldiez/wid/go"
+)
+
+// sigBundle is the BUNDLE= file for threshold verification.
+type sigBundle struct {
+	WID        string `json:"wid"`
+	Threshold  int    `json:"threshold"`
+	Signatures []struct {
+		Key string `json:"key"`
+		Sig string `json:"sig"`
+	} `json:"signatures"`
+}
+
+type keyResult struct {
+	Key   string `json:"key"`
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// runThresholdVerify checks a WID against M-of-N signatures. Keys and
+// signatures come from KEYS=/SIGS= (comma-separated) or a BUNDLE= JSON file.
+// A key counts once if any of the signatures verifies under it, so
+// signatures may be supplied in any order. Per-key results are printed as
+// JSON; the exit code is 0 only when at least THRESHOLD keys are satisfied.
+func runThresholdVerify(c canon) int {
+	keys, sigs := splitList(c.keys), splitList(c.sigs)
+	threshold := c.threshold
+	if strings.TrimSpace(c.bundle) != "" {
+		b, err := os.ReadFile(c.bundle)
+		if err != nil {
+			errln(err.Error())
+			return 1
+		}
+		var sb sigBundle
+		if err := json.Unmarshal(b, &sb); err != nil {
+			errln("invalid signature bundle: " + err.Error())
+			return 1
+		}
+		if c.wid == "" {
+			c.wid = sb.WID
+		} else if sb.WID != "" && sb.WID != c.wid {
+			errln("bundle is for a different WID: " + sb.WID)
+			return 1
+		}
+		if threshold == 0 {
+			threshold = sb.Threshold
+		}
+		for _, s := range sb.Signatures {
+			keys = append(keys, s.Key)
+			sigs = append(sigs, s.Sig)
+		}
+	}
+	if len(keys) == 0 || len(sigs) == 0 {
+		errln("KEYS=<path,...> and SIGS=<sig,...> (or BUNDLE=<file>) required for threshold verification")
+		return 1
+	}
+	if threshold < 1 || threshold > len(keys) {
+		errln(fmt.Sprintf("THRESHOLD must be between 1 and the number of keys (%d)", len(keys)))
+		return 1
+	}
+	msg, err := buildSignVerifyMessage(c)
+	if err != nil {
+		errln(err.Error())
+		return 1
+	}
+	var decoded [][]byte
+	for _, s := range sigs {
+		sigPart, _, _ := strings.Cut(s, ".")
+		if d, err := b64urlDecode(sigPart); err == nil {
+			decoded = append(decoded, d)
+		}
+	}
+
+	seen := map[string]bool{}
+	var results []keyResult
+	valid := 0
+	for _, k := range keys {
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		r := keyResult{Key: k}
+		kc := c
+		kc.key = k
+		pk, _, err := loadVerifyKey(kc)
+		if err != nil {
+			r.Error = err.Error()
+		} else if verifiesAny(pk, msg, decoded) {
+			r.Valid = true
+			valid++
+		} else {
+			r.Error = "no matching signature"
+		}
+		results = append(results, r)
+	}
+	ok := valid >= threshold
+	printJSON(map[string]any{
+		"wid":       c.wid,
+		"threshold": threshold,
+		"keys":      len(results),
+		"valid":     valid,
+		"ok":        ok,
+		"results":   results,
+	})
+	if ok {
+		return 0
+	}
+	return 1
+}
+
+func verifiesAny(pk crypto.PublicKey, msg []byte, sigs [][]byte) bool {
+	for _, s := range sigs {
+		if wid.VerifyMessage(pk, msg, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	wid "github.com/waldiez/wid/go"
+)
+
+// observeLine merges one peer reading into g: an RFC 3339 timestamp, a Unix
+// epoch (13+ digits are taken as milliseconds) or an HLC-WID of the same
+// shape, whose logical counter is merged too.
+func observeLine(g *wid.HLCWidGen, c canon, line string) error {
+	if n, err := strconv.ParseInt(line, 10, 64); err == nil {
+		if len(strings.TrimPrefix(line, "-")) >= 13 {
+			return g.ObserveUnixMilli(n)
+		}
+		return g.ObserveUnix(n)
+	}
+	if t, err := time.Parse(time.RFC3339Nano, line); err == nil {
+		return g.ObserveTime(t)
+	}
+	if err := g.ObserveWid(line); err == nil {
+		return nil
+	}
+	return errors.New("expected RFC 3339 time, Unix epoch or HLC-WID")
+}
+
+// runObserve advances the HLC for NODE= from peer readings on stdin and
+// prints the clock after each one. With E=sql the clock is loaded from and
+// saved back to the shared state row, so a later A=next KIND=hlc E=sql
+// issues IDs ahead of everything observed.
+func runObserve(c canon) int {
+	c.kind = "hlc"
+	stateMode, _ := parseStateTransport(c)
+	g, err := wid.NewHLCWidGenWithUnit(c.node, c.w, c.z, c.t)
+	if err != nil {
+		errln(err.Error())
+		return 1
+	}
+	bad := 0
+	sc := bufio.NewScanner(os.Stdin)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		observe := func(g *wid.HLCWidGen) error { return observeLine(g, c, line) }
+		if stateMode == "sql" {
+			err = sqlObserve(c, g, observe)
+		} else {
+			err = observe(g)
+		}
+		if err != nil {
+			errln(fmt.Sprintf("%s: %v", line, err))
+			bad++
+			continue
+		}
+		pt, lc := g.State()
+		printJSON(map[string]any{"input": line, "pt": pt, "lc": lc, "node": c.node, "time_unit": string(c.t)})
+	}
+	if err := sc.Err(); err != nil {
+		errln(err.Error())
+		return 1
+	}
+	if bad > 0 {
+		return 1
+	}
+	return 0
+}
+
+// sqlObserve applies observe to the persisted HLC state with the same
+// compare-and-swap loop that SQL allocation uses.
+func sqlObserve(c canon, g *wid.HLCWidGen, observe func(*wid.HLCWidGen) error) error {
+	dd := dataDir(c)
+	if err := os.MkdirAll(dd, 0o755); err != nil {
+		return err
+	}
+	dbPath, key := sqlStatePath(c), sqlStateKey(c)
+	if err := sqlEnsureState(dbPath, key); err != nil {
+		return err
+	}
+	for i := 0; i < 64; i++ {
+		lastTick, lastSeq, err := sqlLoadState(dbPath, key)
+		if err != nil {
+			return err
+		}
+		if err := restoreGen(g, lastTick, lastSeq); err != nil {
+			return err
+		}
+		if err := observe(g); err != nil {
+			return err
+		}
+		pt, lc := g.State()
+		ok, err := sqlCompareAndSwapState(dbPath, key, lastTick, lastSeq, pt, lc)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+	return errors.New("sql observe contention: retry budget exhausted")
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var outputFormats = map[string]bool{"text": true, "json": true, "ndjson": true, "csv": true}
+
+// field is one named value of an output record; order is preserved for csv.
+type field struct {
+	k string
+	v any
+}
+
+// emitter renders records for --output text|json|ndjson|csv. Text keeps each
+// command's human format; json and ndjson print one object per record (so
+// json stays streamable); csv prints a header row before the first record.
+type emitter struct {
+	format string
+	csv    *csv.Writer
+	header bool
+	pretty bool
+	color  bool
+}
+
+// noColor is set by --no-color; NO_COLOR in the environment has the same effect.
+var noColor bool
+
+func newEmitter(format string) *emitter {
+	e := &emitter{format: format}
+	if format == "csv" {
+		e.csv = csv.NewWriter(os.Stdout)
+	}
+	if format == "text" && stdoutIsTTY() {
+		e.pretty = true
+		e.color = !noColor && os.Getenv("NO_COLOR") == ""
+	}
+	return e
+}
+
+func stdoutIsTTY() bool {
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// emitTable behaves like emit, except that text output on a terminal is
+// rendered as an aligned (and, unless disabled, colorized) key/value table.
+func (e *emitter) emitTable(text string, fields ...field) {
+	if !e.pretty {
+		e.emit(text, fields...)
+		return
+	}
+	width := 0
+	for _, f := range fields {
+		if len(f.k) > width {
+			width = len(f.k)
+		}
+	}
+	for _, f := range fields {
+		key := fmt.Sprintf("%-*s", width, f.k)
+		val := csvValue(f.v)
+		if e.color {
+			key = ansi("36", key)
+			switch val {
+			case "true", "running", "ok":
+				val = ansi("32", val)
+			case "false", "stopped", "error":
+				val = ansi("31", val)
+			default:
+				val = ansi("1", val)
+			}
+		}
+		fmt.Printf("%s  %s\n", key, val)
+	}
+}
+
+func ansi(code, s string) string {
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
+
+func (e *emitter) emit(text string, fields ...field) {
+	switch e.format {
+	case "json", "ndjson":
+		m := make(map[string]any, len(fields))
+		for _, f := range fields {
+			m[f.k] = f.v
+		}
+		b, _ := json.Marshal(m)
+		fmt.Println(string(b))
+	case "csv":
+		if !e.header {
+			row := make([]string, len(fields))
+			for i, f := range fields {
+				row[i] = f.k
+			}
+			_ = e.csv.Write(row)
+			e.header = true
+		}
+		row := make([]string, len(fields))
+		for i, f := range fields {
+			row[i] = csvValue(f.v)
+		}
+		_ = e.csv.Write(row)
+		e.csv.Flush()
+	default:
+		fmt.Println(text)
+	}
+}
+
+func csvValue(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case *string:
+		if x == nil {
+			return ""
+		}
+		return *x
+	case []string:
+		return strings.Join(x, " ")
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+// kvText renders fields as key=value lines, the plain-text form of a record.
+func kvText(fields []field) string {
+	lines := make([]string, len(fields))
+	for i, f := range fields {
+		lines[i] = f.k + "=" + csvValue(f.v)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PASETO v4.public tokens carrying a WID claim, for environments where JOSE is
+// not allowed. The payload is {"wid": ..., "iat": ..., ["exp": ...]} signed
+// with the same Ed25519 keys as A=sign/A=verify; no footer or implicit
+// assertion is used.
+const pasetoHeader = "v4.public."
+
+// pasetoPAE is the PASETO pre-authentication encoding of pieces.
+func pasetoPAE(pieces ...[]byte) []byte {
+	le64 := func(n int) []byte {
+		b := make([]byte, 8)
+		binary.LittleEndian.PutUint64(b, uint64(n)&^(1<<63))
+		return b
+	}
+	out := le64(len(pieces))
+	for _, p := range pieces {
+		out = append(out, le64(len(p))...)
+		out = append(out, p...)
+	}
+	return out
+}
+
+func pasetoSign(priv ed25519.PrivateKey, payload []byte) string {
+	sig := ed25519.Sign(priv, pasetoPAE([]byte(pasetoHeader), payload, nil, nil))
+	return pasetoHeader + b64urlEncode(append(append([]byte(nil), payload...), sig...))
+}
+
+func pasetoOpen(pub ed25519.PublicKey, token string) ([]byte, error) {
+	body, ok := strings.CutPrefix(token, pasetoHeader)
+	if !ok {
+		return nil, errors.New("not a v4.public PASETO token")
+	}
+	if strings.Contains(body, ".") {
+		return nil, errors.New("PASETO footers are not supported")
+	}
+	raw, err := b64urlDecode(body)
+	if err != nil || len(raw) < ed25519.SignatureSize {
+		return nil, errors.New("malformed PASETO token")
+	}
+	payload, sig := raw[:len(raw)-ed25519.SignatureSize], raw[len(raw)-ed25519.SignatureSize:]
+	if !ed25519.Verify(pub, pasetoPAE([]byte(pasetoHeader), payload, nil, nil), sig) {
+		return nil, errors.New("PASETO signature invalid")
+	}
+	return payload, nil
+}
+
+// runPaseto issues (MODE=issue, KEY=<private key>) or verifies (MODE=verify,
+// KEY=<public key>, TOKEN=) a PASETO v4.public token for a WID.
+func runPaseto(c canon) int {
+	mode := strings.ToLower(strings.TrimSpace(c.mode))
+	if mode == "" {
+		mode = "issue"
+	}
+	if strings.TrimSpace(c.key) == "" {
+		errln("KEY=<key_path> required for A=paseto")
+		return 1
+	}
+	switch mode {
+	case "issue":
+		priv, err := loadEd25519PrivateKey(c.key)
+		if err != nil {
+			errln(err.Error())
+			return 1
+		}
+		id := strings.TrimSpace(c.wid)
+		if id == "" {
+			g, err := newCanonGen(c)
+			if err != nil {
+				errln(err.Error())
+				return 1
+			}
+			id = g.Next()
+		}
+		now := time.Now().UTC()
+		claims := map[string]any{"wid": id, "iat": now.Format(time.RFC3339)}
+		if c.expSec > 0 {
+			claims["exp"] = now.Add(time.Duration(c.expSec) * time.Second).Format(time.RFC3339)
+		}
+		payload, _ := json.Marshal(claims)
+		fmt.Println(pasetoSign(priv, payload))
+		return 0
+	case "verify":
+		if strings.TrimSpace(c.token) == "" {
+			errln("TOKEN=<paseto> required for A=paseto MODE=verify")
+			return 1
+		}
+		key, _, err := loadVerifyKey(c)
+		if err != nil {
+			errln(err.Error())
+			return 1
+		}
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			errln("PASETO v4.public requires an Ed25519 key")
+			return 1
+		}
+		payload, err := pasetoOpen(pub, strings.TrimSpace(c.token))
+		if err != nil {
+			errln(err.Error())
+			return 1
+		}
+		var claims struct {
+			WID string `json:"wid"`
+			Exp string `json:"exp"`
+		}
+		if err := json.Unmarshal(payload, &claims); err != nil || claims.WID == "" {
+			errln("PASETO payload has no wid claim")
+			return 1
+		}
+		if c.wid != "" && c.wid != claims.WID {
+			errln("PASETO wid claim does not match WID=")
+			return 1
+		}
+		if claims.Exp != "" {
+			exp, err := time.Parse(time.RFC3339, claims.Exp)
+			if err != nil || !time.Now().Before(exp) {
+				errln("PASETO token expired")
+				return 1
+			}
+		}
+		fmt.Println(string(payload))
+		return 0
+	default:
+		errln("MODE must be issue or verify for A=paseto")
+		return 1
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// KEY=pkcs11:... signs with a key held in a PKCS#11 token (YubiKey, HSM,
+// SoftHSM) so the private key never exists in a file. The URI follows
+// RFC 7512: path attributes token, object, id and slot-id select the key, and
+// the query attributes module-path and pin-value (or $PKCS11_MODULE and
+// $WID_PKCS11_PIN) locate the module and log in. Signing is delegated to
+// OpenSC's pkcs11-tool using the EDDSA mechanism, so the token must hold an
+// Ed25519 key; its public key verifies the result with A=verify as usual.
+type pkcs11URI struct {
+	token, object, id, slot string
+	module, pin             string
+}
+
+func isPKCS11(key string) bool {
+	return strings.HasPrefix(strings.TrimSpace(key), "pkcs11:")
+}
+
+func parsePKCS11URI(raw string) (pkcs11URI, error) {
+	var u pkcs11URI
+	body := strings.TrimPrefix(strings.TrimSpace(raw), "pkcs11:")
+	path, query, _ := strings.Cut(body, "?")
+	for _, attr := range strings.Split(path, ";") {
+		if attr == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(attr, "=")
+		if !ok {
+			return u, fmt.Errorf("invalid pkcs11 URI attribute: %s", attr)
+		}
+		v, err := url.PathUnescape(v)
+		if err != nil {
+			return u, fmt.Errorf("invalid pkcs11 URI value for %s", k)
+		}
+		switch k {
+		case "token":
+			u.token = v
+		case "object":
+			u.object = v
+		case "id":
+			u.id = hex.EncodeToString([]byte(v))
+		case "slot-id":
+			u.slot = v
+		}
+	}
+	q, err := url.ParseQuery(query)
+	if err != nil {
+		return u, errors.New("invalid pkcs11 URI query")
+	}
+	u.module = q.Get("module-path")
+	u.pin = q.Get("pin-value")
+	if u.module == "" {
+		u.module = os.Getenv("PKCS11_MODULE")
+	}
+	if u.pin == "" {
+		u.pin = os.Getenv("WID_PKCS11_PIN")
+	}
+	if u.module == "" {
+		return u, errors.New("pkcs11 URI needs module-path=<lib.so> (or $PKCS11_MODULE)")
+	}
+	if u.object == "" && u.id == "" {
+		return u, errors.New("pkcs11 URI needs object=<label> or id=<key id>")
+	}
+	return u, nil
+}
+
+// pkcs11Sign signs msg with the token key; pkcs11-tool prompts for the PIN on
+// the terminal when none is configured.
+func pkcs11Sign(key string, msg []byte) ([]byte, error) {
+	u, err := parsePKCS11URI(key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := exec.LookPath("pkcs11-tool"); err != nil {
+		return nil, errors.New("KEY=pkcs11:... requires pkcs11-tool (OpenSC) on PATH")
+	}
+	dir, err := os.MkdirTemp("", "wid-pkcs11-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	in, out := filepath.Join(dir, "message"), filepath.Join(dir, "signature")
+	if err := os.WriteFile(in, msg, 0o600); err != nil {
+		return nil, err
+	}
+	args := []string{"--module", u.module, "--sign", "--mechanism", "EDDSA", "--login",
+		"--input-file", in, "--output-file", out}
+	if u.token != "" {
+		args = append(args, "--token-label", u.token)
+	}
+	if u.slot != "" {
+		args = append(args, "--slot", u.slot)
+	}
+	if u.object != "" {
+		args = append(args, "--label", u.object)
+	}
+	if u.id != "" {
+		args = append(args, "--id", u.id)
+	}
+	cmd := exec.Command("pkcs11-tool", args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stderr, os.Stderr
+	if u.pin != "" {
+		// Passed through the environment rather than argv to keep it out of ps.
+		cmd.Args = append(cmd.Args, "--pin", "env:WID_PKCS11_PIN")
+		cmd.Env = append(os.Environ(), "WID_PKCS11_PIN="+u.pin)
+	}
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("pkcs11-tool signing failed: %v", err)
+	}
+	sig, err := os.ReadFile(out)
+	if err != nil {
+		return nil, err
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("token returned a %d-byte signature; an Ed25519 key is required", len(sig))
+	}
+	return sig, nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// runtimeInstance names the per-instance runtime subdirectory, so several
+// daemons (INSTANCE= or $WID_INSTANCE) never share a pid file.
+var runtimeInstance = defaultInstance()
+
+func defaultInstance() string {
+	if v := os.Getenv("WID_INSTANCE"); validInstanceName(v) {
+		return v
+	}
+	return "default"
+}
+
+// runtimeBase is the absolute directory holding per-instance runtime files:
+// $XDG_RUNTIME_DIR/wid/go, else $XDG_STATE_HOME/wid/go, else
+// ~/.local/state/wid/go. Only without a home directory does it fall back to
+// the historical cwd-relative .local/wid/go.
+func runtimeBase() string {
+	if d := os.Getenv("XDG_RUNTIME_DIR"); d != "" {
+		return filepath.Join(d, "wid", "go")
+	}
+	if d := os.Getenv("XDG_STATE_HOME"); d != "" {
+		return filepath.Join(d, "wid", "go")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".local", "state", "wid", "go")
+	}
+	return filepath.Clean(legacyRuntimeDir)
+}
+
+// legacyRuntimeDir is where releases before per-instance directories kept
+// the pid file, control socket and log, shared by every instance.
+const legacyRuntimeDir = ".local/wid/go"
+
+// legacyDaemonRunning reports whether a daemon such a release started is
+// still alive with none running in its new directory. It is checked once
+// per process, so a daemon and the commands driving it agree on one
+// directory for as long as it runs.
+var legacyDaemonRunning = sync.OnceValue(func() bool {
+	if pid, start, ok := readPid(filepath.Join(runtimeBase(), "default", "service.pid")); ok && pidMatches(pid, start) {
+		return false
+	}
+	pid, start, ok := readPid(filepath.Join(legacyRuntimeDir, "service.pid"))
+	return ok && pidMatches(pid, start)
+})
+
+// runtimeDir is the instance's directory under runtimeBase, except that the
+// default instance stays in legacyRuntimeDir while a daemon started there
+// before an upgrade is alive, so A=stop and A=status still find it. Its
+// next A=start after a stop moves it over.
+func runtimeDir() string {
+	if runtimeInstance == "default" && legacyDaemonRunning() {
+		return filepath.Clean(legacyRuntimeDir)
+	}
+	return filepath.Join(runtimeBase(), runtimeInstance)
+}
+
+func runtimePid() string { return filepath.Join(runtimeDir(), "service.pid") }
+func runtimeLog() string { return filepath.Join(runtimeDir(), "service.log") }
+
+func validInstanceName(s string) bool {
+	if s == "" || s == "." || s == ".." {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// procStartTime returns the kernel start time of pid (field 22 of
+// /proc/<pid>/stat), or "" where procfs is unavailable.
+func procStartTime(pid int) string {
+	b, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return ""
+	}
+	// The command name may contain spaces; fields resume after its ')'.
+	i := strings.LastIndexByte(string(b), ')')
+	if i < 0 {
+		return ""
+	}
+	f := strings.Fields(string(b[i+1:]))
+	if len(f) < 20 {
+		return ""
+	}
+	return f[19]
+}
+
+// readPid parses a pid file of the form "<pid> [<start time>]".
+func readPid(path string) (int, string, bool) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0, "", false
+	}
+	f := strings.Fields(string(b))
+	if len(f) == 0 {
+		return 0, "", false
+	}
+	pid, err := strconv.Atoi(f[0])
+	if err != nil || pid <= 0 {
+		return 0, "", false
+	}
+	start := ""
+	if len(f) > 1 {
+		start = f[1]
+	}
+	return pid, start, true
+}
+
+func pidAlive(pid int) bool {
+	return syscall.Kill(pid, 0) == nil
+}
+
+// pidMatches reports whether pid is alive and, when a start time was
+// recorded, is still the same process rather than a reused pid.
+func pidMatches(pid int, start string) bool {
+	if !pidAlive(pid) {
+		return false
+	}
+	return start == "" || procStartTime(pid) == "" || procStartTime(pid) == start
+}
+
+// runningDaemon returns the daemon recorded in the pid file, removing the
+// file when it is stale.
+func runningDaemon() (int, bool) {
+	pid, start, ok := readPid(runtimePid())
+	if ok && pidMatches(pid, start) {
+		return pid, true
+	}
+	if ok || fileExists(runtimePid()) {
+		_ = os.Remove(runtimePid())
+	}
+	return 0, false
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func pidLine(pid int) []byte {
+	return []byte(strings.TrimSpace(fmt.Sprintf("%d %s", pid, procStartTime(pid))) + "\n")
+}
+
+var errPidFileExists = errors.New("pid file already exists")
+
+// claimPidFile creates the pid file with O_EXCL on behalf of the current
+// process, so two concurrent starts cannot both launch a daemon. A stale
+// file left by a dead process is removed and the claim retried once.
+func claimPidFile() error {
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(runtimePid(), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_, err = f.Write(pidLine(os.Getpid()))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			return err
+		}
+		if !os.IsExist(err) {
+			return err
+		}
+		if _, running := runningDaemon(); running {
+			return errPidFileExists
+		}
+	}
+	return errPidFileExists
+}
+
+// writePidFile atomically records pid as the running daemon.
+func writePidFile(pid int) error {
+	tmp := runtimePid() + ".tmp"
+	if err := os.WriteFile(tmp, pidLine(pid), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, runtimePid())
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func safQueuePath(c canon) string {
+	return filepath.Join(dataDir(c), "saf.queue.ndjson")
+}
+
+func safAckPath(c canon) string {
+	return filepath.Join(dataDir(c), "saf.ack")
+}
+
+// syncer is implemented by transports that buffer records (webhook BATCH > 1)
+// so store-and-forward can push a partial batch before acknowledging it.
+type syncer interface {
+	sync() error
+}
+
+// safPublisher gives A=saf/A=saf-wid store-and-forward semantics: every
+// record is first appended to a durable on-disk queue, then forwarded in
+// order. The number of forwarded records is persisted as the ack offset only
+// after the transport confirms delivery, so records that fail (after the
+// transport's own retries) stay queued and are re-forwarded on the next
+// publish or after a daemon restart. The queue is truncated once fully acked.
+type safPublisher struct {
+	inner     publisher
+	queuePath string
+	ackPath   string
+	batch     int
+	maxBytes  int64
+
+	queued    int64
+	forwarded int64
+	failures  int64
+}
+
+func newSAFPublisher(inner publisher, c canon) (*safPublisher, error) {
+	p := &safPublisher{inner: inner, queuePath: safQueuePath(c), ackPath: safAckPath(c), batch: c.batch, maxBytes: c.maxQueueBytes}
+	if p.batch < 1 {
+		p.batch = 1
+	}
+	if err := os.MkdirAll(filepath.Dir(p.queuePath), 0o755); err != nil {
+		return nil, err
+	}
+	// Forward whatever a previous run left behind before taking new records.
+	if err := p.forward(); err != nil {
+		errln(err.Error())
+	}
+	return p, nil
+}
+
+func (p *safPublisher) publish(rec map[string]any) error {
+	b, _ := json.Marshal(rec)
+	if !queueHasRoom(p.queuePath, len(b)+1, p.maxBytes) {
+		// Try to make room by forwarding (and compacting) first.
+		_ = p.forward()
+		if !queueHasRoom(p.queuePath, len(b)+1, p.maxBytes) {
+			noteGuardrail("queue_full")
+			return fmt.Errorf("store-and-forward: record rejected: %w", errQueueFull)
+		}
+	}
+	if err := appendNDJSON(p.queuePath, rec); err != nil {
+		return err
+	}
+	p.queued++
+	return p.forward()
+}
+
+func (p *safPublisher) close() error {
+	err := p.forward()
+	pending, _ := p.pending()
+	b, _ := json.Marshal(map[string]any{"metric": "saf", "stats": map[string]any{
+		"queued": p.queued, "forwarded": p.forwarded, "failures": p.failures, "pending": len(pending), "queue": p.queuePath,
+	}})
+	fmt.Fprintln(os.Stderr, string(b))
+	if cerr := p.inner.close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (p *safPublisher) readAck() int { return readOffset(p.ackPath) }
+
+func (p *safPublisher) writeAck(n int) error { return writeOffset(p.ackPath, n) }
+
+// readOffset reads a delivered-record count written by writeOffset; a
+// missing or unreadable file counts as 0.
+func readOffset(path string) int {
+	b, err := readDataFile(path)
+	if err != nil {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+func writeOffset(path string, n int) error {
+	return writeDataFile(path, []byte(strconv.Itoa(n)))
+}
+
+// pending returns the queued records past the ack offset.
+func (p *safPublisher) pending() ([]map[string]any, int) {
+	recs, err := readNDJSON(p.queuePath)
+	if err != nil {
+		return nil, 0
+	}
+	acked := p.readAck()
+	if acked > len(recs) {
+		// The queue was truncated but the ack reset did not land; start over.
+		acked = len(recs)
+	}
+	return recs[acked:], acked
+}
+
+// forward delivers pending records in chunks of BATCH, acknowledging each
+// chunk only once the transport has accepted all of it.
+func (p *safPublisher) forward() error {
+	if _, err := os.Stat(p.queuePath); os.IsNotExist(err) {
+		return p.writeAckIfSet(0)
+	}
+	recs, acked := p.pending()
+	for len(recs) > 0 {
+		n := p.batch
+		if n > len(recs) {
+			n = len(recs)
+		}
+		var err error
+		for _, rec := range recs[:n] {
+			if err = p.inner.publish(rec); err != nil {
+				break
+			}
+		}
+		if s, ok := p.inner.(syncer); ok && err == nil {
+			err = s.sync()
+		}
+		if err != nil {
+			p.failures++
+			return fmt.Errorf("store-and-forward: %v (%d record(s) kept in %s)", err, len(recs), p.queuePath)
+		}
+		acked += n
+		p.forwarded += int64(n)
+		if err := p.writeAck(acked); err != nil {
+			return err
+		}
+		recs = recs[n:]
+	}
+	// Fully acknowledged: truncate the queue, then reset the offset.
+	if err := os.Remove(p.queuePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return p.writeAck(0)
+}
+
+func (p *safPublisher) writeAckIfSet(n int) error {
+	if p.readAck() == n {
+		return nil
+	}
+	return p.writeAck(n)
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	wid "github.com/waldiez/wid/go"
+)
+
+// simMaxEvents bounds A=simulate so a typo in RATE= cannot pin a core for
+// minutes.
+const simMaxEvents = 50_000_000
+
+// simResult is what one simulated run observed.
+type simResult struct {
+	ids           int
+	exhaustions   int
+	regressions   int
+	ahead         int
+	maxDriftMs    float64
+	futureRejects int
+	peakPerTick   int
+	stallMs       float64
+	maxStallMs    float64
+}
+
+// simulate replays RATE= ids/sec for DURATION_SEC= against a virtual clock
+// that reads true time plus uniform ±JITTER_MS= noise and, from JUMP_AT_SEC=
+// on, a JUMP_SEC= step. The generator follows the library's rules: it never
+// goes back a tick and, when the sequence is exhausted, either borrows the
+// next tick (POLICY=borrow, what Next does) or waits for the clock to reach
+// it (POLICY=wait).
+func simulate(c canon) simResult {
+	var r simResult
+	unitNs := int64(time.Second)
+	if c.t == wid.TimeUnitMs {
+		unitNs = int64(time.Millisecond)
+	}
+	maxSeq := int(math.Pow10(c.w)) - 1
+	rng := rand.New(rand.NewSource(c.seed))
+	interval := float64(time.Second) / c.rate
+	total := int(c.rate * c.durationSec)
+	jitterNs := c.jitterMs * float64(time.Millisecond)
+	jumpAt := int64(c.jumpAtSec * float64(time.Second))
+	jumpNs := int64(c.jumpSec * float64(time.Second))
+	offset := func(t int64) int64 {
+		if jumpNs != 0 && t >= jumpAt {
+			return jumpNs
+		}
+		return 0
+	}
+	maxFutureMs := float64(c.maxFutureSec) * 1000
+
+	var (
+		lastTick, lastClock int64 = math.MinInt64, math.MinInt64
+		lastSeq             int
+		busyUntil           int64
+		trueTick            int64 = math.MinInt64
+		inTick              int
+	)
+	for i := 0; i < total; i++ {
+		arrival := int64(float64(i) * interval)
+		if tt := arrival / unitNs; tt != trueTick {
+			trueTick, inTick = tt, 0
+		}
+		inTick++
+		r.peakPerTick = max(r.peakPerTick, inTick)
+		t := max(arrival, busyUntil)
+
+		clock := t + offset(t)
+		if jitterNs > 0 {
+			clock += int64((rng.Float64()*2 - 1) * jitterNs)
+		}
+		now := floorDiv(clock, unitNs)
+		if lastClock != math.MinInt64 && now < lastClock {
+			r.regressions++
+		}
+		lastClock = now
+
+		tick, seq := now, 0
+		if tick <= lastTick {
+			tick = lastTick
+			seq = lastSeq + 1
+		}
+		if seq > maxSeq {
+			r.exhaustions++
+			tick, seq = lastTick+1, 0
+			if c.policy == "wait" {
+				// block until the (jitter-free) clock reads the next tick
+				ready := tick*unitNs - offset(t)
+				if ready > t {
+					stall := float64(ready-t) / float64(time.Millisecond)
+					r.stallMs += stall
+					r.maxStallMs = math.Max(r.maxStallMs, stall)
+					t, busyUntil = ready, ready
+				}
+			}
+		}
+		lastTick, lastSeq = tick, seq
+		r.ids++
+
+		drift := float64(tick*unitNs-t) / float64(time.Millisecond)
+		if tick > floorDiv(t, unitNs) {
+			r.ahead++
+			r.maxDriftMs = math.Max(r.maxDriftMs, drift)
+			if drift > maxFutureMs {
+				r.futureRejects++
+			}
+		}
+	}
+	return r
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// simRecommendW is the narrowest W whose sequence space holds the busiest
+// tick twice over.
+func simRecommendW(peak int) int {
+	need := 2 * peak
+	for w := 1; w <= wid.MaxW; w++ {
+		if int(math.Pow10(w))-1 >= need {
+			return w
+		}
+	}
+	return wid.MaxW
+}
+
+// runSimulate models a workload against the chosen W/Z/T and policy and
+// reports sequence exhaustion, future drift and recommended parameters.
+func runSimulate(c canon) int {
+	if c.rate <= 0 {
+		errln("RATE=<ids/sec> required for A=simulate")
+		return 1
+	}
+	if c.durationSec <= 0 {
+		errln("DURATION_SEC must be > 0")
+		return 1
+	}
+	if c.rate*c.durationSec > simMaxEvents {
+		errln(fmt.Sprintf("RATE*DURATION_SEC exceeds %d simulated IDs; shorten DURATION_SEC", simMaxEvents))
+		return 1
+	}
+	if c.w <= 0 || c.w > wid.MaxW {
+		errln("invalid W")
+		return 1
+	}
+	switch c.policy {
+	case "borrow", "wait":
+	default:
+		errln("POLICY must be borrow or wait")
+		return 1
+	}
+	if c.jumpAtSec < 0 {
+		c.jumpAtSec = c.durationSec / 2
+	}
+	r := simulate(c)
+
+	recW := simRecommendW(r.peakPerTick)
+	recT := c.t
+	if c.t == wid.TimeUnitSec && c.rate >= math.Pow10(wid.MaxW-1) {
+		recT = wid.TimeUnitMs
+		recW = simRecommendW(int(math.Ceil(c.rate / 1000)))
+	}
+	recFuture := c.maxFutureSec
+	if f := int(math.Ceil(r.maxDriftMs / 1000)); f > recFuture {
+		recFuture = f
+	}
+	notes := []string{}
+	if r.exhaustions > 0 {
+		notes = append(notes, fmt.Sprintf("sequence exhausted %d time(s) at W=%d", r.exhaustions, c.w))
+	}
+	if r.futureRejects > 0 {
+		notes = append(notes, fmt.Sprintf("%d ID(s) ran more than MAX_FUTURE_SEC=%d ahead and would fail validation", r.futureRejects, c.maxFutureSec))
+	}
+	if c.jumpSec < 0 {
+		notes = append(notes, fmt.Sprintf("a %.3gs backwards jump is absorbed by the last tick's sequence; W=%d holds it without borrowing", -c.jumpSec, simRecommendW(int(math.Ceil(-c.jumpSec*c.rate/2)))))
+	}
+
+	format := c.output
+	if format == "" {
+		format = "json"
+	}
+	text := fmt.Sprintf("ids=%d exhaustions=%d clock_regressions=%d ids_ahead=%d max_future_drift_ms=%.0f future_rejects=%d peak_per_tick=%d recommended W=%d T=%s MAX_FUTURE_SEC=%d",
+		r.ids, r.exhaustions, r.regressions, r.ahead, r.maxDriftMs, r.futureRejects, r.peakPerTick, recW, recT, recFuture)
+	if c.policy == "wait" {
+		text += fmt.Sprintf(" stall_ms=%.0f max_stall_ms=%.0f", r.stallMs, r.maxStallMs)
+	}
+	if len(notes) > 0 {
+		text += "\n" + strings.Join(notes, "\n")
+	}
+	newEmitter(format).emitTable(text,
+		field{"impl", "go"},
+		field{"kind", c.kind},
+		field{"W", c.w},
+		field{"T", string(c.t)},
+		field{"policy", c.policy},
+		field{"rate", c.rate},
+		field{"duration_sec", c.durationSec},
+		field{"ids", r.ids},
+		field{"exhaustions", r.exhaustions},
+		field{"clock_regressions", r.regressions},
+		field{"ids_ahead", r.ahead},
+		field{"max_future_drift_ms", r.maxDriftMs},
+		field{"future_rejects", r.futureRejects},
+		field{"peak_per_tick", r.peakPerTick},
+		field{"stall_ms", r.stallMs},
+		field{"max_stall_ms", r.maxStallMs},
+		field{"recommended_W", recW},
+		field{"recommended_T", string(recT)},
+		field{"recommended_max_future_sec", recFuture},
+		field{"notes", notes},
+	)
+	return 0
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const stateArchiveFormat = "wid-state"
+const stateArchiveVersion = 1
+
+// stateArchiveFiles are the data-dir files carried verbatim by
+// A=state-export; sealed files stay sealed and need the same WID_DATA_KEY.
+var stateArchiveFiles = []string{"dlq.ndjson", "chain.head", "saf.queue.ndjson", "saf.ack", "spill.ndjson", "spill.ack"}
+
+type stateRow struct {
+	K        string `json:"k"`
+	LastTick int64  `json:"last_tick"`
+	LastSeq  int64  `json:"last_seq"`
+}
+
+// stateArchive is the versioned export document. Checksums holds a SHA-256
+// per section ("sql_rows" and each file); SHA256 covers the whole document
+// with that field empty.
+type stateArchive struct {
+	Format    string            `json:"format"`
+	Version   int               `json:"version"`
+	CreatedAt string            `json:"created_at"`
+	Impl      string            `json:"impl"`
+	SQLRows   []stateRow        `json:"sql_rows"`
+	Files     map[string][]byte `json:"files"`
+	Checksums map[string]string `json:"checksums"`
+	SHA256    string            `json:"sha256"`
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func (a *stateArchive) digest() string {
+	cp := *a
+	cp.SHA256 = ""
+	b, _ := json.Marshal(cp)
+	return sha256Hex(b)
+}
+
+func rowsChecksum(rows []stateRow) string {
+	b, _ := json.Marshal(rows)
+	return sha256Hex(b)
+}
+
+// sqlExportRows reads every generator row from the SQL state database,
+// opening sealed rows: the archive carries counters, not ciphertext, so it
+// imports under any key.
+func sqlExportRows(dbPath string) ([]stateRow, error) {
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+	tables, err := sqliteExec(dbPath, "SELECT name FROM sqlite_master WHERE type='table';")
+	if err != nil {
+		return nil, err
+	}
+	var rows []stateRow
+	has := func(t string) bool { return slices.Contains(strings.Split(tables, "\n"), t) }
+	if has("wid_state_sealed") {
+		raw, err := sqliteExec(dbPath, "SELECT k || '|' || v FROM wid_state_sealed ORDER BY k;")
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(raw, "\n") {
+			i := strings.LastIndexByte(line, '|')
+			if i < 0 {
+				continue
+			}
+			tick, seq, err := openStateRow(line[:i], line[i+1:])
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, stateRow{K: line[:i], LastTick: tick, LastSeq: int64(seq)})
+		}
+	}
+	if !has("wid_state") {
+		return rows, nil
+	}
+	raw, err := sqliteExec(dbPath, "SELECT k || '|' || last_tick || '|' || last_seq FROM wid_state ORDER BY k;")
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(raw, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		parts := strings.Split(line, "|")
+		if len(parts) < 3 {
+			return nil, errors.New("invalid sql state row")
+		}
+		n := len(parts)
+		tick, err1 := strconv.ParseInt(parts[n-2], 10, 64)
+		seq, err2 := strconv.ParseInt(parts[n-1], 10, 64)
+		if err1 != nil || err2 != nil {
+			return nil, errors.New("invalid sql state row")
+		}
+		rows = append(rows, stateRow{K: strings.Join(parts[:n-2], "|"), LastTick: tick, LastSeq: seq})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].K < rows[j].K })
+	return rows, nil
+}
+
+func runStateExport(c canon) int {
+	dd := dataDir(c)
+	rows, err := sqlExportRows(sqlStatePath(c))
+	if err != nil {
+		errln("state-export: " + err.Error())
+		return 1
+	}
+	a := &stateArchive{
+		Format:    stateArchiveFormat,
+		Version:   stateArchiveVersion,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		Impl:      "wid-go",
+		SQLRows:   rows,
+		Files:     map[string][]byte{},
+		Checksums: map[string]string{"sql_rows": rowsChecksum(rows)},
+	}
+	for _, name := range stateArchiveFiles {
+		b, err := os.ReadFile(filepath.Join(dd, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			errln("state-export: " + err.Error())
+			return 1
+		}
+		a.Files[name] = b
+		a.Checksums[name] = sha256Hex(b)
+	}
+	a.SHA256 = a.digest()
+	out, _ := json.MarshalIndent(a, "", "  ")
+	out = append(out, '\n')
+	if c.out == "" {
+		_, _ = os.Stdout.Write(out)
+	} else if err := os.WriteFile(c.out, out, 0o600); err != nil {
+		errln("state-export: " + err.Error())
+		return 1
+	}
+	summary, _ := json.Marshal(map[string]any{"exported": c.out, "sql_rows": len(rows), "files": len(a.Files), "sha256": a.SHA256})
+	fmt.Fprintln(os.Stderr, string(summary))
+	return 0
+}
+
+// verify checks the format, version and every checksum before anything is
+// written, so a truncated or edited archive is rejected as a whole.
+func (a *stateArchive) verify() error {
+	if a.Format != stateArchiveFormat {
+		return fmt.Errorf("not a %s archive", stateArchiveFormat)
+	}
+	if a.Version != stateArchiveVersion {
+		return fmt.Errorf("unsupported archive version %d", a.Version)
+	}
+	if a.SHA256 == "" || a.digest() != a.SHA256 {
+		return errors.New("archive checksum mismatch")
+	}
+	if a.Checksums["sql_rows"] != rowsChecksum(a.SQLRows) {
+		return errors.New("sql_rows checksum mismatch")
+	}
+	for name, b := range a.Files {
+		if !isStateArchiveFile(name) {
+			return fmt.Errorf("unexpected file in archive: %s", name)
+		}
+		if a.Checksums[name] != sha256Hex(b) {
+			return fmt.Errorf("%s checksum mismatch", name)
+		}
+	}
+	for _, r := range a.SQLRows {
+		if r.LastTick < 0 || r.LastSeq < -1 {
+			return fmt.Errorf("invalid state row %s", r.K)
+		}
+	}
+	return nil
+}
+
+func isStateArchiveFile(name string) bool {
+	for _, f := range stateArchiveFiles {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// runStateImport restores an archive from DATA= (or stdin). SQL rows merge
+// by maximum, so an import can only move a generator forward; data files
+// are restored only where absent (or identical) to avoid clobbering queues.
+func runStateImport(c canon) int {
+	var raw []byte
+	var err error
+	if c.data == "" {
+		raw, err = io.ReadAll(os.Stdin)
+	} else {
+		raw, err = os.ReadFile(c.data)
+	}
+	if err != nil {
+		errln("state-import: " + err.Error())
+		return 1
+	}
+	var a stateArchive
+	if err := json.Unmarshal(raw, &a); err != nil {
+		errln("state-import: invalid archive: " + err.Error())
+		return 1
+	}
+	if err := a.verify(); err != nil {
+		errln("state-import: " + err.Error())
+		return 1
+	}
+	dd := dataDir(c)
+	restore := []string{}
+	for _, name := range stateArchiveFiles {
+		b, ok := a.Files[name]
+		if !ok {
+			continue
+		}
+		cur, err := os.ReadFile(filepath.Join(dd, name))
+		switch {
+		case os.IsNotExist(err):
+			restore = append(restore, name)
+		case err != nil:
+			errln("state-import: " + err.Error())
+			return 1
+		case !bytes.Equal(cur, b):
+			errln("state-import: " + filepath.Join(dd, name) + " exists with different content; move it aside first")
+			return 1
+		}
+	}
+	if err := os.MkdirAll(dd, 0o755); err != nil {
+		errln("state-import: " + err.Error())
+		return 1
+	}
+	if len(a.SQLRows) > 0 {
+		if err := sqlImportRows(sqlStatePath(c), a.SQLRows); err != nil {
+			errln("state-import: " + err.Error())
+			return 1
+		}
+	}
+	for _, name := range restore {
+		if err := os.WriteFile(filepath.Join(dd, name), a.Files[name], 0o600); err != nil {
+			errln("state-import: " + err.Error())
+			return 1
+		}
+	}
+	printJSON(map[string]any{"imported": true, "sql_rows": len(a.SQLRows), "files": restore, "sha256": a.SHA256})
+	return 0
+}
+
+// sqlImportRows upserts rows, keeping whichever of the stored and imported
+// (last_tick, last_seq) is further ahead.
+func sqlImportRows(dbPath string, rows []stateRow) error {
+	if sealed, err := sqlSealed(); err != nil || sealed {
+		if err != nil {
+			return err
+		}
+		for _, r := range rows {
+			if err := sqlImportSealedRow(dbPath, r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var sb strings.Builder
+	sb.WriteString("CREATE TABLE IF NOT EXISTS wid_state (k TEXT PRIMARY KEY, last_tick INTEGER NOT NULL, last_seq INTEGER NOT NULL);BEGIN;")
+	for _, r := range rows {
+		fmt.Fprintf(&sb,
+			"INSERT INTO wid_state(k,last_tick,last_seq) VALUES('%s',%d,%d) ON CONFLICT(k) DO UPDATE SET last_tick=excluded.last_tick,last_seq=excluded.last_seq "+
+				"WHERE excluded.last_tick > wid_state.last_tick OR (excluded.last_tick = wid_state.last_tick AND excluded.last_seq > wid_state.last_seq);",
+			sqlEscapeSingle(r.K), r.LastTick, r.LastSeq)
+	}
+	sb.WriteString("COMMIT;")
+	_, err := sqliteExec(dbPath, sb.String())
+	return err
+}
+
+// sqlImportSealedRow is sqlImportRows for one row of sealed state, whose
+// counters can only be compared once opened.
+func sqlImportSealedRow(dbPath string, r stateRow) error {
+	if err := sqlEnsureState(dbPath, r.K); err != nil {
+		return err
+	}
+	for i := 0; i < 64; i++ {
+		tick, seq, err := sqlLoadState(dbPath, r.K)
+		if err != nil {
+			return err
+		}
+		if r.LastTick < tick || (r.LastTick == tick && r.LastSeq <= int64(seq)) {
+			return nil
+		}
+		ok, err := sqlCompareAndSwapState(dbPath, r.K, tick, seq, r.LastTick, int(r.LastSeq))
+		if err != nil || ok {
+			return err
+		}
+	}
+	return errors.New("sql import contention: retry budget exhausted")
+}
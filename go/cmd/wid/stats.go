@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	wid "github.com/waldiez/wid/go"
+)
+
+// cmdStats runs the IDs on stdin through a StreamAnalyzer, prints one record
+// per finding and a summary on stderr.
+func cmdStats(o opts) int {
+	a := wid.NewStreamAnalyzer(o.gap)
+	e := newEmitter(outputOr(o, "text"))
+	sc := bufio.NewScanner(os.Stdin)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		for _, f := range a.Observe(line) {
+			at := ""
+			if !f.At.IsZero() {
+				at = f.At.In(o.loc).Format(time.RFC3339)
+			}
+			e.emit(fmt.Sprintf("%-10s %s  %s", f.Kind, f.ID, f.Detail),
+				field{"kind", string(f.Kind)},
+				field{"id", f.ID},
+				field{"prev", f.Prev},
+				field{"at", at},
+				field{"detail", f.Detail},
+			)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		errln(err.Error())
+		return 1
+	}
+	n, bad := a.Counts()
+	fmt.Fprintf(os.Stderr, "observed=%d invalid=%d findings=%d\n", n, bad, len(a.Findings()))
+	return 0
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// daemonMode is set when running as the __daemon child of A=start; only the
+// daemon maintains the status file read by A=status.
+var daemonMode bool
+
+func runtimeStatus() string { return filepath.Join(runtimeDir(), "status.json") }
+
+// daemonStatus is the runtime snapshot the daemon rewrites after every tick
+// and, with STATUS_ADDR=, serves over HTTP for A=fleet-status.
+type daemonStatus struct {
+	mu          sync.Mutex
+	PID         int              `json:"pid"`
+	Action      string           `json:"action"`
+	Transport   string           `json:"transport"`
+	TransportOK bool             `json:"transport_ok"`
+	Failures    int64            `json:"transport_failures"`
+	StartedAt   string           `json:"started_at"`
+	UpdatedAt   string           `json:"updated_at"`
+	Emitted     int64            `json:"ids_emitted"`
+	LastID      string           `json:"last_id,omitempty"`
+	LastError   string           `json:"last_error,omitempty"`
+	LastErrorAt string           `json:"last_error_at,omitempty"`
+	State       string           `json:"state"`
+	Guardrails  map[string]int64 `json:"guardrails,omitempty"`
+}
+
+func newDaemonStatus(action, transport string) *daemonStatus {
+	now := time.Now().UTC()
+	return &daemonStatus{
+		PID: os.Getpid(), Action: action, Transport: transport, TransportOK: true,
+		StartedAt: now.Format(time.RFC3339Nano), State: "running",
+	}
+}
+
+// record notes one emitted ID and the outcome of publishing it.
+func (s *daemonStatus) record(id string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Emitted++
+	s.LastID = id
+	s.setTransportResult(err)
+}
+
+func (s *daemonStatus) transportResult(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setTransportResult(err)
+}
+
+func (s *daemonStatus) setTransportResult(err error) {
+	s.TransportOK = err == nil
+	if err != nil {
+		s.Failures++
+		s.LastError = err.Error()
+		s.LastErrorAt = time.Now().UTC().Format(time.RFC3339)
+	}
+}
+
+// save writes the snapshot atomically; failures are reported, not fatal.
+func (s *daemonStatus) save() {
+	if !daemonMode {
+		return
+	}
+	b := s.marshal()
+	_ = os.MkdirAll(runtimeDir(), 0o755)
+	tmp := runtimeStatus() + ".tmp"
+	err := os.WriteFile(tmp, b, 0o644)
+	if err == nil {
+		err = os.Rename(tmp, runtimeStatus())
+	}
+	if err != nil {
+		errln("status: " + err.Error())
+	}
+}
+
+func (s *daemonStatus) setState(state string) {
+	s.mu.Lock()
+	s.State = state
+	s.mu.Unlock()
+}
+
+// marshal encodes the snapshot, stamping updated_at.
+func (s *daemonStatus) marshal() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	s.Guardrails = guardrailSnapshot()
+	b, _ := json.Marshal(s)
+	return b
+}
+
+// readDaemonStatus returns the status file if it belongs to pid.
+func readDaemonStatus(pid int) (*daemonStatus, bool) {
+	b, err := os.ReadFile(runtimeStatus())
+	if err != nil {
+		return nil, false
+	}
+	var s daemonStatus
+	if json.Unmarshal(b, &s) != nil || s.PID != pid {
+		return nil, false
+	}
+	return &s, true
+}
+
+func runStatus(c canon) int {
+	format := c.output
+	if format == "" {
+		format = "text"
+	}
+	e := newEmitter(format)
+	pid, ok := runningDaemon()
+	if !ok {
+		e.emitTable("wid-go status=stopped", field{"impl", "wid-go"}, field{"status", "stopped"})
+		return 0
+	}
+	fields := []field{{"impl", "wid-go"}, {"status", "running"}, {"pid", pid}, {"log", runtimeLog()}}
+	text := fmt.Sprintf("wid-go status=running pid=%d log=%s", pid, runtimeLog())
+	if s, ok := readDaemonStatus(pid); ok {
+		uptime := ""
+		if t, err := time.Parse(time.RFC3339, s.StartedAt); err == nil {
+			uptime = time.Since(t).Truncate(time.Second).String()
+		}
+		fields = append(fields,
+			field{"action", s.Action},
+			field{"uptime", uptime},
+			field{"ids_emitted", s.Emitted},
+			field{"last_id", s.LastID},
+			field{"transport", s.Transport},
+			field{"transport_ok", s.TransportOK},
+			field{"transport_failures", s.Failures},
+			field{"last_error", s.LastError},
+			field{"guardrails", s.Guardrails},
+			field{"updated_at", s.UpdatedAt},
+		)
+		text += fmt.Sprintf(" action=%s uptime=%s ids_emitted=%d last_id=%s transport=%s transport_ok=%t",
+			s.Action, uptime, s.Emitted, valueOrHash(s.LastID), s.Transport, s.TransportOK)
+		if s.LastError != "" {
+			text += fmt.Sprintf(" last_error=%q", s.LastError)
+		}
+		kinds := make([]string, 0, len(s.Guardrails))
+		for k := range s.Guardrails {
+			kinds = append(kinds, k)
+		}
+		sort.Strings(kinds)
+		for _, k := range kinds {
+			text += fmt.Sprintf(" guardrail.%s=%d", k, s.Guardrails[k])
+		}
+	}
+	e.emitTable(text, fields...)
+	return 0
+}
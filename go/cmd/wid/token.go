@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	wid "github.com/waldiez/wid/go"
+)
+
+// runToken issues a self-certifying token "<wid>.<sig>" for WID= (or a fresh
+// ID) with the private KEY=.
+func runToken(c canon) int {
+	if strings.TrimSpace(c.key) == "" {
+		errln("KEY=<private_key_path> required for A=token")
+		return 1
+	}
+	priv, err := loadEd25519PrivateKey(c.key)
+	if err != nil {
+		errln(err.Error())
+		return 1
+	}
+	id := strings.TrimSpace(c.wid)
+	if id == "" {
+		g, err := newCanonGen(c)
+		if err != nil {
+			errln(err.Error())
+			return 1
+		}
+		id = g.Next()
+	}
+	tok, err := wid.SignToken(id, priv)
+	if err != nil {
+		errln(err.Error())
+		return 1
+	}
+	newEmitter(outputOr(opts{output: c.output}, "text")).emit(tok, field{"wid", id}, field{"token", tok})
+	return 0
+}
+
+// runTokenVerify checks TOKEN= against the public KEY= and the
+// MAX_AGE_SEC/MAX_FUTURE_SEC window, printing the WID it carries.
+func runTokenVerify(c canon) int {
+	if strings.TrimSpace(c.key) == "" {
+		errln("KEY=<public_key_path> required for A=token-verify")
+		return 1
+	}
+	if strings.TrimSpace(c.token) == "" {
+		errln("TOKEN=<token> required for A=token-verify")
+		return 1
+	}
+	if c.maxAgeSec < 0 || c.maxFutureSec < 0 {
+		errln("MAX_AGE_SEC and MAX_FUTURE_SEC must be non-negative integers")
+		return 1
+	}
+	ks := &wid.KeySet{}
+	if isKeySetPath(c.key) {
+		var err error
+		if ks, err = loadKeySet(c); err != nil {
+			errln(err.Error())
+			return 1
+		}
+	} else {
+		pub, err := loadEd25519PublicKey(c.key)
+		if err != nil {
+			errln(err.Error())
+			return 1
+		}
+		ks.Add("", pub)
+	}
+	id, err := wid.VerifyTokenKeySet(strings.TrimSpace(c.token), ks, wid.StrictOptions{
+		MaxAge:    time.Duration(c.maxAgeSec) * time.Second,
+		MaxFuture: time.Duration(c.maxFutureSec) * time.Second,
+	})
+	if err != nil {
+		errln("token invalid: " + err.Error())
+		return 1
+	}
+	newEmitter(outputOr(opts{output: c.output}, "text")).emit(id, field{"wid", id}, field{"valid", true})
+	return 0
+}
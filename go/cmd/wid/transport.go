@@ -0,0 +1,439 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// publisher delivers service-loop records to a transport.
+type publisher interface {
+	publish(rec map[string]any) error
+	close() error
+}
+
+func newPublisher(c canon, transport string) (publisher, error) {
+	var p publisher
+	switch transport {
+	case "null":
+		return nullPublisher{}, nil
+	case "webhook":
+		wp, err := newWebhookPublisher(c)
+		if err != nil {
+			return nil, err
+		}
+		p = wp
+	default:
+		// mqtt/ws/redis are emitted to stdout until broker clients land.
+		p = stdoutPublisher{}
+	}
+	if c.a == "saf" || c.a == "saf-wid" {
+		// Store-and-forward keeps undelivered records in its own queue.
+		sp, err := newSAFPublisher(p, c)
+		if err != nil {
+			return nil, err
+		}
+		p = sp
+	} else {
+		p = &dlqPublisher{inner: p, path: dlqPath(c), transport: transport}
+	}
+	if c.maxInflight > 0 {
+		return newBoundedPublisher(p, c)
+	}
+	return p, nil
+}
+
+type nullPublisher struct{}
+
+func (nullPublisher) publish(map[string]any) error { return nil }
+func (nullPublisher) close() error                 { return nil }
+
+type stdoutPublisher struct{}
+
+func (stdoutPublisher) publish(rec map[string]any) error {
+	printJSON(rec)
+	return nil
+}
+
+func (stdoutPublisher) close() error { return nil }
+
+// webhookPublisher POSTs records as JSON (a single object, or an array when
+// BATCH > 1) and retries failed deliveries with exponential backoff. When a
+// secret is configured every request carries
+// X-Wid-Signature: sha256=HEX(HMAC-SHA256(secret, timestamp "." body)).
+type webhookPublisher struct {
+	url     string
+	secret  []byte
+	batch   int
+	retries int
+	backoff time.Duration
+	client  *http.Client
+	buf     []map[string]any
+}
+
+func newWebhookPublisher(c canon) (*webhookPublisher, error) {
+	if !strings.HasPrefix(c.url, "http://") && !strings.HasPrefix(c.url, "https://") {
+		return nil, errors.New("URL=<http(s)://...> required for R=webhook")
+	}
+	if c.batch < 1 {
+		return nil, errors.New("BATCH must be an integer >= 1")
+	}
+	if c.retries < 0 || c.backoffMs < 0 {
+		return nil, errors.New("RETRIES/BACKOFF_MS must be >= 0")
+	}
+	p := &webhookPublisher{
+		url:     c.url,
+		batch:   c.batch,
+		retries: c.retries,
+		backoff: time.Duration(c.backoffMs) * time.Millisecond,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+	if strings.TrimSpace(c.webhookSecret) != "" {
+		secret, err := resolveWOtpSecret(c.webhookSecret)
+		if err != nil {
+			return nil, err
+		}
+		p.secret = []byte(secret)
+	}
+	return p, nil
+}
+
+func (p *webhookPublisher) publish(rec map[string]any) error {
+	p.buf = append(p.buf, rec)
+	if len(p.buf) < p.batch {
+		return nil
+	}
+	return p.flush()
+}
+
+// sync flushes a partially filled batch.
+func (p *webhookPublisher) sync() error {
+	if len(p.buf) == 0 {
+		return nil
+	}
+	return p.flush()
+}
+
+func (p *webhookPublisher) close() error {
+	if len(p.buf) == 0 {
+		return nil
+	}
+	return p.flush()
+}
+
+func (p *webhookPublisher) flush() error {
+	var body []byte
+	if p.batch == 1 {
+		body, _ = json.Marshal(p.buf[0])
+	} else {
+		body, _ = json.Marshal(p.buf)
+	}
+	recs := append([]map[string]any(nil), p.buf...)
+	p.buf = p.buf[:0]
+	var err error
+	for attempt := 0; attempt <= p.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(p.backoff << (attempt - 1))
+		}
+		if err = p.post(body); err == nil {
+			return nil
+		}
+	}
+	return &deliveryError{
+		records: recs,
+		err:     fmt.Errorf("webhook delivery failed after %d attempt(s): %w", p.retries+1, err),
+	}
+}
+
+func (p *webhookPublisher) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(p.secret) > 0 {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, p.secret)
+		_, _ = mac.Write([]byte(ts + "."))
+		_, _ = mac.Write(body)
+		req.Header.Set("X-Wid-Timestamp", ts)
+		req.Header.Set("X-Wid-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// boundedPublisher decouples generation from a slow transport: records are
+// queued up to MAX_INFLIGHT and published by a background worker. When the
+// queue is full the BACKPRESSURE policy decides whether publish blocks, drops
+// the oldest queued record, or spills the record to disk for later delivery.
+// Once a record is spilled, later ones are spilled too until the file has
+// been drained, so spilling never reorders records.
+type boundedPublisher struct {
+	inner        publisher
+	policy       string
+	limit        int
+	spillPath    string
+	spillAckPath string
+	spillMax     int64
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queue    []map[string]any
+	spilling bool
+	closing  bool
+	done     chan struct{}
+
+	enqueued  int64
+	published int64
+	dropped   int64
+	spilled   int64
+	failed    int64
+	maxDepth  int
+}
+
+func newBoundedPublisher(inner publisher, c canon) (*boundedPublisher, error) {
+	switch c.backpressure {
+	case "block", "drop-oldest", "spill":
+	default:
+		return nil, errors.New("BACKPRESSURE must be block, drop-oldest or spill")
+	}
+	p := &boundedPublisher{
+		inner:        inner,
+		policy:       c.backpressure,
+		limit:        c.maxInflight,
+		spillPath:    filepath.Join(dataDir(c), "spill.ndjson"),
+		spillAckPath: filepath.Join(dataDir(c), "spill.ack"),
+		spillMax:     c.maxQueueBytes,
+		done:         make(chan struct{}),
+	}
+	if fi, err := os.Stat(p.spillPath); err == nil && fi.Size() > 0 && p.policy == "spill" {
+		p.spilling = true // left over from an earlier run
+	}
+	p.cond = sync.NewCond(&p.mu)
+	go p.run()
+	return p, nil
+}
+
+func (p *boundedPublisher) publish(rec map[string]any) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.enqueued++
+	if p.spilling {
+		return p.spillLocked(rec)
+	}
+	for len(p.queue) >= p.limit {
+		switch p.policy {
+		case "drop-oldest":
+			p.queue = p.queue[1:]
+			p.dropped++
+		case "spill":
+			return p.spillLocked(rec)
+		default:
+			p.cond.Wait()
+		}
+	}
+	p.queue = append(p.queue, rec)
+	if len(p.queue) > p.maxDepth {
+		p.maxDepth = len(p.queue)
+	}
+	p.cond.Broadcast()
+	return nil
+}
+
+// spillLocked appends rec to the spill file; p.mu is held.
+func (p *boundedPublisher) spillLocked(rec map[string]any) error {
+	if b, _ := json.Marshal(rec); !queueHasRoom(p.spillPath, len(b)+1, p.spillMax) {
+		noteGuardrail("spill_full")
+		p.dropped++
+		return nil
+	}
+	if err := appendNDJSON(p.spillPath, rec); err != nil {
+		return err
+	}
+	p.spilled++
+	p.spilling = true
+	p.cond.Broadcast()
+	return nil
+}
+
+// run delivers the queue, then the spill file, whose records are all newer
+// than the queued ones.
+func (p *boundedPublisher) run() {
+	defer close(p.done)
+	for {
+		p.mu.Lock()
+		for len(p.queue) == 0 && !p.spilling && !p.closing {
+			p.cond.Wait()
+		}
+		if len(p.queue) == 0 {
+			closing := p.closing
+			p.mu.Unlock()
+			p.drainSpill()
+			if closing {
+				return
+			}
+			continue
+		}
+		rec := p.queue[0]
+		p.queue = p.queue[1:]
+		p.cond.Broadcast()
+		p.mu.Unlock()
+		p.deliver(rec)
+	}
+}
+
+func (p *boundedPublisher) deliver(rec map[string]any) {
+	if err := p.inner.publish(rec); err != nil {
+		errln(err.Error())
+		p.mu.Lock()
+		p.failed++
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Lock()
+	p.published++
+	p.mu.Unlock()
+}
+
+// spillCompactEvery is how many delivered records drainSpill lets pile up
+// at the head of a still-growing spill file before rewriting it.
+const spillCompactEvery = 1024
+
+// drainSpill re-publishes spilled records, oldest first, once the in-memory
+// queue has emptied. As in store-and-forward, delivered records are counted
+// in spill.ack instead of being cut from the file one by one, and a record
+// counts only after its delivery, so a crash redelivers it rather than
+// losing it. Records publish spills meanwhile are drained too before new
+// ones are queued again; the file is removed once fully delivered, and
+// compacted every spillCompactEvery records while it keeps growing.
+func (p *boundedPublisher) drainSpill() {
+	if p.policy != "spill" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	recs, err := readNDJSON(p.spillPath)
+	acked := min(readOffset(p.spillAckPath), len(recs))
+	for err == nil && acked < len(recs) {
+		p.mu.Unlock()
+		p.deliver(recs[acked])
+		p.mu.Lock()
+		acked++
+		if err = writeOffset(p.spillAckPath, acked); err != nil {
+			break
+		}
+		if acked < len(recs) && acked < spillCompactEvery {
+			continue
+		}
+		// Caught up with what was read, or due to compact: pick up what
+		// publish appended meanwhile.
+		if recs, err = readNDJSON(p.spillPath); err != nil || acked < spillCompactEvery || acked == len(recs) {
+			continue
+		}
+		// Reset the offset first: a crash in between then redelivers the
+		// delivered records instead of skipping undelivered ones.
+		if err = writeOffset(p.spillAckPath, 0); err == nil {
+			recs, acked = recs[acked:], 0
+			err = rewriteNDJSON(p.spillPath, recs)
+		}
+	}
+	if err == nil || os.IsNotExist(err) {
+		err = os.Remove(p.spillPath)
+		if err == nil || os.IsNotExist(err) {
+			err = writeOffset(p.spillAckPath, 0)
+		}
+	}
+	if err != nil {
+		errln("spill: " + err.Error())
+	}
+	p.spilling = false
+}
+
+func (p *boundedPublisher) close() error {
+	p.mu.Lock()
+	p.closing = true
+	p.cond.Broadcast()
+	p.mu.Unlock()
+	<-p.done
+	b, _ := json.Marshal(map[string]any{"metric": "transport", "stats": p.stats()})
+	fmt.Fprintln(os.Stderr, string(b))
+	return p.inner.close()
+}
+
+func (p *boundedPublisher) stats() map[string]any {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return map[string]any{
+		"policy":       p.policy,
+		"max_inflight": p.limit,
+		"queued":       len(p.queue),
+		"max_depth":    p.maxDepth,
+		"enqueued":     p.enqueued,
+		"published":    p.published,
+		"dropped":      p.dropped,
+		"spilled":      p.spilled,
+		"failed":       p.failed,
+	}
+}
+
+func appendNDJSON(path string, rec map[string]any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, _ := json.Marshal(rec)
+	b, err := sealRecord(b)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
+
+func readNDJSON(path string) ([]map[string]any, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var out []map[string]any
+	for _, line := range strings.Split(string(b), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		plain, err := openRecord([]byte(line))
+		if err != nil {
+			return nil, err
+		}
+		var rec map[string]any
+		if err := json.Unmarshal(plain, &rec); err != nil {
+			continue
+		}
+		out = append(out, rec)
+	}
+	return out, nil
+}
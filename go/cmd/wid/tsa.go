@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// RFC 3161 trusted timestamps. With TSA=<url>, A=sign sends the SHA-256 of the
+// signature to a Time-Stamping Authority and emits "<sig>.<token>", where the
+// token is the TSA's DER TimeStampToken in base64url. A=verify checks such a
+// bundle: the token must cover exactly this signature and be signed by a TSA
+// certificate that chains to TSA_CA= (or the system roots) with the
+// timeStamping usage. The token's genTime proves the signature existed then,
+// independently of the WID's own timestamp.
+
+var (
+	oidSHA256        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+)
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	Nonce          *big.Int
+	CertReq        bool `asn1:"optional"`
+}
+
+type pkiStatusInfo struct {
+	Status       int
+	StatusString asn1.RawValue  `asn1:"optional,tag:16"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type encapContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type tstAccuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time   `asn1:"generalized"`
+	Accuracy       tstAccuracy `asn1:"optional"`
+	Ordering       bool        `asn1:"optional"`
+	Nonce          *big.Int    `asn1:"optional"`
+}
+
+type cmsAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+// requestTimestamp obtains a TimeStampToken over SHA-256(sig) from the TSA.
+func requestTimestamp(url string, sig []byte) ([]byte, error) {
+	digest := crypto.SHA256.New()
+	digest.Write(sig)
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, err
+	}
+	req, err := asn1.Marshal(timeStampReq{
+		Version:        1,
+		MessageImprint: messageImprint{HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256}, HashedMessage: digest.Sum(nil)},
+		Nonce:          nonce,
+		CertReq:        true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Post(url, "application/timestamp-query", bytes.NewReader(req))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("TSA returned HTTP %d", resp.StatusCode)
+	}
+	var tsr timeStampResp
+	if _, err := asn1.Unmarshal(body, &tsr); err != nil {
+		return nil, fmt.Errorf("malformed TSA response: %v", err)
+	}
+	// 0 = granted, 1 = grantedWithMods.
+	if tsr.Status.Status > 1 || len(tsr.TimeStampToken.FullBytes) == 0 {
+		return nil, fmt.Errorf("TSA rejected the request (status %d)", tsr.Status.Status)
+	}
+	info, _, err := parseTimestampToken(tsr.TimeStampToken.FullBytes)
+	if err != nil {
+		return nil, err
+	}
+	if info.Nonce == nil || info.Nonce.Cmp(nonce) != 0 {
+		return nil, errors.New("TSA response nonce mismatch")
+	}
+	return tsr.TimeStampToken.FullBytes, nil
+}
+
+// parseTimestampToken decodes a TimeStampToken and checks its CMS signature,
+// returning the TSTInfo and the certificate that signed it.
+func parseTimestampToken(der []byte) (*tstInfo, *x509.Certificate, error) {
+	malformed := func(what string, err error) error {
+		return fmt.Errorf("malformed timestamp token (%s): %v", what, err)
+	}
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, nil, malformed("content info", err)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, nil, errors.New("timestamp token is not CMS SignedData")
+	}
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, nil, malformed("signed data", err)
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) || len(sd.SignerInfos) != 1 {
+		return nil, nil, errors.New("timestamp token does not carry a single-signer TSTInfo")
+	}
+	var info tstInfo
+	if _, err := asn1.Unmarshal(sd.EncapContentInfo.EContent, &info); err != nil {
+		return nil, nil, malformed("tst info", err)
+	}
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil || len(certs) == 0 {
+		return nil, nil, errors.New("timestamp token carries no TSA certificate (request it with certReq)")
+	}
+
+	si := sd.SignerInfos[0]
+	hash, ok := hashForOID(si.DigestAlgorithm.Algorithm)
+	if !ok {
+		return nil, nil, errors.New("unsupported timestamp digest algorithm")
+	}
+	if len(si.SignedAttrs.FullBytes) == 0 {
+		return nil, nil, errors.New("timestamp token has no signed attributes")
+	}
+	var attrs []cmsAttribute
+	if _, err := asn1.UnmarshalWithParams(si.SignedAttrs.FullBytes, &attrs, "tag:0"); err != nil {
+		return nil, nil, malformed("signed attributes", err)
+	}
+	h := hash.New()
+	h.Write(sd.EncapContentInfo.EContent)
+	digestOK := false
+	for _, a := range attrs {
+		if a.Type.Equal(oidMessageDigest) {
+			var md []byte
+			if _, err := asn1.Unmarshal(a.Values.Bytes, &md); err == nil && bytes.Equal(md, h.Sum(nil)) {
+				digestOK = true
+			}
+		}
+	}
+	if !digestOK {
+		return nil, nil, errors.New("timestamp token content digest mismatch")
+	}
+	// The signature covers the DER of the attributes as a SET OF, not [0].
+	signed := append([]byte{0x31}, si.SignedAttrs.FullBytes[1:]...)
+	for _, cert := range certs {
+		if algo, ok := signatureAlgorithmFor(cert, hash); ok && cert.CheckSignature(algo, signed, si.Signature) == nil {
+			return &info, cert, nil
+		}
+	}
+	return nil, nil, errors.New("timestamp token signature invalid")
+}
+
+func hashForOID(oid asn1.ObjectIdentifier) (crypto.Hash, bool) {
+	switch {
+	case oid.Equal(oidSHA256):
+		return crypto.SHA256, true
+	case oid.Equal(oidSHA384):
+		return crypto.SHA384, true
+	case oid.Equal(oidSHA512):
+		return crypto.SHA512, true
+	}
+	return 0, false
+}
+
+func signatureAlgorithmFor(cert *x509.Certificate, hash crypto.Hash) (x509.SignatureAlgorithm, bool) {
+	table := map[x509.PublicKeyAlgorithm]map[crypto.Hash]x509.SignatureAlgorithm{
+		x509.RSA:   {crypto.SHA256: x509.SHA256WithRSA, crypto.SHA384: x509.SHA384WithRSA, crypto.SHA512: x509.SHA512WithRSA},
+		x509.ECDSA: {crypto.SHA256: x509.ECDSAWithSHA256, crypto.SHA384: x509.ECDSAWithSHA384, crypto.SHA512: x509.ECDSAWithSHA512},
+	}
+	if cert.PublicKeyAlgorithm == x509.Ed25519 {
+		return x509.PureEd25519, true
+	}
+	algo, ok := table[cert.PublicKeyAlgorithm][hash]
+	return algo, ok
+}
+
+// verifyTimestamp checks that token timestamps exactly sig and that its TSA
+// certificate is trusted, returning the attested time and TSA identity.
+func verifyTimestamp(c canon, sig, token []byte) (time.Time, string, error) {
+	info, cert, err := parseTimestampToken(token)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	hash, ok := hashForOID(info.MessageImprint.HashAlgorithm.Algorithm)
+	if !ok {
+		return time.Time{}, "", errors.New("unsupported timestamp imprint algorithm")
+	}
+	h := hash.New()
+	h.Write(sig)
+	if !bytes.Equal(h.Sum(nil), info.MessageImprint.HashedMessage) {
+		return time.Time{}, "", errors.New("timestamp token does not cover this signature")
+	}
+	vo := x509.VerifyOptions{
+		CurrentTime: info.GenTime,
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}
+	if strings.TrimSpace(c.tsaCA) != "" {
+		b, err := os.ReadFile(c.tsaCA)
+		if err != nil {
+			return time.Time{}, "", err
+		}
+		vo.Roots = x509.NewCertPool()
+		if !vo.Roots.AppendCertsFromPEM(b) {
+			return time.Time{}, "", fmt.Errorf("no certificates found in TSA_CA bundle: %s", c.tsaCA)
+		}
+	}
+	if _, err := cert.Verify(vo); err != nil {
+		return time.Time{}, "", fmt.Errorf("TSA certificate not trusted: %v", err)
+	}
+	return info.GenTime, cert.Subject.String(), nil
+}
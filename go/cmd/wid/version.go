@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build metadata, overridable with
+// -ldflags "-X main.version=... -X main.commit=... -X main.buildDate=...".
+var (
+	version   = "1.0.0"
+	commit    = ""
+	buildDate = ""
+)
+
+var transportNames = []string{"auto", "mqtt", "ws", "redis", "null", "stdout", "webhook"}
+
+// buildInfo fills commit/build date from the embedded VCS stamp when the
+// binary was built without -ldflags.
+func buildInfo() (string, string) {
+	rev, date := commit, buildDate
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if rev == "" {
+					rev = s.Value
+				}
+			case "vcs.time":
+				if date == "" {
+					date = s.Value
+				}
+			}
+		}
+	}
+	if rev == "" {
+		rev = "unknown"
+	}
+	if date == "" {
+		date = "unknown"
+	}
+	return rev, date
+}
+
+func stateBackends() []string {
+	backends := []string{"state", "stateless"}
+	if _, err := exec.LookPath("sqlite3"); err == nil {
+		backends = append(backends, "sql")
+	}
+	return backends
+}
+
+func cmdVersion(o opts) int {
+	rev, date := buildInfo()
+	text := fmt.Sprintf("wid-go %s (commit %s, built %s, %s)", version, rev, date, runtime.Version())
+	if o.output == "" || o.output == "text" {
+		fmt.Println(text)
+		return 0
+	}
+	newEmitter(o.output).emit(text,
+		field{"impl", "go"},
+		field{"version", version},
+		field{"commit", rev},
+		field{"build_date", date},
+		field{"go_version", runtime.Version()},
+		field{"time_units", []string{"sec", "ms"}},
+		field{"transports", transportNames},
+		field{"state_backends", stateBackends()},
+		field{"signature_algorithms", []string{"Ed25519"}},
+	)
+	return 0
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	wid "github.com/waldiez/wid/go"
+)
+
+// watchPoll is how often a followed file is checked for new lines, and a
+// quiet stream for a stall.
+const watchPoll = 250 * time.Millisecond
+
+// findingStall is the live-only finding for a stream that has gone quiet.
+const findingStall wid.FindingKind = "stall"
+
+// cmdWatch is the live counterpart of stats: it follows path (new lines
+// only, reopening it when it is rotated or truncated) or reads stdin, and
+// reports each StreamAnalyzer finding as it happens. Unlike stats it also
+// notices a stream that goes quiet: with --gap, a silence longer than the
+// gap is reported as a "stall" while it lasts, not only once the next ID
+// arrives. With --every a summary line goes to stderr at that interval.
+// It runs until stdin ends or it is interrupted.
+func cmdWatch(path string, o opts) int {
+	a := wid.NewStreamAnalyzer(o.gap)
+	e := newEmitter(outputOr(o, "text"))
+	report := func(f wid.Finding) {
+		at := ""
+		if !f.At.IsZero() {
+			at = f.At.In(o.loc).Format(time.RFC3339)
+		}
+		e.emit(fmt.Sprintf("%-10s %s  %s", f.Kind, f.ID, f.Detail),
+			field{"kind", string(f.Kind)},
+			field{"id", f.ID},
+			field{"prev", f.Prev},
+			field{"at", at},
+			field{"detail", f.Detail},
+		)
+	}
+
+	lines, errc := make(chan string), make(chan error, 1)
+	if path == "" || path == "-" {
+		go scanLines(os.Stdin, lines, errc)
+	} else {
+		go followLines(path, lines, errc)
+	}
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGTERM, os.Interrupt)
+	defer signal.Stop(sigc)
+	var tick <-chan time.Time
+	if o.every > 0 {
+		t := time.NewTicker(o.every)
+		defer t.Stop()
+		tick = t.C
+	}
+	var poll <-chan time.Time
+	if o.gap > 0 {
+		t := time.NewTicker(watchPoll)
+		defer t.Stop()
+		poll = t.C
+	}
+
+	start, last, stalled := time.Now(), "", false
+	seen := start
+	summary := func() {
+		n, bad := a.Counts()
+		rate := float64(n) / time.Since(start).Seconds()
+		fmt.Fprintf(os.Stderr, "observed=%d invalid=%d findings=%d rate=%.1f/s\n", n, bad, len(a.Findings()), rate)
+	}
+	status := 0
+loop:
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				break loop
+			}
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			for _, f := range a.Observe(line) {
+				report(f)
+			}
+			last, seen, stalled = line, time.Now(), false
+		case now := <-poll:
+			if !stalled && now.Sub(seen) > o.gap {
+				stalled = true
+				report(wid.Finding{Kind: findingStall, Prev: last, At: now,
+					Detail: fmt.Sprintf("no IDs for %s", o.gap)})
+			}
+		case <-tick:
+			summary()
+		case err := <-errc:
+			errln(err.Error())
+			status = 1
+			break loop
+		case <-sigc:
+			break loop
+		}
+	}
+	summary()
+	return status
+}
+
+// scanLines sends r's lines on out and closes it at EOF.
+func scanLines(r io.Reader, out chan<- string, errc chan<- error) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		out <- sc.Text()
+	}
+	if err := sc.Err(); err != nil {
+		errc <- err
+		return
+	}
+	close(out)
+}
+
+// followLines sends the lines appended to path from now on, like tail -F.
+// A partial last line is held back until its newline arrives.
+func followLines(path string, out chan<- string, errc chan<- error) {
+	f, err := os.Open(path)
+	if err != nil {
+		errc <- err
+		return
+	}
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		errc <- err
+		return
+	}
+	var partial string
+	buf := make([]byte, 64<<10)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			offset += int64(n)
+			chunk := partial + string(buf[:n])
+			for {
+				i := strings.IndexByte(chunk, '\n')
+				if i < 0 {
+					break
+				}
+				out <- chunk[:i]
+				chunk = chunk[i+1:]
+			}
+			partial = chunk
+			continue
+		}
+		if err != nil && err != io.EOF {
+			errc <- err
+			return
+		}
+		time.Sleep(watchPoll)
+		// Reopen from the start when the file was replaced or truncated.
+		cur, serr := f.Stat()
+		fi, perr := os.Stat(path)
+		if serr != nil || perr != nil {
+			continue
+		}
+		if !os.SameFile(cur, fi) || fi.Size() < offset {
+			if nf, err := os.Open(path); err == nil {
+				f.Close()
+				f, offset, partial = nf, 0, ""
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// defaultWOtpSecretPath is where A=w-otp MODE=secret writes without OUT=.
+const defaultWOtpSecretPath = "wid_wotp.secret"
+
+var wotpBase32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// wotpSecretBytes is the generated secret's entropy: 256 bits, the HMAC-SHA256
+// block-size-safe length RFC 2104 recommends at minimum.
+const wotpSecretBytes = 32
+
+// runWOtpSecret is A=w-otp MODE=secret: it writes a random key, base32
+// without padding as otpauth URIs carry it, to OUT= with mode 0600 and never
+// over an existing file. The file is then the KEY= of gen and verify; with
+// KEY_FORMAT=base32 they HMAC with the decoded key bytes, as an otpauth
+// consumer does with secret=, and without it with the base32 text, as every
+// other implementation does. With
+// URI=true it also prints an otpauth://wotp/ provisioning URI for NODE=,
+// whose parameters repeat DIGITS=, STEP= and WINDOW_SEC=; pipe it to a QR
+// encoder (qrencode -t ansiutf8) to hand it to a device.
+func runWOtpSecret(c canon) int {
+	path := strings.TrimSpace(c.out)
+	if path == "" {
+		path = defaultWOtpSecretPath
+	}
+	raw := make([]byte, wotpSecretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		errln(err.Error())
+		return 1
+	}
+	secret := wotpBase32.EncodeToString(raw)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		errln(err.Error())
+		return 1
+	}
+	if _, err := f.WriteString(secret + "\n"); err != nil {
+		f.Close()
+		os.Remove(path)
+		errln(err.Error())
+		return 1
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		errln(err.Error())
+		return 1
+	}
+	text := "secret_file=" + path + "\nkey_format=base32"
+	fields := []field{{"secret_file", path}, {"bits", wotpSecretBytes * 8}, {"key_format", "base32"}}
+	if c.uri {
+		u := wotpProvisioningURI(c, secret)
+		text += "\nuri=" + u
+		fields = append(fields, field{"node", c.node}, field{"uri", u})
+	}
+	newEmitter(outputOr(opts{output: c.output}, "text")).emitTable(text, fields...)
+	return 0
+}
+
+// resolveWOtpKey resolves KEY= for A=w-otp gen and verify. The secret is
+// used as text unless KEY_FORMAT=base32, a Go-only extension (see
+// CRYPTO_SPEC.md), which decodes it to the key bytes an otpauth URI's
+// secret= stands for.
+func resolveWOtpKey(c canon) (string, error) {
+	secret, err := resolveWOtpSecret(c.key)
+	if err != nil || c.keyFormat != "base32" {
+		return secret, err
+	}
+	key, err := wotpBase32.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil || len(key) == 0 {
+		return "", errors.New("KEY_FORMAT=base32: KEY is not valid base32")
+	}
+	return string(key), nil
+}
+
+// wotpProvisioningURI renders otpauth://wotp/<issuer>:<node>?... in the
+// Key Uri Format layout. The "wotp" type keeps TOTP-only authenticator apps
+// from accepting a secret whose codes they would compute differently.
+func wotpProvisioningURI(c canon, secret string) string {
+	issuer := strings.TrimSpace(c.issuer)
+	if issuer == "" {
+		issuer = "wid"
+	}
+	digits := c.digits
+	if digits == 0 {
+		digits = 6
+	}
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA256")
+	q.Set("digits", fmt.Sprint(digits))
+	if c.stepSec > 0 {
+		q.Set("period", fmt.Sprint(c.stepSec))
+	}
+	if c.windowSec > 0 {
+		q.Set("window", fmt.Sprint(c.windowSec))
+	}
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(c.node)
+	return "otpauth://wotp/" + label + "?" + q.Encode()
+}
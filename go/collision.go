@@ -0,0 +1,50 @@
+package wid
+
+import "math"
+
+// Collision math. Within one generator the sequence already makes every ID
+// unique; collisions come from independent generators (processes, hosts)
+// that issue the same timestamp and sequence, and only the Z hex digits of
+// padding tell those apart. Taking the worst case, where all idsPerTick IDs
+// of one tick share a sequence number, the birthday bound over the 16^Z
+// paddings gives
+//
+//	p(n, Z) = 1 - exp(-n(n-1) / (2 * 16^Z))
+//
+// for n = idsPerTick. The tick is what differs between the units: at a total
+// rate of r IDs per second, n is r with TimeUnitSec and r/1000 with
+// TimeUnitMs, so millisecond IDs need fewer padding digits for the same rate
+// (see IDsPerTick). Each tick is an independent trial, so the chance of any
+// collision over T ticks is about 1 - (1-p)^T.
+
+// CollisionProbability is the probability that at least two of idsPerTick
+// IDs issued by independent generators in the same tick collide, given Z
+// padding digits.
+func CollisionProbability(z int, idsPerTick int) float64 {
+	if idsPerTick < 2 {
+		return 0
+	}
+	n := float64(idsPerTick)
+	space := math.Pow(16, float64(z))
+	return -math.Expm1(-n * (n - 1) / (2 * space))
+}
+
+// RecommendZ returns the smallest Z whose CollisionProbability for
+// idsPerTick is at most targetProb, or MaxZ when none is.
+func RecommendZ(idsPerTick int, targetProb float64) int {
+	for z := 0; z < MaxZ; z++ {
+		if CollisionProbability(z, idsPerTick) <= targetProb {
+			return z
+		}
+	}
+	return MaxZ
+}
+
+// IDsPerTick converts a total rate in IDs per second to the idsPerTick the
+// estimators expect for unit, rounding up.
+func IDsPerTick(perSecond float64, unit TimeUnit) int {
+	if unit == TimeUnitMs {
+		perSecond /= 1000
+	}
+	return int(math.Ceil(perSecond))
+}
@@ -0,0 +1,32 @@
+package wid
+
+import (
+	"math"
+	"testing"
+)
+
+// TestCollisionEstimates checks the birthday bound against hand-computed
+// values and that RecommendZ picks the smallest sufficient Z per unit.
+func TestCollisionEstimates(t *testing.T) {
+	if p := CollisionProbability(6, 1); p != 0 {
+		t.Fatalf("one ID per tick cannot collide, got %g", p)
+	}
+	// 2 IDs over 16 paddings: 1 - exp(-1/16)
+	if p := CollisionProbability(1, 2); math.Abs(p-0.0605869) > 1e-6 {
+		t.Fatalf("p(2, Z=1) = %g", p)
+	}
+	if p := CollisionProbability(0, 2); math.Abs(p-(1-math.Exp(-1))) > 1e-12 {
+		t.Fatalf("p(2, Z=0) = %g", p)
+	}
+	sec := RecommendZ(IDsPerTick(100_000, TimeUnitSec), 1e-9)
+	ms := RecommendZ(IDsPerTick(100_000, TimeUnitMs), 1e-9)
+	if sec != 16 || ms != 11 {
+		t.Fatalf("RecommendZ sec=%d ms=%d, want 16 and 11", sec, ms)
+	}
+	if CollisionProbability(sec, 100_000) > 1e-9 || CollisionProbability(sec-1, 100_000) <= 1e-9 {
+		t.Fatalf("Z=%d is not the smallest sufficient Z", sec)
+	}
+	if z := RecommendZ(1<<40, 0); z != MaxZ {
+		t.Fatalf("unreachable target = %d, want MaxZ", z)
+	}
+}
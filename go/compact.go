@@ -0,0 +1,73 @@
+package wid
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ToCompact renders a WID as 26 characters of Crockford Base32 over its
+// EncodeBinary bytes: URL- and filename-safe, and, as the alphabet is in
+// ASCII order, sorting like the binary form. It has the same limits as
+// EncodeBinary.
+func ToCompact(id string) (string, error) {
+	b, err := EncodeBinary(id)
+	if err != nil {
+		return "", err
+	}
+	return crockfordEncode(b), nil
+}
+
+// FromCompact restores the WID from ToCompact's form. As Crockford Base32
+// allows, input is case-insensitive and reads O as 0 and I and L as 1.
+func FromCompact(s string) (string, error) {
+	b, err := crockfordDecode(s)
+	if err != nil {
+		return "", err
+	}
+	return DecodeBinary(b)
+}
+
+func crockfordEncode(b [16]byte) string {
+	// 128 bits become 26 digits; the two leading pad bits are zero so the
+	// text sorts like the bytes.
+	hi, lo := binary.BigEndian.Uint64(b[0:8]), binary.BigEndian.Uint64(b[8:16])
+	out := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out)
+}
+
+func crockfordDecode(s string) ([16]byte, error) {
+	var b [16]byte
+	if len(s) != 26 {
+		return b, fmt.Errorf("%w: Base32 form must be 26 characters", ErrInvalidFormat)
+	}
+	var hi, lo uint64
+	for i := 0; i < len(s); i++ {
+		c := strings.ToUpper(s[i : i+1])
+		switch c {
+		case "O":
+			c = "0"
+		case "I", "L":
+			c = "1"
+		}
+		v := strings.Index(crockford, c)
+		if v < 0 {
+			return b, fmt.Errorf("%w: invalid base32 character %q", ErrInvalidFormat, s[i])
+		}
+		if i == 0 && v > 7 {
+			return b, fmt.Errorf("%w: base32 value overflows 128 bits", ErrInvalidFormat)
+		}
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(v)
+	}
+	binary.BigEndian.PutUint64(b[0:8], hi)
+	binary.BigEndian.PutUint64(b[8:16], lo)
+	return b, nil
+}
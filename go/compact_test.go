@@ -0,0 +1,42 @@
+package wid
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestCompactRoundTripAndOrder checks ToCompact/FromCompact round-trip, keep
+// string order and accept Crockford's lenient spellings.
+func TestCompactRoundTripAndOrder(t *testing.T) {
+	g, _ := NewWidGenWithUnit(4, 6, TimeUnitMs)
+	ids := g.NextN(300)
+	cs := make([]string, len(ids))
+	for i, id := range ids {
+		c, err := ToCompact(id)
+		if err != nil || len(c) != 26 {
+			t.Fatalf("ToCompact(%s) = %q, %v", id, c, err)
+		}
+		if back, err := FromCompact(strings.ToLower(c)); back != id || err != nil {
+			t.Fatalf("FromCompact(%s) = %q, %v", c, back, err)
+		}
+		cs[i] = c
+	}
+	sort.Strings(ids)
+	sort.Strings(cs)
+	for i := range ids {
+		if back, _ := FromCompact(cs[i]); back != ids[i] {
+			t.Fatalf("compact order differs from ID order at %d", i)
+		}
+	}
+	c, _ := ToCompact("20261016T120000.0001Z-abcdef")
+	if back, err := FromCompact(strings.ReplaceAll(c, "0", "o")); err != nil || back != "20261016T120000.0001Z-abcdef" {
+		t.Fatalf("O for 0 = %q, %v", back, err)
+	}
+	for _, bad := range []string{"short", strings.Repeat("U", 26), "8" + strings.Repeat("0", 25)} {
+		if _, err := FromCompact(bad); !errors.Is(err, ErrInvalidFormat) {
+			t.Errorf("FromCompact(%q) = %v", bad, err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package wid
+
+import (
+	"cmp"
+	"strings"
+)
+
+// Compare orders two WIDs by timestamp, then sequence, returning -1, 0 or +1.
+// Unlike string comparison it is not thrown off by differing W or padding:
+// w and z describe the expected shape, and an ID with another W or Z in the
+// same time unit is still accepted through DetectParams, so streams produced
+// with different settings can be merged. Padding never affects the order.
+func Compare(a, b string, w, z int, unit TimeUnit) (int, error) {
+	pa, err := parseWidFor(a, w, z, unit)
+	if err != nil {
+		return 0, err
+	}
+	pb, err := parseWidFor(b, w, z, unit)
+	if err != nil {
+		return 0, err
+	}
+	if c := pa.Timestamp.Compare(pb.Timestamp); c != 0 {
+		return c, nil
+	}
+	return cmp.Compare(pa.Sequence, pb.Sequence), nil
+}
+
+// CompareHlc orders two HLC-WIDs by timestamp, then logical counter, then
+// node, with the same tolerance for differing W and Z as Compare.
+func CompareHlc(a, b string, w, z int, unit TimeUnit) (int, error) {
+	pa, err := parseHlcFor(a, w, z, unit)
+	if err != nil {
+		return 0, err
+	}
+	pb, err := parseHlcFor(b, w, z, unit)
+	if err != nil {
+		return 0, err
+	}
+	if c := pa.Timestamp.Compare(pb.Timestamp); c != 0 {
+		return c, nil
+	}
+	if c := cmp.Compare(pa.LogicalCounter, pb.LogicalCounter); c != 0 {
+		return c, nil
+	}
+	return strings.Compare(pa.Node, pb.Node), nil
+}
+
+func parseWidFor(id string, w, z int, unit TimeUnit) (*ParsedWid, error) {
+	p, err := ParseWidFast(id, w, z, unit)
+	if err == nil {
+		return p, nil
+	}
+	d, derr := DetectParams(id)
+	if derr != nil || d.Kind != "wid" || d.TimeUnit != unit {
+		return nil, err
+	}
+	return ParseWidFast(id, d.W, d.Z, unit)
+}
+
+func parseHlcFor(id string, w, z int, unit TimeUnit) (*ParsedHlcWid, error) {
+	p, err := ParseHlcWidFast(id, w, z, unit)
+	if err == nil {
+		return p, nil
+	}
+	d, derr := DetectParams(id)
+	if derr != nil || d.TimeUnit != unit {
+		return nil, err
+	}
+	// A lone hex segment is detected as WID padding but may be the node.
+	dz := d.Z
+	if d.Kind != "hlc" {
+		dz = 0
+	}
+	if p, perr := ParseHlcWidFast(id, d.W, dz, unit); perr == nil {
+		return p, nil
+	}
+	return nil, err
+}
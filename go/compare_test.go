@@ -0,0 +1,51 @@
+package wid
+
+import (
+	"sort"
+	"testing"
+)
+
+// TestCompareMergesMixedStreams sorts IDs from generators with different W
+// and Z and checks the order follows time and sequence, where plain string
+// sorting does not.
+func TestCompareMergesMixedStreams(t *testing.T) {
+	ids := []string{
+		"20261016T120001.0000Z-abcdef",
+		"20261016T120000.10Z",
+		"20261016T120000.0002Z-abcdef",
+		"20261016T120000.0002Z",
+		"20261016T120000.09Z-12",
+	}
+	want := []string{ids[2], ids[3], ids[4], ids[1], ids[0]}
+	var cerr error
+	sort.SliceStable(ids, func(i, j int) bool {
+		c, err := Compare(ids[i], ids[j], 4, 6, TimeUnitSec)
+		if err != nil {
+			cerr = err
+		}
+		return c < 0
+	})
+	if cerr != nil {
+		t.Fatal(cerr)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("sorted %v, want %v", ids, want)
+		}
+	}
+	if _, err := Compare("20261016T120000.0002Z", "20261016T120000123.0002Z", 4, 0, TimeUnitSec); err == nil {
+		t.Fatal("an ID in another time unit must be rejected")
+	}
+	for _, c := range []struct {
+		a, b string
+		want int
+	}{
+		{"20261016T120000.0009Z-n1", "20261016T120000.00010Z-n1", -1},
+		{"20261016T120000.0001Z-b", "20261016T120000.0001Z-a-abcdef", 1},
+		{"20261016T120000.0001Z-n1-abcdef", "20261016T120000.0001Z-n1", 0},
+	} {
+		if got, err := CompareHlc(c.a, c.b, 4, 6, TimeUnitSec); got != c.want || err != nil {
+			t.Errorf("CompareHlc(%s, %s) = %d, %v; want %d", c.a, c.b, got, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package wid
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// NextForContent returns a WID whose padding is derived from a content digest
+// (for example a SHA-256 of the payload) instead of random bytes. Within one
+// tick the same digest always yields the same ID, so re-ingesting a payload is
+// idempotent; in a later tick it gets a fresh sequence. Padding digits beyond
+// the digest length are filled by re-hashing the digest.
+func (g *WidGen) NextForContent(hash []byte) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	key := string(hash)
+	// One clock reading serves both the lookup and the issue, so a virtual
+	// clock advances once per call.
+	now := g.now()
+	tick := max(now, g.lastTick)
+	if tick == g.contentTick && g.contentIDs != nil {
+		if id, ok := g.contentIDs[key]; ok {
+			return id
+		}
+	}
+	id := g.nextAtLocked(now, contentPadding(hash, g.Z))
+	if g.lastTick != g.contentTick || g.contentIDs == nil {
+		g.contentTick = g.lastTick
+		g.contentIDs = map[string]string{}
+	}
+	g.contentIDs[key] = id
+	return id
+}
+
+func contentPadding(hash []byte, z int) string {
+	h := hex.EncodeToString(hash)
+	for len(h) < z {
+		sum := sha256.Sum256([]byte(h))
+		h += hex.EncodeToString(sum[:])
+	}
+	return h[:z]
+}
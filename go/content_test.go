@@ -0,0 +1,54 @@
+package wid
+
+import (
+	"crypto/sha256"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNextForContentIdempotent checks equal digests map to one ID per tick and distinct digests do not.
+func TestNextForContentIdempotent(t *testing.T) {
+	g, _ := NewWidGen(4, 8)
+	a := sha256.Sum256([]byte("payload-a"))
+	b := sha256.Sum256([]byte("payload-b"))
+	// Retry across a tick boundary so both calls land in the same second.
+	for i := 0; i < 3; i++ {
+		first, second := g.NextForContent(a[:]), g.NextForContent(a[:])
+		if first[:15] != second[:15] {
+			continue
+		}
+		if first != second {
+			t.Fatalf("same content in same tick gave %s and %s", first, second)
+		}
+		if !ValidateWid(first, 4, 8) || !strings.HasSuffix(first, "-"+contentPadding(a[:], 8)) {
+			t.Fatalf("padding not derived from digest: %s", first)
+		}
+		if other := g.NextForContent(b[:]); other == first || !(other > first) {
+			t.Fatalf("distinct content should get a later, distinct ID: %s vs %s", other, first)
+		}
+		return
+	}
+	t.Fatal("could not observe two calls in the same tick")
+}
+
+// TestNextForContentReadsClockOnce checks a virtual clock advances one step
+// per call, not two.
+func TestNextForContentReadsClockOnce(t *testing.T) {
+	start := time.Date(2026, 2, 12, 9, 15, 30, 0, time.UTC)
+	g, _ := NewDeterministicWidGen(1, start, time.Second, 4, 6, TimeUnitSec)
+	a := sha256.Sum256([]byte("payload-a"))
+	for i, want := range []string{"20260212T091530.0000Z-", "20260212T091531.0000Z-"} {
+		if id := g.NextForContent(a[:]); !strings.HasPrefix(id, want) {
+			t.Fatalf("call %d = %s, want %s...", i, id, want)
+		}
+	}
+}
+
+// TestContentPaddingExtends verifies padding longer than the digest is still deterministic hex.
+func TestContentPaddingExtends(t *testing.T) {
+	p := contentPadding([]byte{0xab, 0xcd}, 20)
+	if len(p) != 20 || !strings.HasPrefix(p, "abcd") || p != contentPadding([]byte{0xab, 0xcd}, 20) {
+		t.Fatalf("contentPadding = %q", p)
+	}
+}
@@ -0,0 +1,196 @@
+package wid
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrPrecisionLoss   = errors.New("conversion would lose precision")
+	ErrConversionRange = errors.New("timestamp outside the target format's range")
+)
+
+// ksuidEpoch is the KSUID epoch, 2014-05-13T16:53:20Z, in Unix seconds.
+const ksuidEpoch = 1400000000
+
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// ksuidMsBits is where ToKSUID keeps the milliseconds of a ms-unit WID: the
+// top 10 payload bits, ahead of the sequence and padding, so KSUIDs of one
+// second still sort by millisecond.
+const ksuidMsBits = 118
+
+// ToULID maps a WID onto a ULID: the 48-bit timestamp is the tick in
+// milliseconds and the 80 random bits hold sequence << 4Z | padding, so
+// ULIDs sort like the IDs they came from. FromULID with the same W, Z and
+// unit gives the ID back. A WID whose sequence and padding need more than 80
+// bits fails with ErrPrecisionLoss.
+func ToULID(id string) (string, error) {
+	f, err := convFieldsOf(id)
+	if err != nil {
+		return "", err
+	}
+	ms := f.tick
+	if f.unit == TimeUnitSec {
+		ms *= 1000
+	}
+	if ms < 0 || ms >= 1<<48 {
+		return "", ErrConversionRange
+	}
+	if f.low.BitLen() > 80 {
+		return "", fmt.Errorf("%w: W=%d with Z=%d needs more than 80 bits", ErrPrecisionLoss, f.w, f.z)
+	}
+	var b [16]byte
+	big.NewInt(ms).FillBytes(b[0:6])
+	f.low.FillBytes(b[6:16])
+	return crockfordEncode(b), nil
+}
+
+// FromULID maps a ULID to a WID with the given W, Z and unit, reversing
+// ToULID. Any ULID converts losslessly in ms with Z=20, where the padding is
+// the 80 random bits; otherwise a millisecond part the sec unit cannot hold,
+// or random bits beyond W and Z, fail with ErrPrecisionLoss.
+func FromULID(s string, w, z int, unit TimeUnit) (string, error) {
+	if err := checkParams(w, z, unit); err != nil {
+		return "", err
+	}
+	b, err := crockfordDecode(s)
+	if err != nil {
+		return "", err
+	}
+	tick := new(big.Int).SetBytes(b[0:6]).Int64()
+	if unit == TimeUnitSec {
+		if tick%1000 != 0 {
+			return "", fmt.Errorf("%w: ULID has milliseconds", ErrPrecisionLoss)
+		}
+		tick /= 1000
+	}
+	return convFields{tick: tick, unit: unit, w: w, z: z, low: new(big.Int).SetBytes(b[6:16])}.format()
+}
+
+// ToKSUID maps a WID onto a KSUID: seconds since the KSUID epoch, then a
+// 128-bit payload of sequence << 4Z | padding, with a ms-unit WID's
+// milliseconds in the top 10 bits. KSUIDs sort like the IDs they came from
+// and FromKSUID with the same W, Z and unit gives the ID back. IDs before
+// 2014-05-13 fail with ErrConversionRange, and a sequence and padding too
+// wide for the payload with ErrPrecisionLoss.
+func ToKSUID(id string) (string, error) {
+	f, err := convFieldsOf(id)
+	if err != nil {
+		return "", err
+	}
+	sec, room := f.tick, 128
+	payload := f.low
+	if f.unit == TimeUnitMs {
+		sec, room = f.tick/1000, ksuidMsBits
+		payload = new(big.Int).Lsh(big.NewInt(f.tick%1000), ksuidMsBits)
+		payload.Or(payload, f.low)
+	}
+	if sec < ksuidEpoch || sec-ksuidEpoch >= 1<<32 {
+		return "", ErrConversionRange
+	}
+	if f.low.BitLen() > room {
+		return "", fmt.Errorf("%w: W=%d with Z=%d needs more than %d bits", ErrPrecisionLoss, f.w, f.z, room)
+	}
+	var b [20]byte
+	big.NewInt(sec - ksuidEpoch).FillBytes(b[0:4])
+	payload.FillBytes(b[4:20])
+	// Fixed-width base62 in ASCII order sorts like the bytes.
+	n, r := new(big.Int).SetBytes(b[:]), new(big.Int)
+	out := make([]byte, 27)
+	for i := 26; i >= 0; i-- {
+		n.DivMod(n, big.NewInt(62), r)
+		out[i] = base62[r.Int64()]
+	}
+	return string(out), nil
+}
+
+// FromKSUID maps a KSUID to a WID with the given W, Z and unit, reversing
+// ToKSUID. Any KSUID converts losslessly in sec with Z=32, where the padding
+// is the payload; payload bits beyond W and Z, or in ms a millisecond field
+// over 999, fail with ErrPrecisionLoss.
+func FromKSUID(s string, w, z int, unit TimeUnit) (string, error) {
+	if err := checkParams(w, z, unit); err != nil {
+		return "", err
+	}
+	if len(s) != 27 {
+		return "", fmt.Errorf("%w: KSUID must be 27 characters", ErrInvalidFormat)
+	}
+	n := new(big.Int)
+	for i := 0; i < len(s); i++ {
+		v := strings.IndexByte(base62, s[i])
+		if v < 0 {
+			return "", fmt.Errorf("%w: invalid base62 character %q", ErrInvalidFormat, s[i])
+		}
+		n.Mul(n, big.NewInt(62)).Add(n, big.NewInt(int64(v)))
+	}
+	if n.BitLen() > 160 {
+		return "", fmt.Errorf("%w: KSUID overflows 160 bits", ErrInvalidFormat)
+	}
+	var b [20]byte
+	n.FillBytes(b[:])
+	tick := new(big.Int).SetBytes(b[0:4]).Int64() + ksuidEpoch
+	low := new(big.Int).SetBytes(b[4:20])
+	if unit == TimeUnitMs {
+		ms := new(big.Int).Rsh(low, ksuidMsBits).Int64()
+		if ms > 999 {
+			return "", fmt.Errorf("%w: millisecond field %d", ErrPrecisionLoss, ms)
+		}
+		tick = tick*1000 + ms
+		low.And(low, new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), ksuidMsBits), big.NewInt(1)))
+	}
+	return convFields{tick: tick, unit: unit, w: w, z: z, low: low}.format()
+}
+
+// convFields is a WID as tick plus sequence << 4Z | padding, wide enough for
+// any Z.
+type convFields struct {
+	tick int64
+	unit TimeUnit
+	w, z int
+	low  *big.Int
+}
+
+func convFieldsOf(id string) (convFields, error) {
+	d, err := DetectParams(id)
+	if err != nil {
+		return convFields{}, err
+	}
+	if d.Kind != "wid" {
+		return convFields{}, ErrBinaryHLC
+	}
+	p, err := ParseWidFast(id, d.W, d.Z, d.TimeUnit)
+	if err != nil {
+		return convFields{}, err
+	}
+	f := convFields{tick: p.Timestamp.Unix(), unit: d.TimeUnit, w: d.W, z: d.Z, low: big.NewInt(int64(p.Sequence))}
+	if d.TimeUnit == TimeUnitMs {
+		f.tick = p.Timestamp.UnixMilli()
+	}
+	f.low.Lsh(f.low, uint(4*d.Z))
+	if p.Padding != nil {
+		pad, _ := new(big.Int).SetString(*p.Padding, 16)
+		f.low.Or(f.low, pad)
+	}
+	return f, nil
+}
+
+// format renders the fields as a WID, failing with ErrPrecisionLoss when
+// the sequence part does not fit W digits.
+func (f convFields) format() (string, error) {
+	seq := new(big.Int).Rsh(f.low, uint(4*f.z))
+	if seq.Cmp(big.NewInt(int64(pow10(f.w)))) >= 0 {
+		return "", fmt.Errorf("%w: value does not fit W=%d with Z=%d", ErrPrecisionLoss, f.w, f.z)
+	}
+	pad := ""
+	if f.z > 0 {
+		mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(4*f.z)), big.NewInt(1))
+		pad = fmt.Sprintf("%0*x", f.z, new(big.Int).And(f.low, mask))
+	}
+	if err := checkTick(f.tick, f.w, f.z, f.unit); err != nil {
+		return "", err
+	}
+	return formatID(f.tick, int(seq.Int64()), f.w, f.unit, "", pad), nil
+}
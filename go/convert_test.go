@@ -0,0 +1,71 @@
+package wid
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestULIDAndKSUIDRoundTrip converts generated IDs both ways, checks order is
+// kept, foreign values survive at full width and lossy targets are refused.
+func TestULIDAndKSUIDRoundTrip(t *testing.T) {
+	conv := []struct {
+		name string
+		to   func(string) (string, error)
+		from func(string, int, int, TimeUnit) (string, error)
+	}{{"ULID", ToULID, FromULID}, {"KSUID", ToKSUID, FromKSUID}}
+	for _, c := range conv {
+		for _, unit := range []TimeUnit{TimeUnitSec, TimeUnitMs} {
+			g, _ := NewWidGenWithUnit(4, 6, unit)
+			ids := g.NextN(100)
+			outs := make([]string, len(ids))
+			for i, id := range ids {
+				out, err := c.to(id)
+				if err != nil {
+					t.Fatalf("To%s(%s) = %v", c.name, id, err)
+				}
+				if back, err := c.from(out, 4, 6, unit); back != id || err != nil {
+					t.Fatalf("From%s(%s) = %q, %v; want %s", c.name, out, back, err, id)
+				}
+				outs[i] = out
+			}
+			if !sort.StringsAreSorted(outs) {
+				t.Fatalf("%s %s: output order differs from ID order", c.name, unit)
+			}
+		}
+	}
+
+	ulid := "01ARZ3NDEKTSV4RRFFQ69G5FAV"
+	id, err := FromULID(ulid, 1, 20, TimeUnitMs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back, _ := ToULID(id); back != ulid {
+		t.Fatalf("foreign ULID %s came back as %s via %s", ulid, back, id)
+	}
+	if _, err := FromULID(ulid, 4, 6, TimeUnitMs); !errors.Is(err, ErrPrecisionLoss) {
+		t.Fatalf("random bits beyond W/Z = %v", err)
+	}
+	if _, err := FromULID(ulid, 1, 20, TimeUnitSec); !errors.Is(err, ErrPrecisionLoss) {
+		t.Fatalf("milliseconds into sec = %v", err)
+	}
+
+	ksuid := "0ujtsYcgvSTl8PAuAdqWYSMnLOv"
+	id, err = FromKSUID(ksuid, 1, 32, TimeUnitSec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back, _ := ToKSUID(id); back != ksuid {
+		t.Fatalf("foreign KSUID %s came back as %s via %s", ksuid, back, id)
+	}
+	if _, err := ToKSUID("20100101T000000.0000Z"); !errors.Is(err, ErrConversionRange) {
+		t.Fatalf("pre-epoch KSUID = %v", err)
+	}
+	if _, err := ToULID("20261016T120000.0001Z-node1"); !errors.Is(err, ErrBinaryHLC) {
+		t.Fatalf("HLC-WID = %v", err)
+	}
+	if _, err := FromKSUID(strings.Repeat("z", 27), 1, 32, TimeUnitSec); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("overflowing KSUID = %v", err)
+	}
+}
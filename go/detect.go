@@ -0,0 +1,81 @@
+package wid
+
+import "strings"
+
+// DetectedParams is what can be inferred about an ID from its layout alone.
+type DetectedParams struct {
+	Kind     string // "wid" or "hlc"
+	TimeUnit TimeUnit
+	W, Z     int
+}
+
+// DetectParams infers kind, time unit, W and Z from an ID's layout. A single
+// lowercase-hex segment after the Z is read as WID padding, so an unpadded
+// HLC-WID whose node is itself lowercase hex is reported as a padded WID.
+func DetectParams(id string) (DetectedParams, error) {
+	dot := strings.IndexByte(id, '.')
+	zi := strings.IndexByte(id, 'Z')
+	if len(id) < 9 || id[8] != 'T' || dot < 0 || zi < dot {
+		return DetectedParams{}, ErrInvalidFormat
+	}
+	s := DetectedParams{Kind: "wid", TimeUnit: TimeUnitSec, W: zi - dot - 1}
+	switch dot - 9 {
+	case timeDigits(TimeUnitSec):
+	case timeDigits(TimeUnitMs):
+		s.TimeUnit = TimeUnitMs
+	default:
+		return DetectedParams{}, ErrInvalidTimestamp
+	}
+	rest := id[zi+1:]
+	if rest == "" {
+		return s, nil
+	}
+	segs := strings.Split(strings.TrimPrefix(rest, "-"), "-")
+	switch len(segs) {
+	case 1:
+		if segs[0] != "" && isLowerHexStr(segs[0]) {
+			s.Z = len(segs[0])
+		} else {
+			s.Kind = "hlc"
+		}
+	case 2:
+		s.Kind, s.Z = "hlc", len(segs[1])
+	default:
+		return DetectedParams{}, ErrInvalidFormat
+	}
+	return s, nil
+}
+
+// ParseAny detects an ID's parameters and parses it with them, for consumers
+// that receive IDs from producers with differing settings. Exactly one of the
+// returned parsed values is non-nil on success.
+func ParseAny(id string) (*ParsedWid, *ParsedHlcWid, DetectedParams, error) {
+	s, err := DetectParams(id)
+	if err != nil {
+		return nil, nil, s, err
+	}
+	if s.Kind == "wid" {
+		p, err := ParseWidWithUnit(id, s.W, s.Z, s.TimeUnit)
+		return p, nil, s, err
+	}
+	p, err := ParseHlcWidWithUnit(id, s.W, s.Z, s.TimeUnit)
+	return nil, p, s, err
+}
+
+// parseAnyHlc is ParseAny for callers that expect an HLC-WID: a lone hex
+// segment, which DetectParams reads as WID padding, is taken as the node.
+// Anything else that is not an HLC-WID fails with ErrInvalidFormat.
+func parseAnyHlc(id string) (*ParsedHlcWid, DetectedParams, error) {
+	_, h, d, err := ParseAny(id)
+	if err != nil {
+		return nil, d, err
+	}
+	if h == nil && d.Z > 0 {
+		h, _ = ParseHlcWidFast(id, d.W, 0, d.TimeUnit)
+		d.Kind, d.Z = "hlc", 0
+	}
+	if h == nil {
+		return nil, d, ErrInvalidFormat
+	}
+	return h, d, nil
+}
@@ -0,0 +1,28 @@
+package wid
+
+import "testing"
+
+// TestParseAny checks parameters are inferred for each kind/unit/padding mix.
+func TestParseAny(t *testing.T) {
+	cases := []struct {
+		id   string
+		want DetectedParams
+	}{
+		{"20261016T120000.0001Z", DetectedParams{"wid", TimeUnitSec, 4, 0}},
+		{"20261016T120000123.000001Z-abcdef", DetectedParams{"wid", TimeUnitMs, 6, 6}},
+		{"20261016T120000.0001Z-node1", DetectedParams{"hlc", TimeUnitSec, 4, 0}},
+		{"20261016T120000123.0001Z-node1-ab", DetectedParams{"hlc", TimeUnitMs, 4, 2}},
+	}
+	for _, c := range cases {
+		pw, ph, got, err := ParseAny(c.id)
+		if err != nil || got != c.want {
+			t.Fatalf("ParseAny(%s) = %+v, %v; want %+v", c.id, got, err, c.want)
+		}
+		if (pw != nil) != (c.want.Kind == "wid") || (ph != nil) != (c.want.Kind == "hlc") {
+			t.Fatalf("ParseAny(%s) returned the wrong parsed kind", c.id)
+		}
+	}
+	if _, _, _, err := ParseAny("20261016T1200.0001Z"); err != ErrInvalidTimestamp {
+		t.Fatalf("short time: %v", err)
+	}
+}
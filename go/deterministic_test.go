@@ -0,0 +1,59 @@
+package wid
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDeterministicWidStable checks identical inputs reproduce the same valid WID.
+func TestDeterministicWidStable(t *testing.T) {
+	at := time.Date(2026, 2, 12, 9, 15, 30, 0, time.UTC)
+	a, err := DeterministicWid("orders", "order-42", at, 4, 6, TimeUnitSec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := DeterministicWid("orders", "order-42", at, 4, 6, TimeUnitSec)
+	if a != b {
+		t.Fatalf("expected stable ID, got %s and %s", a, b)
+	}
+	if !ValidateWid(a, 4, 6) || a[:15] != "20260212T091530" {
+		t.Fatalf("unexpected deterministic WID %s", a)
+	}
+	c, _ := DeterministicWid("orders", "order-43", at, 4, 6, TimeUnitSec)
+	d, _ := DeterministicWid("order", "sorder-42", at, 4, 6, TimeUnitSec)
+	if c == a || d == a {
+		t.Fatal("different namespace/name must change the ID")
+	}
+	if _, err := DeterministicWid("ns", "n", at, 0, 6, TimeUnitSec); err != ErrInvalidW {
+		t.Fatalf("expected ErrInvalidW, got %v", err)
+	}
+}
+
+// TestDeterministicWidGenReproducible checks equal seeds replay the same
+// sequence and a different seed changes the padding only.
+func TestDeterministicWidGenReproducible(t *testing.T) {
+	start := time.Date(2026, 2, 12, 9, 15, 30, 0, time.UTC)
+	run := func(seed int64) []string {
+		g, err := NewDeterministicWidGen(seed, start, 400*time.Millisecond, 4, 6, TimeUnitSec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return g.NextN(5)
+	}
+	a, b, c := run(7), run(7), run(8)
+	want := []string{"20260212T091530.0000Z", "20260212T091530.0001Z", "20260212T091530.0002Z", "20260212T091531.0000Z", "20260212T091531.0001Z"}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("same seed diverged at %d: %s vs %s", i, a[i], b[i])
+		}
+		if a[i][:len(want[i])] != want[i] || !ValidateWid(a[i], 4, 6) {
+			t.Fatalf("id %d = %s, want prefix %s", i, a[i], want[i])
+		}
+		if c[i] == a[i] {
+			t.Fatalf("different seeds gave the same id %s", a[i])
+		}
+	}
+	if _, err := NewDeterministicWidGen(1, start, 0, 4, 6, TimeUnitSec); err != ErrInvalidStep {
+		t.Fatalf("zero step = %v, want ErrInvalidStep", err)
+	}
+}
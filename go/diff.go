@@ -0,0 +1,77 @@
+package wid
+
+import (
+	"fmt"
+	"time"
+)
+
+// WidDiff describes how two IDs relate, as reported by Diff.
+type WidDiff struct {
+	A, B string
+	// Kind is "wid" or "hlc" when both IDs share it, otherwise "mixed".
+	Kind string
+	// Elapsed is B's timestamp minus A's.
+	Elapsed time.Duration
+	// SameTick reports whether both IDs carry the same timestamp.
+	SameTick bool
+	// CounterDelta is B's sequence (or HLC logical counter) minus A's. Across
+	// ticks it is informational only, since counters restart every tick.
+	CounterDelta int
+	NodeA, NodeB string
+	NodeChanged  bool
+	// SameGenerator reports whether B could plausibly have been emitted by the
+	// generator that emitted A, after it; Reason explains a false verdict.
+	SameGenerator bool
+	Reason        string
+}
+
+// Diff compares two IDs whose parameters are inferred from their shape
+// (kind, time unit, W and Z).
+func Diff(a, b string) (*WidDiff, error) {
+	pa, ha, sa, err := ParseAny(a)
+	if err != nil {
+		return nil, fmt.Errorf("first id: %w", err)
+	}
+	pb, hb, sb, err := ParseAny(b)
+	if err != nil {
+		return nil, fmt.Errorf("second id: %w", err)
+	}
+	var ta, tb time.Time
+	var ca, cb int
+	d := &WidDiff{A: a, B: b, Kind: sa.Kind}
+	if pa != nil {
+		ta, ca = pa.Timestamp, pa.Sequence
+	} else {
+		ta, ca, d.NodeA = ha.Timestamp, ha.LogicalCounter, ha.Node
+	}
+	if pb != nil {
+		tb, cb = pb.Timestamp, pb.Sequence
+	} else {
+		tb, cb, d.NodeB = hb.Timestamp, hb.LogicalCounter, hb.Node
+	}
+	if sa.Kind != sb.Kind {
+		d.Kind = "mixed"
+	}
+	d.Elapsed = tb.Sub(ta)
+	d.SameTick = ta.Equal(tb)
+	d.CounterDelta = cb - ca
+	d.NodeChanged = d.NodeA != d.NodeB
+
+	switch {
+	case sa.Kind != sb.Kind:
+		d.Reason = "different kinds"
+	case sa.TimeUnit != sb.TimeUnit:
+		d.Reason = "different time units"
+	case sa.W != sb.W:
+		d.Reason = "different sequence widths"
+	case sa.Z != sb.Z:
+		d.Reason = "different padding lengths"
+	case d.NodeChanged:
+		d.Reason = "different nodes"
+	case d.Elapsed < 0 || d.SameTick && d.CounterDelta <= 0:
+		d.Reason = "second id does not sort after the first"
+	default:
+		d.SameGenerator = true
+	}
+	return d, nil
+}
@@ -0,0 +1,44 @@
+package wid
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDiffSameGenerator checks elapsed time and counter delta for IDs from one generator.
+func TestDiffSameGenerator(t *testing.T) {
+	d, err := Diff("20260212T091530.0042Z-a3f91c", "20260212T091532.0001Z-0b77e2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Elapsed != 2*time.Second || d.SameTick || d.CounterDelta != -41 || !d.SameGenerator || d.Kind != "wid" {
+		t.Fatalf("unexpected diff %+v", d)
+	}
+	d, _ = Diff("20260212T091530123.0042Z-node01", "20260212T091530123.0045Z-node01")
+	if !d.SameTick || d.CounterDelta != 3 || !d.SameGenerator || d.Kind != "hlc" {
+		t.Fatalf("unexpected ms HLC diff %+v", d)
+	}
+}
+
+// TestDiffImplausible covers the reasons an ID cannot follow another from the same generator.
+func TestDiffImplausible(t *testing.T) {
+	cases := map[[2]string]string{
+		{"20260212T091530.0042Z-node01", "20260212T091530.0043Z-node02"}: "different nodes",
+		{"20260212T091530.0042Z", "20260212T091530.0042Z"}:               "second id does not sort after the first",
+		{"20260212T091530.0042Z", "20260212T091529.0043Z"}:               "second id does not sort after the first",
+		{"20260212T091530.0042Z", "20260212T091530.00043Z"}:              "different sequence widths",
+		{"20260212T091530.0042Z-a3f91c", "20260212T091531.0000Z-node01"}: "different kinds",
+	}
+	for ids, reason := range cases {
+		d, err := Diff(ids[0], ids[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.SameGenerator || d.Reason != reason {
+			t.Errorf("Diff(%s, %s): got %v %q, want %q", ids[0], ids[1], d.SameGenerator, d.Reason, reason)
+		}
+	}
+	if _, err := Diff("waldiez", "20260212T091530.0042Z"); err == nil {
+		t.Fatal("expected error for malformed id")
+	}
+}
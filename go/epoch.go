@@ -0,0 +1,114 @@
+package wid
+
+import "time"
+
+// Config describes a family of IDs: W, Z, the time unit and an optional
+// custom epoch. With an Epoch, generators write the time elapsed since it in
+// place of the Unix time, so the printed date starts at 1970-01-01 when the
+// epoch begins. That hides when IDs were really issued from anyone without
+// the Config, and, for short-lived IDs, keeps the date part constant and
+// compressible. Epoch IDs are ordinary WIDs to every other function; only
+// the Config methods read their true time. A zero TimeUnit means seconds
+// and a zero Epoch the Unix epoch. EscapeNodes selects node format v2 (see
+// NodeEscape) for the HLC-WIDs of the Config.
+type Config struct {
+	W, Z        int
+	TimeUnit    TimeUnit
+	Epoch       time.Time
+	EscapeNodes bool
+}
+
+func (c Config) unit() TimeUnit {
+	if c.TimeUnit == "" {
+		return TimeUnitSec
+	}
+	return c.TimeUnit
+}
+
+// offset is the epoch in ticks of the unit.
+func (c Config) offset() int64 {
+	if c.Epoch.IsZero() {
+		return 0
+	}
+	if c.unit() == TimeUnitMs {
+		return c.Epoch.UnixMilli()
+	}
+	return c.Epoch.Unix()
+}
+
+// shift is what the Config methods add to a printed time to get the real one.
+func (c Config) shift() time.Duration {
+	return time.Duration(c.offset()) * tickDuration(c.unit())
+}
+
+// NewWidGen returns a WID generator for c.
+func (c Config) NewWidGen() (*WidGen, error) {
+	g, err := NewWidGenWithUnit(c.W, c.Z, c.unit())
+	if err != nil {
+		return nil, err
+	}
+	g.epoch = c.offset()
+	return g, nil
+}
+
+// NewHLCWidGen returns an HLC-WID generator for c. Peers exchanging IDs
+// (Observe, ObserveWid, ReceiveEvent) must share the epoch; ObserveTime and
+// ObserveUnix take real times and convert them. With EscapeNodes, node may
+// be any non-empty name and is escaped.
+func (c Config) NewHLCWidGen(node string) (*HLCWidGen, error) {
+	if c.EscapeNodes {
+		var err error
+		if node, err = EscapeNode(node); err != nil {
+			return nil, err
+		}
+	}
+	g, err := NewHLCWidGenWithUnit(node, c.W, c.Z, c.unit())
+	if err != nil {
+		return nil, err
+	}
+	g.epoch = c.offset()
+	return g, nil
+}
+
+// ParseWid parses a WID issued under c and reports its real time.
+func (c Config) ParseWid(id string) (*ParsedWid, error) {
+	p, err := ParseWidFast(id, c.W, c.Z, c.unit())
+	if err != nil {
+		return nil, err
+	}
+	p.Timestamp = p.Timestamp.Add(c.shift())
+	p.Millisecond = p.Timestamp.Nanosecond() / 1_000_000
+	return p, nil
+}
+
+// ParseHlcWid parses an HLC-WID issued under c and reports its real time
+// and, with EscapeNodes, the unescaped node name.
+func (c Config) ParseHlcWid(id string) (*ParsedHlcWid, error) {
+	p, err := ParseHlcWidFast(id, c.W, c.Z, c.unit())
+	if err != nil {
+		return nil, err
+	}
+	if c.EscapeNodes {
+		if p.Node, err = UnescapeNode(p.Node); err != nil {
+			return nil, err
+		}
+	}
+	p.Timestamp = p.Timestamp.Add(c.shift())
+	p.Millisecond = p.Timestamp.Nanosecond() / 1_000_000
+	return p, nil
+}
+
+// RangeForInterval is the package-level RangeForInterval for IDs issued
+// under c: from and to are real times.
+func (c Config) RangeForInterval(from, to time.Time) (lo, hi string) {
+	return RangeForInterval(from.Add(-c.shift()), to.Add(-c.shift()), c.W, c.Z, c.unit())
+}
+
+func (g *WidGen) now() int64 {
+	if g.clock != nil {
+		return g.clock() - g.epoch
+	}
+	return nowTick(g.TimeUnit) - g.epoch
+}
+
+func (g *HLCWidGen) now() int64 { return nowTick(g.TimeUnit) - g.epoch }
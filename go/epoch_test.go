@@ -0,0 +1,45 @@
+package wid
+
+import (
+	"testing"
+	"time"
+)
+
+// TestConfigEpoch checks IDs under a custom epoch print the elapsed time,
+// parse back to the real time and fall inside the Config's ranges.
+func TestConfigEpoch(t *testing.T) {
+	c := Config{W: 4, Z: 0, TimeUnit: TimeUnitMs, Epoch: time.Now().Add(-90 * time.Minute)}
+	g, err := c.NewWidGen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now().Truncate(time.Millisecond)
+	id := g.Next()
+	if id[:11] != "19700101T01" {
+		t.Fatalf("%s does not show 1h30m since the epoch", id)
+	}
+	p, err := c.ParseWid(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := p.Timestamp.Sub(before); d < -time.Millisecond || d > time.Second {
+		t.Fatalf("real time %v, issued around %v", p.Timestamp, before)
+	}
+	lo, hi := c.RangeForInterval(before.Add(-time.Second), time.Now().Add(time.Second))
+	if id < lo || id > hi {
+		t.Fatalf("%s outside [%s, %s]", id, lo, hi)
+	}
+	at, err := g.NextAt(before.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := c.ParseWid(at); !p.Timestamp.Equal(before.Add(time.Hour)) {
+		t.Fatalf("NextAt %s parsed to %v", at, p.Timestamp)
+	}
+
+	h, _ := c.NewHLCWidGen("n1")
+	hid := h.Next()
+	if hp, err := c.ParseHlcWid(hid); err != nil || hp.Timestamp.Sub(before) > time.Second {
+		t.Fatalf("HLC %s = %+v, %v", hid, hp, err)
+	}
+}
@@ -0,0 +1,132 @@
+package wid
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ParseWidFast is ParseWidWithUnit without regular expressions: the fixed
+// fields are checked by offset and the padding by a byte scan, so there is
+// no per-W/unit regexp to compile or run. It accepts and rejects exactly the
+// inputs ParseWidWithUnit does and returns the same errors; the regexp path
+// remains the reference for parity tests.
+func ParseWidFast(wid string, w, z int, unit TimeUnit) (*ParsedWid, error) {
+	if err := checkParams(w, z, unit); err != nil {
+		return nil, err
+	}
+	n, ok := scanHead(wid, w, unit)
+	if !ok || strings.IndexByte(wid[n:], '\n') >= 0 {
+		return nil, ErrInvalidFormat
+	}
+	td := timeDigits(unit)
+	ts, err := parseCalendar(wid[:8], wid[9:9+td], unit)
+	if err != nil {
+		return nil, err
+	}
+	seq, _ := strconv.Atoi(wid[10+td : n-1])
+	padding, err := scanPadding(wid[n:], z)
+	if err != nil {
+		return nil, err
+	}
+	ms := ts.Nanosecond() / 1_000_000
+	return &ParsedWid{Raw: wid, Timestamp: ts, Sequence: seq, Padding: padding, Millisecond: ms}, nil
+}
+
+// ParseHlcWidFast is the regexp-free counterpart of ParseHlcWidWithUnit.
+func ParseHlcWidFast(wid string, w, z int, unit TimeUnit) (*ParsedHlcWid, error) {
+	if err := checkParams(w, z, unit); err != nil {
+		return nil, err
+	}
+	n, ok := scanHead(wid, w, unit)
+	if !ok || n >= len(wid) || wid[n] != '-' {
+		return nil, ErrInvalidFormat
+	}
+	end := n + 1
+	for end < len(wid) && !isNodeStop(wid[end]) {
+		end++
+	}
+	if end == n+1 || strings.IndexByte(wid[end:], '\n') >= 0 {
+		return nil, ErrInvalidFormat
+	}
+	node := wid[n+1 : end]
+	if !isValidNode(node) {
+		return nil, ErrInvalidNode
+	}
+	td := timeDigits(unit)
+	ts, err := parseCalendar(wid[:8], wid[9:9+td], unit)
+	if err != nil {
+		return nil, err
+	}
+	lc, _ := strconv.Atoi(wid[10+td : n-1])
+	padding, err := scanPadding(wid[end:], z)
+	if err != nil {
+		return nil, err
+	}
+	ms := ts.Nanosecond() / 1_000_000
+	return &ParsedHlcWid{Raw: wid, Timestamp: ts, LogicalCounter: lc, Node: node, Padding: padding, Millisecond: ms}, nil
+}
+
+func checkParams(w, z int, unit TimeUnit) error {
+	if w <= 0 || w > MaxW {
+		return ErrInvalidW
+	}
+	if z < 0 || z > MaxZ {
+		return ErrInvalidZ
+	}
+	if unit != TimeUnitSec && unit != TimeUnitMs {
+		return ErrInvalidTimeUnit
+	}
+	return nil
+}
+
+// scanHead matches "<8 digits>T<time digits>.<W digits>Z" at the start of s
+// and returns the offset just past the Z.
+func scanHead(s string, w int, unit TimeUnit) (int, bool) {
+	td := timeDigits(unit)
+	n := 8 + 1 + td + 1 + w + 1
+	if len(s) < n || s[8] != 'T' || s[9+td] != '.' || s[n-1] != 'Z' {
+		return 0, false
+	}
+	if !allDigits(s[:8]) || !allDigits(s[9:9+td]) || !allDigits(s[10+td:n-1]) {
+		return 0, false
+	}
+	return n, true
+}
+
+func allDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isNodeStop reports the bytes that end an HLC node: '-' and the ASCII
+// whitespace of the regexp's \s class.
+func isNodeStop(b byte) bool {
+	switch b {
+	case '-', ' ', '\t', '\n', '\f', '\r':
+		return true
+	}
+	return false
+}
+
+// scanPadding applies the "-<Z lowercase hex>" rule to whatever follows the
+// fixed fields.
+func scanPadding(suffix string, z int) (*string, error) {
+	if suffix == "" {
+		return nil, nil
+	}
+	if suffix[0] != '-' || z == 0 || len(suffix)-1 != z {
+		return nil, ErrInvalidFormat
+	}
+	seg := suffix[1:]
+	for i := 0; i < len(seg); i++ {
+		c := seg[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return nil, ErrInvalidFormat
+		}
+	}
+	return &seg, nil
+}
@@ -0,0 +1,59 @@
+package wid
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// TestParseFastParity mutates valid IDs byte by byte and checks the fast
+// parsers agree with the regexp parsers on every input, errors included.
+func TestParseFastParity(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	alphabet := []byte("0123456789abcfgTZz.-_ \t\n\f\xff")
+	for _, unit := range []TimeUnit{TimeUnitSec, TimeUnitMs} {
+		wg, _ := NewWidGenWithUnit(4, 6, unit)
+		hg, _ := NewHLCWidGenWithUnit("node01", 4, 6, unit)
+		seeds := []string{wg.Next(), hg.Next(), "20260230T120000.0000Z", "20261016T235959.9999Z-node-x", "20261016T120000.0001Z-abcdef_prod"}
+		for _, seed := range seeds {
+			for i := 0; i < 2000; i++ {
+				b := []byte(seed)
+				for k := r.Intn(3); k >= 0; k-- {
+					switch p := r.Intn(len(b) + 1); r.Intn(3) {
+					case 0:
+						if p < len(b) {
+							b[p] = alphabet[r.Intn(len(alphabet))]
+						}
+					case 1:
+						b = append(b[:p], append([]byte{alphabet[r.Intn(len(alphabet))]}, b[p:]...)...)
+					default:
+						if p < len(b) {
+							b = append(b[:p], b[p+1:]...)
+						}
+					}
+				}
+				s := string(b)
+				for _, z := range []int{0, 6} {
+					want, werr := ParseWidWithUnit(s, 4, z, unit)
+					got, gerr := ParseWidFast(s, 4, z, unit)
+					if werr != gerr || (want != nil) != (got != nil) || (want != nil && !sameParsed(*want, *got)) {
+						t.Fatalf("ParseWidFast(%q, z=%d, %s) = %v, %v; regexp path = %v, %v", s, z, unit, got, gerr, want, werr)
+					}
+					hwant, hwerr := ParseHlcWidWithUnit(s, 4, z, unit)
+					hgot, hgerr := ParseHlcWidFast(s, 4, z, unit)
+					if hwerr != hgerr || (hwant != nil) != (hgot != nil) || (hwant != nil && (hwant.Node != hgot.Node || hwant.LogicalCounter != hgot.LogicalCounter || !samePadded(hwant.Padding, hgot.Padding))) {
+						t.Fatalf("ParseHlcWidFast(%q, z=%d, %s) = %v, %v; regexp path = %v, %v", s, z, unit, hgot, hgerr, hwant, hwerr)
+					}
+				}
+			}
+		}
+	}
+}
+
+func sameParsed(a, b ParsedWid) bool {
+	return a.Raw == b.Raw && a.Timestamp.Equal(b.Timestamp) && a.Sequence == b.Sequence &&
+		a.Millisecond == b.Millisecond && samePadded(a.Padding, b.Padding) && a.Namespace == b.Namespace
+}
+
+func samePadded(a, b *string) bool {
+	return (a == nil) == (b == nil) && (a == nil || *a == *b)
+}
@@ -0,0 +1,181 @@
+package wid
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrStateCorrupt is returned when a state file fails its checksum and
+// cannot be recovered safely.
+var ErrStateCorrupt = errors.New("state file is corrupt")
+
+// FileStateStore keeps generator state in a single file, so one host can run
+// a restart-safe generator without sqlite3. Every CompareAndSwap holds an
+// exclusive flock on "<path>.lock" (on Unix; elsewhere it only serializes
+// within the process), writes a checksummed record to a temporary file,
+// fsyncs it, keeps the previous state as "<path>.prev" and renames the new
+// record into place. A crash therefore leaves either the old or the new
+// state, never a torn one.
+//
+// If the file is nonetheless damaged (a failed disk, a manual edit) and
+// RecoverUnit is set, it is moved aside to "<path>.corrupt" and the store
+// resumes past anything the lost record could have issued: the tick after
+// both the previous state and the current time. Without RecoverUnit, Load
+// fails with ErrStateCorrupt. PersistentGen sets RecoverUnit from its
+// generator when it is empty.
+type FileStateStore struct {
+	Path        string
+	RecoverUnit TimeUnit
+	mu          sync.Mutex
+}
+
+// NewFileStateStore returns a store backed by path.
+func NewFileStateStore(path string) *FileStateStore {
+	return &FileStateStore{Path: path}
+}
+
+// setRecoverUnit fills in a FileStateStore's RecoverUnit if it has none.
+func setRecoverUnit(store StateStore, unit TimeUnit) {
+	if fs, ok := store.(*FileStateStore); ok {
+		fs.mu.Lock()
+		if fs.RecoverUnit == "" {
+			fs.RecoverUnit = unit
+		}
+		fs.mu.Unlock()
+	}
+}
+
+func (s *FileStateStore) Load() (GenState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := lockFile(s.Path + ".lock")
+	if err != nil {
+		return GenState{}, err
+	}
+	defer unlock()
+	return s.loadLocked()
+}
+
+func (s *FileStateStore) CompareAndSwap(old, next GenState) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := lockFile(s.Path + ".lock")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	cur, err := s.loadLocked()
+	if err != nil || cur != old {
+		return false, err
+	}
+	if err := s.writeLocked(next); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// loadLocked reads the state, recovering from a damaged file when allowed.
+func (s *FileStateStore) loadLocked() (GenState, error) {
+	st, err := readStateFile(s.Path)
+	if os.IsNotExist(err) {
+		return InitialGenState, nil
+	}
+	if err == nil || !errors.Is(err, ErrStateCorrupt) {
+		return st, err
+	}
+	if s.RecoverUnit == "" {
+		return GenState{}, err
+	}
+	prev, perr := readStateFile(s.Path + ".prev")
+	if perr != nil {
+		return GenState{}, fmt.Errorf("%w; no usable %s.prev to recover from", err, s.Path)
+	}
+	fence := GenState{Tick: max(prev.Tick, nowTick(s.RecoverUnit)) + 1, Seq: -1}
+	if err := os.Rename(s.Path, s.Path+".corrupt"); err != nil {
+		return GenState{}, err
+	}
+	if err := s.writeLocked(fence); err != nil {
+		return GenState{}, err
+	}
+	return fence, nil
+}
+
+func (s *FileStateStore) writeLocked(st GenState) error {
+	dir := filepath.Dir(s.Path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp := s.Path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(encodeStateRecord(st))
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	// Keep the state being replaced; recovery fences off from it.
+	_ = os.Remove(s.Path + ".prev")
+	_ = os.Link(s.Path, s.Path+".prev")
+	if err := os.Rename(tmp, s.Path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	// Some platforms cannot fsync a directory; the rename is still atomic.
+	_ = d.Sync()
+	return nil
+}
+
+// encodeStateRecord renders "v1 <tick> <seq> <written unix ms> <crc32>".
+func encodeStateRecord(st GenState) string {
+	body := fmt.Sprintf("v1 %d %d %d", st.Tick, st.Seq, time.Now().UnixMilli())
+	return fmt.Sprintf("%s %08x\n", body, crc32.ChecksumIEEE([]byte(body)))
+}
+
+func readStateFile(path string) (GenState, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return GenState{}, err
+	}
+	f := strings.Fields(string(b))
+	corrupt := fmt.Errorf("%w: %s", ErrStateCorrupt, path)
+	switch {
+	case len(f) == 5 && f[0] == "v1":
+		body := strings.Join(f[:4], " ")
+		if fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(body))) != f[4] {
+			return GenState{}, corrupt
+		}
+		f = f[1:3]
+	case len(f) == 2:
+		// unchecksummed "<tick> <seq>" from earlier versions
+	default:
+		return GenState{}, corrupt
+	}
+	tick, err1 := strconv.ParseInt(f[0], 10, 64)
+	seq, err2 := strconv.Atoi(f[1])
+	if err1 != nil || err2 != nil {
+		return GenState{}, corrupt
+	}
+	return GenState{Tick: tick, Seq: seq}, nil
+}
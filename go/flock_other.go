@@ -0,0 +1,9 @@
+//go:build !unix
+
+package wid
+
+// lockFile is a no-op where flock is unavailable; FileStateStore then only
+// serializes writers within one process.
+func lockFile(string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,61 @@
+package wid
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrSequenceRange is returned by FormatWid and FormatHlcWid for a sequence
+// or logical counter that does not fit in W digits.
+var ErrSequenceRange = errors.New("sequence out of range for W")
+
+// FormatWid builds a WID from explicit components, for backfills that mint
+// IDs for historical records. ts is truncated to unit; padding must be empty
+// when z is 0 and otherwise exactly z lowercase hex characters. The same
+// inputs always give the same ID, and nothing is checked for uniqueness.
+func FormatWid(ts time.Time, seq int, padding string, w, z int, unit TimeUnit) (string, error) {
+	tick, err := formatArgs(ts, seq, padding, w, z, unit)
+	if err != nil {
+		return "", err
+	}
+	return formatID(tick, seq, w, unit, "", padding), nil
+}
+
+// FormatHlcWid is FormatWid for HLC-WIDs: lc is the logical counter and node
+// must satisfy IsValidNode.
+func FormatHlcWid(ts time.Time, lc int, node, padding string, w, z int, unit TimeUnit) (string, error) {
+	tick, err := formatArgs(ts, lc, padding, w, z, unit)
+	if err != nil {
+		return "", err
+	}
+	if !isValidNode(node) {
+		return "", ErrInvalidNode
+	}
+	return formatID(tick, lc, w, unit, node, padding), nil
+}
+
+// formatArgs validates the shared arguments and returns ts as a tick.
+func formatArgs(ts time.Time, seq int, padding string, w, z int, unit TimeUnit) (int64, error) {
+	if err := checkParams(w, z, unit); err != nil {
+		return 0, err
+	}
+	if seq < 0 || seq >= pow10(w) {
+		return 0, ErrSequenceRange
+	}
+	if y := ts.UTC().Year(); y < 0 || y > 9999 {
+		return 0, ErrInvalidTimestamp
+	}
+	if z == 0 && padding != "" {
+		return 0, ErrUnexpectedPadding
+	}
+	if len(padding) != z {
+		return 0, ErrPaddingLength
+	}
+	if !isLowerHexStr(padding) {
+		return 0, ErrPaddingCharset
+	}
+	if unit == TimeUnitMs {
+		return ts.UnixMilli(), nil
+	}
+	return ts.Unix(), nil
+}
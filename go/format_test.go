@@ -0,0 +1,43 @@
+package wid
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestFormatWidRoundTrip builds IDs from parts, parses them back and checks
+// the argument validation.
+func TestFormatWidRoundTrip(t *testing.T) {
+	ts := time.Date(2019, 3, 4, 5, 6, 7, 891_000_000, time.UTC)
+	id, err := FormatWid(ts, 42, "00ff", 4, 4, TimeUnitMs)
+	if err != nil || id != "20190304T050607891.0042Z-00ff" {
+		t.Fatalf("FormatWid = %q, %v", id, err)
+	}
+	if p, err := ParseWidWithUnit(id, 4, 4, TimeUnitMs); err != nil || !p.Timestamp.Equal(ts) || p.Sequence != 42 {
+		t.Fatalf("parse back = %+v, %v", p, err)
+	}
+	id, err = FormatHlcWid(ts, 7, "node1", "", 4, 0, TimeUnitSec)
+	if err != nil || id != "20190304T050607.0007Z-node1" {
+		t.Fatalf("FormatHlcWid = %q, %v", id, err)
+	}
+	for _, c := range []struct {
+		seq  int
+		pad  string
+		z    int
+		want error
+	}{
+		{10000, "", 0, ErrSequenceRange},
+		{-1, "", 0, ErrSequenceRange},
+		{1, "ab", 0, ErrUnexpectedPadding},
+		{1, "abc", 4, ErrPaddingLength},
+		{1, "ABCD", 4, ErrPaddingCharset},
+	} {
+		if _, err := FormatWid(ts, c.seq, c.pad, 4, c.z, TimeUnitSec); !errors.Is(err, c.want) {
+			t.Errorf("FormatWid(seq=%d, pad=%q, z=%d) = %v, want %v", c.seq, c.pad, c.z, err, c.want)
+		}
+	}
+	if _, err := FormatHlcWid(ts, 1, "a-b", "", 4, 0, TimeUnitSec); err != ErrInvalidNode {
+		t.Fatalf("bad node = %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package wid
+
+import "errors"
+
+// ErrNotComparable is returned by HappensBefore for IDs that are not HLC-WIDs
+// or that use different time units.
+var ErrNotComparable = errors.New("IDs are not comparable HLC-WIDs")
+
+// HLCTimestamp is a hybrid logical clock reading: physical time in the
+// generator's unit and the logical counter within it.
+type HLCTimestamp struct {
+	PT int64
+	LC int
+}
+
+// Before orders timestamps by physical time, then logical counter.
+func (t HLCTimestamp) Before(u HLCTimestamp) bool {
+	return t.PT < u.PT || (t.PT == u.PT && t.LC < u.LC)
+}
+
+// SendEvent records a local or send event and returns the HLC-WID to attach
+// to the outgoing message together with the event's timestamp. It is Next
+// with the clock reading the ID encodes.
+func (g *HLCWidGen) SendEvent() (string, HLCTimestamp) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	id := g.nextLocked()
+	return id, HLCTimestamp{PT: g.pt, LC: g.lc}
+}
+
+// ReceiveEvent records the receipt of a message stamped remote and returns
+// the receive event's timestamp: physical time is the maximum of the local
+// clock, the remote time and now, and the logical counter follows the HLC
+// paper's receive rule. The returned timestamp is strictly after remote.
+// remote's physical time is read in the generator's unit, so it should come
+// from a peer with the same time unit.
+func (g *HLCWidGen) ReceiveEvent(remote ParsedHlcWid) (HLCTimestamp, error) {
+	pt := remote.Timestamp.Unix()
+	if g.TimeUnit == TimeUnitMs {
+		pt = remote.Timestamp.UnixMilli()
+	}
+	if pt < 0 || remote.LogicalCounter < 0 {
+		return HLCTimestamp{}, ErrInvalidRemoteClock
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.observeLocked(pt, remote.LogicalCounter)
+	return HLCTimestamp{PT: g.pt, LC: g.lc}, nil
+}
+
+// HappensBefore reports whether HLC-WID a is ordered before b. HLC orders
+// are consistent with causality: if a's event happened before b's, the
+// result is true, and a false result proves it did not. A true result for
+// events on different nodes may still be concurrent in the causal sense.
+// Parameters are detected from each ID; both must be HLC-WIDs in the same
+// time unit, else ErrNotComparable.
+func HappensBefore(a, b string) (bool, error) {
+	ta, ua, err := hlcTimestampOf(a)
+	if err != nil {
+		return false, err
+	}
+	tb, ub, err := hlcTimestampOf(b)
+	if err != nil {
+		return false, err
+	}
+	if ua != ub {
+		return false, ErrNotComparable
+	}
+	return ta.Before(tb), nil
+}
+
+func hlcTimestampOf(id string) (HLCTimestamp, TimeUnit, error) {
+	h, d, err := parseAnyHlc(id)
+	if err == ErrInvalidFormat && d.Kind == "wid" {
+		return HLCTimestamp{}, "", ErrNotComparable
+	}
+	if err != nil {
+		return HLCTimestamp{}, "", err
+	}
+	pt := h.Timestamp.Unix()
+	if d.TimeUnit == TimeUnitMs {
+		pt = h.Timestamp.UnixMilli()
+	}
+	return HLCTimestamp{PT: pt, LC: h.LogicalCounter}, d.TimeUnit, nil
+}
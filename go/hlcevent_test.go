@@ -0,0 +1,40 @@
+package wid
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestSendReceiveCausality passes a message from a node whose clock runs
+// ahead and checks the receive event, and everything after it, is ordered
+// after the send.
+func TestSendReceiveCausality(t *testing.T) {
+	a, _ := NewHLCWidGenWithUnit("a", 4, 0, TimeUnitMs)
+	b, _ := NewHLCWidGenWithUnit("b", 4, 0, TimeUnitMs)
+	a.RestoreState(nowTick(TimeUnitMs)+5000, 3)
+	sent, sts := a.SendEvent()
+	if pt, lc := a.State(); sts != (HLCTimestamp{pt, lc}) {
+		t.Fatalf("SendEvent timestamp %+v, state %d,%d", sts, pt, lc)
+	}
+	msg, err := ParseHlcWidWithUnit(sent, 4, 0, TimeUnitMs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rts, err := b.ReceiveEvent(*msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sts.Before(rts) || rts != (HLCTimestamp{sts.PT, sts.LC + 1}) {
+		t.Fatalf("receive %+v not right after send %+v", rts, sts)
+	}
+	reply, _ := b.SendEvent()
+	if ok, err := HappensBefore(sent, reply); !ok || err != nil {
+		t.Fatalf("HappensBefore(%s, %s) = %v, %v", sent, reply, ok, err)
+	}
+	if ok, _ := HappensBefore(reply, sent); ok {
+		t.Fatal("the reply cannot precede the message it answers")
+	}
+	if _, err := HappensBefore(sent, "20261016T120000.0000Z"); !errors.Is(err, ErrNotComparable) {
+		t.Fatalf("plain WID = %v", err)
+	}
+}
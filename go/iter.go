@@ -0,0 +1,34 @@
+//go:build go1.23
+
+package wid
+
+import (
+	"context"
+	"iter"
+)
+
+// All returns an unbounded sequence of WIDs for range-over-func:
+//
+//	for id := range g.All(ctx) { ... }
+//
+// Each ID is issued only when the loop asks for it, and the sequence ends
+// when the loop breaks or ctx is done. Unlike NextN nothing is buffered, so
+// it suits consumers with no fixed count.
+func (g *WidGen) All(ctx context.Context) iter.Seq[string] {
+	return allIDs(ctx, g.Next)
+}
+
+// All is WidGen.All for HLC-WIDs.
+func (g *HLCWidGen) All(ctx context.Context) iter.Seq[string] {
+	return allIDs(ctx, g.Next)
+}
+
+func allIDs(ctx context.Context, next func() string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for ctx.Err() == nil {
+			if !yield(next()) {
+				return
+			}
+		}
+	}
+}
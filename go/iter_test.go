@@ -0,0 +1,42 @@
+//go:build go1.23
+
+package wid
+
+import (
+	"context"
+	"testing"
+)
+
+// TestAllStopsOnBreakAndCancel checks All issues increasing IDs until the
+// loop breaks, and ends once the context is cancelled.
+func TestAllStopsOnBreakAndCancel(t *testing.T) {
+	g, _ := NewWidGen(4, 0)
+	var ids []string
+	for id := range g.All(context.Background()) {
+		ids = append(ids, id)
+		if len(ids) == 5 {
+			break
+		}
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("ids not increasing: %s then %s", ids[i-1], ids[i])
+		}
+	}
+
+	h, _ := NewHLCWidGen("node01", 4, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n := 0
+	for id := range h.All(ctx) {
+		if !ValidateHlcWid(id, 4, 0) {
+			t.Fatalf("invalid HLC-WID %s", id)
+		}
+		if n++; n == 3 {
+			cancel()
+		}
+	}
+	if n != 3 {
+		t.Fatalf("got %d ids after cancel, want 3", n)
+	}
+}
@@ -0,0 +1,130 @@
+package wid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInsecureKeyURL = errors.New("key set URL must use https")
+	ErrKeyPinMismatch = errors.New("no key in the fetched key set matches a pin")
+)
+
+// DefaultJWKSTTL is how long a RemoteKeySet reuses a fetched key set.
+const DefaultJWKSTTL = 5 * time.Minute
+
+// maxJWKSBytes bounds a key set response.
+const maxJWKSBytes = 1 << 20
+
+// RemoteKeySet is a JSON Web Key Set fetched over HTTPS, so verifiers pick
+// up rotated keys without key files shipped by hand. The set is cached for
+// TTL, in memory and, with CacheFile, on disk for short-lived processes
+// such as the CLI. When a refresh fails, the last good set keeps serving.
+// Pins, when set, are the fingerprints (see PublicKeyFingerprint) of the
+// keys the verifier trusts: other keys in the response are dropped, so a
+// compromised key host cannot introduce keys of its own. Key IDs are chosen
+// by the host and are never matched against pins.
+type RemoteKeySet struct {
+	URL       string
+	TTL       time.Duration
+	Pins      []string
+	CacheFile string
+	Client    *http.Client
+
+	mu      sync.Mutex
+	ks      *KeySet
+	fetched time.Time
+}
+
+// KeySet returns the cached key set, fetching it when it is older than TTL.
+func (r *RemoteKeySet) KeySet(ctx context.Context) (*KeySet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ttl := r.TTL
+	if ttl <= 0 {
+		ttl = DefaultJWKSTTL
+	}
+	if r.ks != nil && time.Since(r.fetched) < ttl {
+		return r.ks, nil
+	}
+	if r.ks == nil && r.CacheFile != "" {
+		if fi, err := os.Stat(r.CacheFile); err == nil && time.Since(fi.ModTime()) < ttl {
+			if b, err := os.ReadFile(r.CacheFile); err == nil {
+				if ks, err := r.parse(b); err == nil {
+					r.ks, r.fetched = ks, fi.ModTime()
+					return ks, nil
+				}
+			}
+		}
+	}
+	b, err := r.fetch(ctx)
+	var ks *KeySet
+	if err == nil {
+		ks, err = r.parse(b)
+	}
+	if err != nil {
+		if r.ks != nil {
+			return r.ks, nil
+		}
+		return nil, err
+	}
+	r.ks, r.fetched = ks, time.Now()
+	if r.CacheFile != "" {
+		_ = os.MkdirAll(filepath.Dir(r.CacheFile), 0o700)
+		_ = os.WriteFile(r.CacheFile, b, 0o600)
+	}
+	return ks, nil
+}
+
+func (r *RemoteKeySet) fetch(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(r.URL, "https://") {
+		return nil, ErrInsecureKeyURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/jwk-set+json, application/json")
+	client := r.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching key set: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxJWKSBytes))
+}
+
+// parse reads a JWKS body and applies the pins.
+func (r *RemoteKeySet) parse(b []byte) (*KeySet, error) {
+	ks, err := ParseJWKS(b)
+	if err != nil || len(r.Pins) == 0 {
+		return ks, err
+	}
+	pinned := &KeySet{}
+	for _, e := range ks.entries {
+		for _, p := range r.Pins {
+			if p == PublicKeyFingerprint(e.key) {
+				pinned.Add(e.id, e.key)
+				break
+			}
+		}
+	}
+	if pinned.Len() == 0 {
+		return nil, ErrKeyPinMismatch
+	}
+	return pinned, nil
+}
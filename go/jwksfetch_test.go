@@ -0,0 +1,67 @@
+package wid
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// TestRemoteKeySetCachesAndPins serves a JWKS over TLS and checks caching,
+// the on-disk cache, stale fallback and pinning.
+func TestRemoteKeySetCachesAndPins(t *testing.T) {
+	pubA, _, _ := ed25519.GenerateKey(rand.Reader)
+	pubB, _, _ := ed25519.GenerateKey(rand.Reader)
+	body := `{"keys":[{"kty":"OKP","crv":"Ed25519","kid":"a","x":"` + base64.RawURLEncoding.EncodeToString(pubA) +
+		`"},{"kty":"OKP","crv":"Ed25519","kid":"b","x":"` + base64.RawURLEncoding.EncodeToString(pubB) + `"}]}`
+	var hits atomic.Int32
+	var down atomic.Bool
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		if down.Load() {
+			http.Error(w, "down", http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+	cache := filepath.Join(t.TempDir(), "jwks.json")
+
+	r := &RemoteKeySet{URL: srv.URL, Client: srv.Client(), CacheFile: cache}
+	if ks, err := r.KeySet(ctx); err != nil || ks.Len() != 2 {
+		t.Fatalf("first fetch: %v", err)
+	}
+	r.KeySet(ctx)
+	if hits.Load() != 1 {
+		t.Fatalf("cached key set refetched: %d hits", hits.Load())
+	}
+	fresh := &RemoteKeySet{URL: srv.URL, Client: srv.Client(), CacheFile: cache}
+	if _, err := fresh.KeySet(ctx); err != nil || hits.Load() != 1 {
+		t.Fatalf("disk cache not used: %v, %d hits", err, hits.Load())
+	}
+	down.Store(true)
+	r.TTL = 1
+	if ks, err := r.KeySet(ctx); err != nil || ks.Len() != 2 {
+		t.Fatalf("stale fallback: %v", err)
+	}
+
+	down.Store(false)
+	pinned := &RemoteKeySet{URL: srv.URL, Client: srv.Client(), Pins: []string{PublicKeyFingerprint(pubB)}}
+	if ks, err := pinned.KeySet(ctx); err != nil || ks.Len() != 1 || !ks.Candidates("")[0].(ed25519.PublicKey).Equal(pubB) {
+		t.Fatalf("pinned: %v", err)
+	}
+	wrong := &RemoteKeySet{URL: srv.URL, Client: srv.Client(), Pins: []string{"b"}}
+	if _, err := wrong.KeySet(ctx); !errors.Is(err, ErrKeyPinMismatch) {
+		t.Fatalf("kid as pin = %v", err)
+	}
+	if _, err := (&RemoteKeySet{URL: "http://example.com/jwks.json"}).KeySet(ctx); !errors.Is(err, ErrInsecureKeyURL) {
+		t.Fatalf("plain http = %v", err)
+	}
+}
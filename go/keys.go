@@ -0,0 +1,160 @@
+package wid
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+)
+
+// KeyAlgorithm names a signing key type supported by GenerateSigningKey.
+type KeyAlgorithm string
+
+const (
+	KeyEd25519 KeyAlgorithm = "ed25519"
+)
+
+var (
+	ErrUnsupportedKeyAlgorithm = errors.New("unsupported key algorithm")
+	ErrInvalidPrivateKeyPEM    = errors.New("failed to parse PEM private key")
+	ErrInvalidPublicKeyPEM     = errors.New("failed to parse PEM public key")
+	ErrNotEd25519PrivateKey    = errors.New("loaded key is not an Ed25519 private key")
+	ErrNotEd25519PublicKey     = errors.New("loaded key is not an Ed25519 public key")
+)
+
+// GenerateSigningKey creates a keypair for A=sign/A=verify and returns it as
+// PEM: the private key as PKCS#8 ("PRIVATE KEY") and the public key as PKIX
+// ("PUBLIC KEY"), the formats the CLI and the other implementations read.
+func GenerateSigningKey(algo KeyAlgorithm) (privPEM, pubPEM []byte, err error) {
+	if algo != KeyEd25519 {
+		return nil, nil, ErrUnsupportedKeyAlgorithm
+	}
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, nil, err
+	}
+	privPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER})
+	pubPEM = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+	return privPEM, pubPEM, nil
+}
+
+// ParsePrivateKeyPEM decodes a PKCS#8 PEM Ed25519 private key, or an
+// unencrypted OpenSSH one (see ParseOpenSSHPrivateKey).
+func ParsePrivateKeyPEM(b []byte) (ed25519.PrivateKey, error) {
+	blk, _ := pem.Decode(b)
+	if blk == nil {
+		return nil, ErrInvalidPrivateKeyPEM
+	}
+	if blk.Type == "OPENSSH PRIVATE KEY" {
+		return ParseOpenSSHPrivateKey(blk.Bytes)
+	}
+	keyAny, err := x509.ParsePKCS8PrivateKey(blk.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	pk, ok := keyAny.(ed25519.PrivateKey)
+	if !ok {
+		return nil, ErrNotEd25519PrivateKey
+	}
+	return pk, nil
+}
+
+// ParsePublicKeyPEM decodes a PKIX PEM Ed25519 public key.
+func ParsePublicKeyPEM(b []byte) (ed25519.PublicKey, error) {
+	blk, _ := pem.Decode(b)
+	if blk == nil {
+		return nil, ErrInvalidPublicKeyPEM
+	}
+	keyAny, err := x509.ParsePKIXPublicKey(blk.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	pk, ok := keyAny.(ed25519.PublicKey)
+	if !ok {
+		return nil, ErrNotEd25519PublicKey
+	}
+	return pk, nil
+}
+
+// WriteKeyPair writes PEM keys produced by GenerateSigningKey. The private key
+// is created with mode 0600 and never overwrites an existing file; the public
+// key is written with mode 0644.
+func WriteKeyPair(privPath, pubPath string, privPEM, pubPEM []byte) error {
+	f, err := os.OpenFile(privPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(privPEM); err != nil {
+		f.Close()
+		os.Remove(privPath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(privPath)
+		return err
+	}
+	return os.WriteFile(pubPath, pubPEM, 0o644)
+}
+
+// PublicKeyFingerprint returns the fingerprint ssh-keygen -l prints for pub:
+// "SHA256:" and the unpadded base64 SHA-256 of its SSH wire encoding, so the
+// same key reads the same whether it was loaded from PEM or OpenSSH form.
+// Keys of an unsupported type (see SignatureAlgorithm) have no fingerprint.
+func PublicKeyFingerprint(pub crypto.PublicKey) string {
+	var blob []byte
+	switch k := pub.(type) {
+	case ed25519.PublicKey:
+		blob = sshString(nil, []byte("ssh-ed25519"))
+		blob = sshString(blob, k)
+	case *ecdsa.PublicKey:
+		ek, err := k.ECDH()
+		if err != nil || k.Curve != elliptic.P256() {
+			return ""
+		}
+		blob = sshString(nil, []byte("ecdsa-sha2-nistp256"))
+		blob = sshString(blob, []byte("nistp256"))
+		blob = sshString(blob, ek.Bytes())
+	case *rsa.PublicKey:
+		blob = sshString(nil, []byte("ssh-rsa"))
+		blob = sshString(blob, sshMpint(big.NewInt(int64(k.E))))
+		blob = sshString(blob, sshMpint(k.N))
+	default:
+		return ""
+	}
+	sum := sha256.Sum256(blob)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// sshString appends v to b as a length-prefixed SSH wire string.
+func sshString(b, v []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(v)))
+	return append(b, v...)
+}
+
+// sshMpint is the SSH wire form of a non-negative integer: big-endian, with
+// a leading zero byte when the top bit is set.
+func sshMpint(n *big.Int) []byte {
+	b := n.Bytes()
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	}
+	return b
+}
@@ -0,0 +1,51 @@
+package wid
+
+import (
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGenerateSigningKeyRoundTrip checks generated PEM keys parse back and sign/verify together.
+func TestGenerateSigningKeyRoundTrip(t *testing.T) {
+	privPEM, pubPEM, err := GenerateSigningKey(KeyEd25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := ParsePrivateKeyPEM(privPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ParsePublicKeyPEM(pubPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("20260212T091530.0042Z-a3f91c")
+	if !ed25519.Verify(pub, msg, ed25519.Sign(priv, msg)) {
+		t.Fatal("generated keypair does not verify")
+	}
+	if _, err := ParsePrivateKeyPEM(pubPEM); err == nil {
+		t.Fatal("public key must not parse as private")
+	}
+	if _, _, err := GenerateSigningKey("rsa"); err != ErrUnsupportedKeyAlgorithm {
+		t.Fatalf("expected ErrUnsupportedKeyAlgorithm, got %v", err)
+	}
+}
+
+// TestWriteKeyPairPermissions checks the private key is 0600 and never overwritten.
+func TestWriteKeyPairPermissions(t *testing.T) {
+	dir := t.TempDir()
+	priv, pub := filepath.Join(dir, "wid.key"), filepath.Join(dir, "wid.pub")
+	privPEM, pubPEM, _ := GenerateSigningKey(KeyEd25519)
+	if err := WriteKeyPair(priv, pub, privPEM, pubPEM); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(priv)
+	if err != nil || fi.Mode().Perm() != 0o600 {
+		t.Fatalf("private key mode = %v, %v", fi.Mode().Perm(), err)
+	}
+	if err := WriteKeyPair(priv, pub, privPEM, pubPEM); err == nil {
+		t.Fatal("expected refusal to overwrite an existing private key")
+	}
+}
@@ -0,0 +1,213 @@
+package wid
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrEmptyKeySet   = errors.New("key set holds no supported public keys")
+	ErrInvalidKeySet = errors.New("invalid JSON key set")
+)
+
+// KeySet holds the public keys a verifier accepts, so signatures made before
+// and after a key rotation both verify. Each key has an ID; a signature may
+// carry an ID hint (the kid of an A=sign FORMAT=jws envelope) to pick the key
+// directly, otherwise every key is tried.
+type KeySet struct {
+	entries []keySetEntry
+}
+
+type keySetEntry struct {
+	id  string
+	key crypto.PublicKey
+}
+
+// Add puts key into the set under id, or under its PublicKeyFingerprint when
+// id is empty.
+func (ks *KeySet) Add(id string, key crypto.PublicKey) {
+	if id == "" {
+		id = PublicKeyFingerprint(key)
+	}
+	ks.entries = append(ks.entries, keySetEntry{id, key})
+}
+
+// Len is the number of keys in the set.
+func (ks *KeySet) Len() int { return len(ks.entries) }
+
+// Candidates returns the keys worth trying for a signature with the given ID
+// hint: those whose ID or fingerprint equals hint, or every key when hint is
+// empty or matches none.
+func (ks *KeySet) Candidates(hint string) []crypto.PublicKey {
+	var all, match []crypto.PublicKey
+	for _, e := range ks.entries {
+		all = append(all, e.key)
+		if hint != "" && (e.id == hint || PublicKeyFingerprint(e.key) == hint) {
+			match = append(match, e.key)
+		}
+	}
+	if len(match) > 0 {
+		return match
+	}
+	return all
+}
+
+// Verify reports whether sig is a signature of msg (see VerifyMessage) under
+// one of the Candidates for hint, and returns that key's ID.
+func (ks *KeySet) Verify(msg, sig []byte, hint string) (string, bool) {
+	for _, k := range ks.Candidates(hint) {
+		if VerifyMessage(k, msg, sig) {
+			return ks.idOf(k), true
+		}
+	}
+	return "", false
+}
+
+// Key returns the key stored under id, or nil.
+func (ks *KeySet) Key(id string) crypto.PublicKey {
+	for _, e := range ks.entries {
+		if e.id == id {
+			return e.key
+		}
+	}
+	return nil
+}
+
+func (ks *KeySet) idOf(k crypto.PublicKey) string {
+	for _, e := range ks.entries {
+		if e.key.(interface{ Equal(crypto.PublicKey) bool }).Equal(k) {
+			return e.id
+		}
+	}
+	return ""
+}
+
+// jwk is the subset of a JSON Web Key that holds an Ed25519 (RFC 8037 OKP),
+// ECDSA P-256 or RSA public key.
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Kid string `json:"kid"`
+}
+
+// publicKey decodes k, returning nil for keys a KeySet does not hold.
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	b64 := base64.RawURLEncoding
+	switch {
+	case k.Kty == "OKP" && k.Crv == "Ed25519":
+		x, err := b64.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, ErrInvalidKeySet
+		}
+		return ed25519.PublicKey(x), nil
+	case k.Kty == "EC" && k.Crv == "P-256":
+		x, errX := b64.DecodeString(k.X)
+		y, errY := b64.DecodeString(k.Y)
+		if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+			return nil, ErrInvalidKeySet
+		}
+		pt, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...))
+		if err != nil {
+			return nil, ErrInvalidKeySet
+		}
+		der, _ := x509.MarshalPKIXPublicKey(pt)
+		return x509.ParsePKIXPublicKey(der)
+	case k.Kty == "RSA":
+		n, errN := b64.DecodeString(k.N)
+		e, errE := b64.DecodeString(k.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			return nil, ErrInvalidKeySet
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if _, err := SignatureAlgorithm(pub); err != nil {
+			return nil, nil // too short for PS256
+		}
+		return pub, nil
+	}
+	return nil, nil
+}
+
+// ParseJWKS reads a JSON Web Key Set ({"keys": [...]}), keeping its Ed25519
+// ("kty": "OKP"), ECDSA P-256 ("kty": "EC") and RSA keys under their kid.
+func ParseJWKS(b []byte) (*KeySet, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, ErrInvalidKeySet
+	}
+	ks := &KeySet{}
+	for _, k := range set.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, err
+		}
+		if key != nil {
+			ks.Add(k.Kid, key)
+		}
+	}
+	if ks.Len() == 0 {
+		return nil, ErrEmptyKeySet
+	}
+	return ks, nil
+}
+
+// LoadKeySet reads a key set from path: a JSON Web Key Set file, or a
+// directory whose PEM and OpenSSH public key files each add one key, named
+// by the file name without its extension. Other files in the directory are
+// skipped.
+func LoadKeySet(path string) (*KeySet, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return ParseJWKS(b)
+	}
+	files, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	ks := &KeySet{}
+	for _, f := range files {
+		if !f.Type().IsRegular() {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(path, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var key crypto.PublicKey
+		if strings.HasPrefix(strings.TrimSpace(string(b)), "ssh-") {
+			key, err = ParseSSHPublicKey(b)
+		} else {
+			key, err = ParseVerifyingKeyPEM(b)
+		}
+		if err != nil {
+			continue
+		}
+		ks.Add(strings.TrimSuffix(f.Name(), filepath.Ext(f.Name())), key)
+	}
+	if ks.Len() == 0 {
+		return nil, ErrEmptyKeySet
+	}
+	return ks, nil
+}
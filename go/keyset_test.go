@@ -0,0 +1,71 @@
+package wid
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestKeySetAcrossRotation checks tokens signed by an old and a new key both
+// verify against a JWKS holding the two, and kid hints select the key.
+func TestKeySetAcrossRotation(t *testing.T) {
+	oldPub, oldPriv, _ := ed25519.GenerateKey(rand.Reader)
+	newPub, newPriv, _ := ed25519.GenerateKey(rand.Reader)
+	strayPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	jwks := `{"keys":[
+		{"kty":"OKP","crv":"Ed25519","kid":"2025","x":"` + base64.RawURLEncoding.EncodeToString(oldPub) + `"},
+		{"kty":"RSA","n":"AQAB","e":"AQAB"},
+		{"kty":"OKP","crv":"Ed25519","kid":"2026","x":"` + base64.RawURLEncoding.EncodeToString(newPub) + `"}]}`
+	ks, err := ParseJWKS([]byte(jwks))
+	if err != nil || ks.Len() != 2 {
+		t.Fatalf("ParseJWKS: %v, %d keys", err, ks.Len())
+	}
+	window := StrictOptions{Now: time.Date(2026, 2, 12, 9, 16, 0, 0, time.UTC)}
+	for _, priv := range []ed25519.PrivateKey{oldPriv, newPriv} {
+		tok, _ := SignToken("20260212T091530.0042Z-a3f91c", priv)
+		if _, err := VerifyTokenKeySet(tok, ks, window); err != nil {
+			t.Fatalf("rotated key rejected: %v", err)
+		}
+	}
+	msg := []byte("m")
+	if id, ok := ks.Verify(msg, ed25519.Sign(oldPriv, msg), "2024"); !ok || id != "2025" {
+		t.Fatalf("unmatched hint should fall back to all keys: %q %v", id, ok)
+	}
+	if got := ks.Candidates(PublicKeyFingerprint(newPub)); len(got) != 1 || !got[0].(ed25519.PublicKey).Equal(newPub) {
+		t.Fatalf("fingerprint hint = %d candidates", len(got))
+	}
+	if _, ok := ks.Verify(msg, ed25519.Sign(oldPriv, msg), "2026"); ok {
+		t.Fatal("a matching hint must restrict the keys tried")
+	}
+	other := &KeySet{}
+	other.Add("", strayPub)
+	tok, _ := SignToken("20260212T091530.0042Z-a3f91c", newPriv)
+	if _, err := VerifyTokenKeySet(tok, other, window); !errors.Is(err, ErrTokenSignature) {
+		t.Fatalf("foreign key set = %v", err)
+	}
+}
+
+// TestLoadKeySetDirectory checks a directory of public key files loads,
+// skipping files that are not keys.
+func TestLoadKeySetDirectory(t *testing.T) {
+	dir := t.TempDir()
+	_, pubPEM, _ := GenerateSigningKey(KeyEd25519)
+	os.WriteFile(filepath.Join(dir, "2026.pem"), pubPEM, 0o644)
+	os.WriteFile(filepath.Join(dir, "ssh.pub"), []byte(sshTestPub), 0o644)
+	os.WriteFile(filepath.Join(dir, "README"), []byte("rotated 2026-01"), 0o644)
+	ks, err := LoadKeySet(dir)
+	if err != nil || ks.Len() != 2 {
+		t.Fatalf("LoadKeySet: %v, %v", err, ks)
+	}
+	if got := ks.Candidates("ssh"); len(got) != 1 {
+		t.Fatalf("file-name hint = %d candidates", len(got))
+	}
+	if _, err := LoadKeySet(t.TempDir()); err != ErrEmptyKeySet {
+		t.Fatalf("empty dir = %v", err)
+	}
+}
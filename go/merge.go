@@ -0,0 +1,80 @@
+//go:build go1.23
+
+package wid
+
+import (
+	"container/heap"
+	"iter"
+)
+
+// MergeSorted k-way merges streams that are each already in SortIDs order,
+// such as the per-node HLC-WID exports of a cluster, into one stream in that
+// order. Because an HLC-WID never precedes an event it causally follows, the
+// merged stream of several nodes is a causally consistent total order. Only
+// one ID per stream is held at a time. An ID that cannot be parsed keeps the
+// position of the ID before it in its own stream.
+func MergeSorted(streams ...iter.Seq[string]) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		h := &mergeHeap{}
+		defer func() {
+			for _, c := range *h {
+				c.stop()
+			}
+		}()
+		for _, s := range streams {
+			next, stop := iter.Pull(s)
+			c := &mergeCursor{next: next, stop: stop}
+			if c.advance() {
+				*h = append(*h, c)
+			} else {
+				stop()
+			}
+		}
+		heap.Init(h)
+		for h.Len() > 0 {
+			c := (*h)[0]
+			if !yield(c.key.raw) {
+				return
+			}
+			if c.advance() {
+				heap.Fix(h, 0)
+			} else {
+				c.stop()
+				heap.Pop(h)
+			}
+		}
+	}
+}
+
+type mergeCursor struct {
+	next func() (string, bool)
+	stop func()
+	key  idKey
+}
+
+// advance reads the stream's next ID, reporting false at its end.
+func (c *mergeCursor) advance() bool {
+	id, ok := c.next()
+	if !ok {
+		return false
+	}
+	if k, err := keyOf(id); err == nil {
+		c.key = k
+	} else {
+		c.key.raw = id
+	}
+	return true
+}
+
+type mergeHeap []*mergeCursor
+
+func (h mergeHeap) Len() int           { return len(h) }
+func (h mergeHeap) Less(i, j int) bool { return h[i].key.compare(h[j].key) < 0 }
+func (h mergeHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x any)        { *h = append(*h, x.(*mergeCursor)) }
+func (h *mergeHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
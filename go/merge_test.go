@@ -0,0 +1,32 @@
+//go:build go1.23
+
+package wid
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestMergeSortedHlcStreams merges two per-node streams and checks the
+// result matches sorting everything at once, and that breaking early works.
+func TestMergeSortedHlcStreams(t *testing.T) {
+	a := []string{"20260212T091530.0000Z-node01", "20260212T091530.0002Z-node01", "20260212T091532.0000Z-node01"}
+	b := []string{"20260212T091530.0001Z-node02", "20260212T091530.0002Z-node02", "20260212T091531.0000Z-node02"}
+	got := slices.Collect(MergeSorted(slices.Values(a), slices.Values(b), slices.Values([]string(nil))))
+	want := slices.Concat(a, b)
+	if err := SortIDs(want); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	n := 0
+	for range MergeSorted(slices.Values(a), slices.Values(b)) {
+		if n++; n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Fatalf("break did not stop the merge: %d", n)
+	}
+}
@@ -0,0 +1,131 @@
+package wid
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+var (
+	ErrMetadataTooLong = errors.New("metadata does not fit the padding: at most Z/2-9 bytes")
+	ErrMetadataAuth    = errors.New("padding metadata failed authentication")
+)
+
+// metaTagLen is the authentication tag, and synthetic IV, length in bytes.
+const metaTagLen = 8
+
+// MetadataCapacity is how many metadata bytes a Z-digit padding holds: half
+// of Z, less the tag and a length byte, or 0 for an odd Z.
+func MetadataCapacity(z int) int {
+	if z%2 != 0 || z/2 <= metaTagLen {
+		return 0
+	}
+	return z/2 - metaTagLen - 1
+}
+
+// metaKeys derives the encryption and MAC keys from key.
+func metaKeys(key []byte) (enc, mac []byte) {
+	derive := func(label string) []byte {
+		h := hmac.New(sha256.New, key)
+		_, _ = h.Write([]byte("wid-meta-v1:" + label))
+		return h.Sum(nil)
+	}
+	return derive("enc"), derive("mac")
+}
+
+// sealMetadata encrypts meta into a z-digit padding, authenticating body
+// with it. The construction is SIV-like: the tag is HMAC-SHA256 over body
+// and the plaintext, and doubles as the AES-CTR IV, so equal inputs give
+// equal paddings and no nonce has to be stored or kept unique.
+func sealMetadata(key []byte, body string, meta []byte, z int) (string, error) {
+	n := MetadataCapacity(z)
+	if len(meta) > n {
+		return "", ErrMetadataTooLong
+	}
+	plain := make([]byte, n+1)
+	plain[0] = byte(len(meta))
+	copy(plain[1:], meta)
+	enc, mac := metaKeys(key)
+	tag := metaTag(mac, body, plain)
+	out := append(tag, metaXOR(enc, tag, plain)...)
+	return hex.EncodeToString(out), nil
+}
+
+// openMetadata reverses sealMetadata.
+func openMetadata(key []byte, body, padding string) ([]byte, error) {
+	raw, err := hex.DecodeString(padding)
+	if err != nil || len(raw) < metaTagLen+1 {
+		return nil, ErrMetadataAuth
+	}
+	enc, mac := metaKeys(key)
+	tag := raw[:metaTagLen]
+	plain := metaXOR(enc, tag, raw[metaTagLen:])
+	if !hmac.Equal(tag, metaTag(mac, body, plain)) || int(plain[0]) > len(plain)-1 {
+		return nil, ErrMetadataAuth
+	}
+	return plain[1 : 1+int(plain[0])], nil
+}
+
+func metaTag(mac []byte, body string, plain []byte) []byte {
+	h := hmac.New(sha256.New, mac)
+	_, _ = h.Write([]byte(body))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write(plain)
+	return h.Sum(nil)[:metaTagLen]
+}
+
+func metaXOR(enc, tag, in []byte) []byte {
+	block, _ := aes.NewCipher(enc)
+	iv := make([]byte, aes.BlockSize)
+	copy(iv, tag)
+	out := make([]byte, len(in))
+	cipher.NewCTR(block, iv).XORKeyStream(out, in)
+	return out
+}
+
+// NewMetadataWidGen returns a generator whose padding carries meta, such as
+// a tenant ID or a shard hint, encrypted and authenticated under key: only
+// key holders can read it (with ParseWithKey), and a changed timestamp,
+// sequence or padding fails authentication. meta may be up to
+// MetadataCapacity(z) bytes; Z must be even and above 16, and Z=64 holds
+// 23 bytes. The padding is derived rather than random, so the IDs are only as
+// unguessable as their timestamp and sequence.
+func NewMetadataWidGen(key, meta []byte, w, z int, unit TimeUnit) (*WidGen, error) {
+	g, err := NewWidGenWithUnit(w, z, unit)
+	if err != nil {
+		return nil, err
+	}
+	if z%2 != 0 || z/2 <= metaTagLen {
+		return nil, ErrInvalidZ
+	}
+	if len(meta) > MetadataCapacity(z) {
+		return nil, ErrMetadataTooLong
+	}
+	key, meta = append([]byte(nil), key...), append([]byte(nil), meta...)
+	g.padOf = func(body string) string {
+		pad, _ := sealMetadata(key, body, meta, z)
+		return pad
+	}
+	return g, nil
+}
+
+// ParseWithKey parses a NewMetadataWidGen ID and returns it with its
+// decrypted metadata, or ErrMetadataAuth when the padding does not open
+// under key for this timestamp and sequence.
+func ParseWithKey(id string, key []byte, w, z int, unit TimeUnit) (*ParsedWid, []byte, error) {
+	p, err := ParseWidWithUnit(id, w, z, unit)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.Padding == nil {
+		return nil, nil, ErrMetadataAuth
+	}
+	meta, err := openMetadata(key, id[:zIndex(id)+1], *p.Padding)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, meta, nil
+}
@@ -0,0 +1,45 @@
+package wid
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestMetadataPaddingRoundTrip seals metadata into generated IDs, reads it
+// back, and checks a wrong key or an altered ID fails authentication.
+func TestMetadataPaddingRoundTrip(t *testing.T) {
+	key := []byte("metadata-key")
+	meta := []byte("tenant-42|s7")
+	g, err := NewMetadataWidGen(key, meta, 4, 48, TimeUnitSec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := g.NextN(2)
+	if ids[0][len(ids[0])-48:] == ids[1][len(ids[1])-48:] {
+		t.Fatal("consecutive IDs share a padding")
+	}
+	for _, id := range ids {
+		p, got, err := ParseWithKey(id, key, 4, 48, TimeUnitSec)
+		if err != nil || !bytes.Equal(got, meta) || p.Padding == nil {
+			t.Fatalf("%s: %q, %v", id, got, err)
+		}
+		if _, _, err := ParseWithKey(id, []byte("other"), 4, 48, TimeUnitSec); !errors.Is(err, ErrMetadataAuth) {
+			t.Fatalf("wrong key = %v", err)
+		}
+		zi := zIndex(id)
+		moved := id[:zi-1] + string('0'+(id[zi-1]-'0'+1)%10) + id[zi:]
+		if _, _, err := ParseWithKey(moved, key, 4, 48, TimeUnitSec); !errors.Is(err, ErrMetadataAuth) {
+			t.Fatalf("altered sequence = %v", err)
+		}
+	}
+	if MetadataCapacity(64) != 23 {
+		t.Fatalf("MetadataCapacity(64) = %d", MetadataCapacity(64))
+	}
+	if _, err := NewMetadataWidGen(key, make([]byte, 16), 4, 48, TimeUnitSec); !errors.Is(err, ErrMetadataTooLong) {
+		t.Fatalf("oversized metadata = %v", err)
+	}
+	if _, err := NewMetadataWidGen(key, nil, 4, 15, TimeUnitSec); !errors.Is(err, ErrInvalidZ) {
+		t.Fatalf("odd Z = %v", err)
+	}
+}
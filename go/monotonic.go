@@ -0,0 +1,100 @@
+package wid
+
+import "sync"
+
+// MonotonicStats are the counters kept by a MonotonicChecker.
+type MonotonicStats struct {
+	Checked    uint64
+	OutOfOrder uint64
+	Duplicates uint64
+}
+
+// MonotonicChecker is a correctness canary: it verifies that every ID it sees
+// sorts strictly after the previous one and has not been seen among the last
+// Window IDs. It can wrap a Generator (and is itself one), or be fed IDs from
+// any other source through Check. Violations are counted, never fatal.
+type MonotonicChecker struct {
+	src    Generator
+	window int
+
+	mu    sync.Mutex
+	last  string
+	ring  []string
+	next  int
+	seen  map[string]int
+	stats MonotonicStats
+}
+
+var _ Generator = (*MonotonicChecker)(nil)
+
+// NewMonotonicChecker wraps src (which may be nil when only Check is used) and
+// remembers the last window IDs for duplicate detection; window < 1 means 1.
+func NewMonotonicChecker(src Generator, window int) *MonotonicChecker {
+	if window < 1 {
+		window = 1
+	}
+	return &MonotonicChecker{src: src, window: window, ring: make([]string, 0, window), seen: map[string]int{}}
+}
+
+// Next draws an ID from the wrapped generator and checks it. Both happen
+// under one lock, so concurrent callers cannot check their IDs in a
+// different order than they were drawn.
+func (c *MonotonicChecker) Next() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := c.src.Next()
+	c.checkLocked(id)
+	return id
+}
+
+// NextN draws n IDs from the wrapped generator and checks each in order.
+func (c *MonotonicChecker) NextN(n int) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ids := c.src.NextN(n)
+	for _, id := range ids {
+		c.checkLocked(id)
+	}
+	return ids
+}
+
+// Check records id and reports whether it was both in order and unique within
+// the window.
+func (c *MonotonicChecker) Check(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.checkLocked(id)
+}
+
+func (c *MonotonicChecker) checkLocked(id string) bool {
+	c.stats.Checked++
+	ok := true
+	if c.seen[id] > 0 {
+		c.stats.Duplicates++
+		ok = false
+	}
+	if c.last != "" && id <= c.last {
+		c.stats.OutOfOrder++
+		ok = false
+	}
+	c.last = id
+	if len(c.ring) < c.window {
+		c.ring = append(c.ring, id)
+	} else {
+		old := c.ring[c.next]
+		if c.seen[old]--; c.seen[old] <= 0 {
+			delete(c.seen, old)
+		}
+		c.ring[c.next] = id
+		c.next = (c.next + 1) % c.window
+	}
+	c.seen[id]++
+	return ok
+}
+
+// Stats returns a snapshot of the violation counters.
+func (c *MonotonicChecker) Stats() MonotonicStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
@@ -0,0 +1,88 @@
+package wid
+
+import (
+	"errors"
+	"strings"
+)
+
+// NamespaceSeparator joins a WID and the namespace of its issuing domain:
+// `<wid>_<namespace>`. '_' is outside the WID alphabet, so the base ID is
+// recovered unambiguously, and a trailing suffix keeps IDs time-sorted.
+const NamespaceSeparator = "_"
+
+// MaxNamespaceLen bounds a namespace's length.
+const MaxNamespaceLen = 32
+
+// ErrInvalidNamespace is returned for a namespace that is empty, too long or
+// not lowercase letters and digits.
+var ErrInvalidNamespace = errors.New("namespace must be 1-32 lowercase letters or digits")
+
+// ValidNamespace reports whether ns can be used as a WID namespace.
+func ValidNamespace(ns string) bool {
+	if ns == "" || len(ns) > MaxNamespaceLen {
+		return false
+	}
+	for i := 0; i < len(ns); i++ {
+		if c := ns[i]; (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// NewWidGenWithNamespace returns a generator whose IDs end in
+// "_<namespace>", so multi-product deployments can tell the issuing domain
+// from the ID. The suffix is an extension outside the WID spec: the core
+// parsers reject it, and ParseNamespacedWid reads it.
+func NewWidGenWithNamespace(namespace string, w, z int, unit TimeUnit) (*WidGen, error) {
+	if !ValidNamespace(namespace) {
+		return nil, ErrInvalidNamespace
+	}
+	g, err := NewWidGenWithUnit(w, z, unit)
+	if err != nil {
+		return nil, err
+	}
+	g.nsSuffix = NamespaceSeparator + namespace
+	return g, nil
+}
+
+// splitNamespace separates a trailing "_<namespace>" from id. ok is false
+// when a separator is present but the namespace is invalid.
+func splitNamespace(id string) (base, ns string, ok bool) {
+	i := strings.LastIndexByte(id, NamespaceSeparator[0])
+	if i < 0 {
+		return id, "", true
+	}
+	ns = id[i+1:]
+	if !ValidNamespace(ns) {
+		return "", "", false
+	}
+	return id[:i], ns, true
+}
+
+// ParseNamespacedWid parses a WID that may end in "_<namespace>": the suffix
+// is stripped, the rest parsed with ParseWidWithUnit, and the namespace
+// reported as ParsedWid.Namespace. Raw keeps the full ID.
+func ParseNamespacedWid(id string, w, z int, unit TimeUnit) (*ParsedWid, error) {
+	base, ns, ok := splitNamespace(id)
+	if !ok {
+		return nil, ErrInvalidFormat
+	}
+	p, err := ParseWidWithUnit(base, w, z, unit)
+	if err != nil {
+		return nil, err
+	}
+	p.Raw, p.Namespace = id, ns
+	return p, nil
+}
+
+// stripNamespace removes a "_<namespace>" suffix when what precedes it reads
+// as a WID; in an HLC-WID a '_' belongs to the node and id is kept whole.
+func stripNamespace(id string) (base, ns string) {
+	if b, n, ok := splitNamespace(id); ok && n != "" {
+		if d, err := DetectParams(b); err == nil && d.Kind == "wid" {
+			return b, n
+		}
+	}
+	return id, ""
+}
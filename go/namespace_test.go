@@ -0,0 +1,38 @@
+package wid
+
+import "testing"
+
+// TestNamespaceGenerateAndParse checks namespaced IDs parse with
+// ParseNamespacedWid only, the core parsers staying on the spec, and that bad
+// namespaces are refused.
+func TestNamespaceGenerateAndParse(t *testing.T) {
+	g, err := NewWidGenWithNamespace("billing", 4, 6, TimeUnitSec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := g.Next(), g.Next()
+	if a >= b {
+		t.Fatalf("not increasing: %s then %s", a, b)
+	}
+	p, err := ParseNamespacedWid(a, 4, 6, TimeUnitSec)
+	if err != nil || p.Namespace != "billing" || p.Raw != a || p.Padding == nil {
+		t.Fatalf("ParseNamespacedWid(%s) = %+v, %v", a, p, err)
+	}
+	if p, err := ParseNamespacedWid(a[:len(a)-len("_billing")], 4, 6, TimeUnitSec); err != nil || p.Namespace != "" {
+		t.Fatalf("plain ID = %+v, %v", p, err)
+	}
+	for _, parse := range []func(string, int, int, TimeUnit) (*ParsedWid, error){ParseWidWithUnit, ParseWidFast} {
+		if _, err := parse(a, 4, 6, TimeUnitSec); err != ErrInvalidFormat {
+			t.Fatalf("core parser accepted %s: %v", a, err)
+		}
+	}
+	if _, err := ParseNamespacedWid("20261016T120000.0001Z_Bad", 4, 0, TimeUnitSec); err != ErrInvalidFormat {
+		t.Fatalf("upper-case namespace = %v", err)
+	}
+	if _, err := NewWidGenWithNamespace("", 4, 6, TimeUnitSec); err != ErrInvalidNamespace {
+		t.Fatalf("empty namespace = %v", err)
+	}
+	if _, err := EncodeBinary(a); err == nil {
+		t.Fatalf("EncodeBinary of a namespaced ID = %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package wid
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrTimeBeforeState is returned by NextAt for a time earlier than the last
+// tick the generator issued.
+var ErrTimeBeforeState = errors.New("time is before the generator's last issued tick")
+
+// NextAt issues the next ID for the tick containing t instead of now, so a
+// job can stamp records with their event time. Monotonicity still holds: t
+// may not fall before the last issued tick (ErrTimeBeforeState), and a full
+// tick yields ErrSequenceExhausted rather than borrowing the next one, since
+// that would change the stamped time. Feed events in time order; for
+// unordered history use FormatWid. NextAt does not count towards clock
+// regression statistics.
+func (g *WidGen) NextAt(t time.Time) (string, error) {
+	tick := t.Unix() - g.epoch
+	if g.TimeUnit == TimeUnitMs {
+		tick = t.UnixMilli() - g.epoch
+	}
+	if err := checkTick(tick, g.W, g.Z, g.TimeUnit); err != nil {
+		return "", err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if tick < g.lastTick {
+		return "", ErrTimeBeforeState
+	}
+	seq := 0
+	if tick == g.lastTick {
+		seq = g.lastSeq + 1
+	}
+	if seq > g.maxSeq {
+		g.stats.Rollovers++
+		return "", ErrSequenceExhausted
+	}
+	g.lastTick = tick
+	g.lastSeq = seq
+	g.stats.note(seq)
+	return g.format(tick, seq, g.padding()), nil
+}
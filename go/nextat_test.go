@@ -0,0 +1,32 @@
+package wid
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestNextAtStampsEventTime checks IDs carry the supplied time, count up
+// within a tick and refuse to go back or overflow.
+func TestNextAtStampsEventTime(t *testing.T) {
+	g, _ := NewWidGenWithUnit(1, 0, TimeUnitSec)
+	ev := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		id, err := g.NextAt(ev.Add(300 * time.Millisecond))
+		if want := "20210601T120000." + string(rune('0'+i)) + "Z"; id != want || err != nil {
+			t.Fatalf("NextAt #%d = %q, %v; want %s", i, id, err, want)
+		}
+	}
+	if _, err := g.NextAt(ev); !errors.Is(err, ErrSequenceExhausted) {
+		t.Fatalf("full tick = %v", err)
+	}
+	if id, err := g.NextAt(ev.Add(time.Second)); id != "20210601T120001.0Z" || err != nil {
+		t.Fatalf("next tick = %q, %v", id, err)
+	}
+	if _, err := g.NextAt(ev); !errors.Is(err, ErrTimeBeforeState) {
+		t.Fatalf("earlier time = %v", err)
+	}
+	if id := g.Next(); id <= "20210601T120001.0Z" {
+		t.Fatalf("Next after NextAt went back: %s", id)
+	}
+}
@@ -0,0 +1,106 @@
+package wid
+
+import (
+	"errors"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ErrNoNode is returned when no source yields a usable node name.
+var ErrNoNode = errors.New("could not derive a node name")
+
+// DefaultNodeEnv is the environment DeriveNodeFromEnv reads when given no
+// variable names: an explicit WID_NODE, then the Kubernetes downward-API
+// names, then HOSTNAME.
+var DefaultNodeEnv = []string{"WID_NODE", "NODE_NAME", "POD_NAME", "HOSTNAME"}
+
+var containerIDRe = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// SanitizeNode turns an arbitrary host, pod or container name into a valid
+// node name: lowercase, with every run of characters outside [a-z0-9_]
+// folded into one '_' and leading and trailing '_' dropped. A result made
+// only of hex digits gets an "n" prefix, so it is not mistaken for padding
+// when Z is 0 (see DetectParams). It returns "" when nothing usable is left.
+func SanitizeNode(s string) string {
+	var b strings.Builder
+	sep := false
+	for _, c := range strings.ToLower(s) {
+		if c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' {
+			if sep && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			sep = false
+			b.WriteRune(c)
+			continue
+		}
+		sep = true
+	}
+	node := strings.Trim(b.String(), "_")
+	if node != "" && isLowerHexStr(node) {
+		node = "n" + node
+	}
+	return node
+}
+
+// DeriveNodeFromEnv returns the sanitized value of the first of vars that is
+// set and non-empty, or of DefaultNodeEnv when vars is empty.
+func DeriveNodeFromEnv(vars ...string) (string, error) {
+	if len(vars) == 0 {
+		vars = DefaultNodeEnv
+	}
+	for _, v := range vars {
+		if node := SanitizeNode(os.Getenv(v)); node != "" {
+			return node, nil
+		}
+	}
+	return "", ErrNoNode
+}
+
+// DeriveNode picks a node name for this process without configuration: the
+// hostname (a pod name under Kubernetes), else the container ID found in
+// /proc, shortened to 12 characters as docker prints it, else the first
+// hardware address of a non-loopback interface. The result always passes
+// IsValidNode; nodes in one cluster should still be checked for uniqueness.
+func DeriveNode() (string, error) {
+	if h, err := os.Hostname(); err == nil {
+		if node := SanitizeNode(h); node != "" {
+			return node, nil
+		}
+	}
+	if id := containerID(); id != "" {
+		return "ctr" + id[:12], nil
+	}
+	if mac := firstMAC(); mac != "" {
+		return "mac" + mac, nil
+	}
+	return "", ErrNoNode
+}
+
+// containerID finds a docker/containerd ID in the cgroup or mount tables.
+func containerID() string {
+	for _, path := range []string{"/proc/self/cgroup", "/proc/self/mountinfo"} {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if id := containerIDRe.Find(b); id != nil {
+			return string(id)
+		}
+	}
+	return ""
+}
+
+func firstMAC() string {
+	ifs, err := net.Interfaces()
+	if err != nil {
+		return ""
+	}
+	for _, i := range ifs {
+		if i.Flags&net.FlagLoopback == 0 && len(i.HardwareAddr) > 0 {
+			return strings.ReplaceAll(i.HardwareAddr.String(), ":", "")
+		}
+	}
+	return ""
+}
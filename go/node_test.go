@@ -0,0 +1,43 @@
+package wid
+
+import "testing"
+
+// TestSanitizeNode checks typical host, pod and container names come out
+// valid and unambiguous.
+func TestSanitizeNode(t *testing.T) {
+	cases := map[string]string{
+		"web-7d9f8-x2lqp":         "web_7d9f8_x2lqp",
+		"Build Agent.local":       "build_agent_local",
+		"--api--":                 "api",
+		"0242ac110002":            "n0242ac110002",
+		"node_01":                 "node_01",
+		"  \t-":                   "",
+		"ip-10-0-1-12.ec2.intern": "ip_10_0_1_12_ec2_intern",
+	}
+	for in, want := range cases {
+		got := SanitizeNode(in)
+		if got != want {
+			t.Errorf("SanitizeNode(%q) = %q, want %q", in, got, want)
+		}
+		if got != "" && !IsValidNode(got) {
+			t.Errorf("SanitizeNode(%q) = %q is not a valid node", in, got)
+		}
+	}
+}
+
+// TestDeriveNodeFromEnv checks the first set variable wins and an empty
+// environment reports ErrNoNode.
+func TestDeriveNodeFromEnv(t *testing.T) {
+	t.Setenv("WID_TEST_A", "")
+	t.Setenv("WID_TEST_B", "orders-api-5f6c")
+	if node, err := DeriveNodeFromEnv("WID_TEST_A", "WID_TEST_B"); err != nil || node != "orders_api_5f6c" {
+		t.Fatalf("got %q, %v", node, err)
+	}
+	if _, err := DeriveNodeFromEnv("WID_TEST_A"); err != ErrNoNode {
+		t.Fatalf("empty env = %v, want ErrNoNode", err)
+	}
+	node, err := DeriveNode()
+	if err == nil && !IsValidNode(node) {
+		t.Fatalf("DeriveNode() = %q is not valid", node)
+	}
+}
@@ -0,0 +1,61 @@
+package wid
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NodeEscape starts an escape in an escaped node name (node format v2).
+//
+// Node format v1 is the node as written: anything without hyphens or
+// whitespace. Format v2 lets names that v1 rejects, such as hyphenated
+// Kubernetes pod names, be used by percent-encoding every '-', '%' and
+// whitespace byte as '%' plus two lowercase hex digits, so
+// "orders-api-5f6c" is written "orders%2dapi%2d5f6c". The result is a valid
+// v1 node, so every existing parser and validator accepts it unchanged and
+// the '-' separators of the HLC-WID stay unambiguous; UnescapeNode recovers
+// the name. Names v1 already accepts and that contain no '%' are the same in
+// both formats. Issuers and readers agree on v2 out of band, by setting
+// Config.EscapeNodes on both sides.
+const NodeEscape = '%'
+
+// EscapeNode returns name in node format v2, or ErrInvalidNode for an empty
+// name.
+func EscapeNode(name string) (string, error) {
+	if name == "" {
+		return "", ErrInvalidNode
+	}
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; c {
+		case '-', NodeEscape, ' ', '\t', '\n', '\r':
+			fmt.Fprintf(&b, "%c%02x", NodeEscape, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+// UnescapeNode decodes a node written by EscapeNode, returning
+// ErrInvalidNode for a truncated or non-hex escape.
+func UnescapeNode(node string) (string, error) {
+	if strings.IndexByte(node, NodeEscape) < 0 {
+		return node, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(node); i++ {
+		if node[i] != NodeEscape {
+			b.WriteByte(node[i])
+			continue
+		}
+		if i+2 >= len(node) || !isLowerHexStr(node[i+1:i+3]) {
+			return "", ErrInvalidNode
+		}
+		v, _ := strconv.ParseUint(node[i+1:i+3], 16, 8)
+		b.WriteByte(byte(v))
+		i += 2
+	}
+	return b.String(), nil
+}
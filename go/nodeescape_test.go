@@ -0,0 +1,46 @@
+package wid
+
+import "testing"
+
+// TestEscapeNodeRoundTrip checks escaped names are valid v1 nodes and decode
+// back, including names that already look escaped.
+func TestEscapeNodeRoundTrip(t *testing.T) {
+	for _, name := range []string{"orders-api-5f6c7d-x2lqp", "node01", "100%", "a%2db", "with space"} {
+		node, err := EscapeNode(name)
+		if err != nil || !IsValidNode(node) {
+			t.Fatalf("EscapeNode(%q) = %q, %v", name, node, err)
+		}
+		if back, err := UnescapeNode(node); err != nil || back != name {
+			t.Fatalf("UnescapeNode(%q) = %q, %v, want %q", node, back, err, name)
+		}
+	}
+	if got, _ := EscapeNode("orders-api"); got != "orders%2dapi" {
+		t.Fatalf("EscapeNode = %q", got)
+	}
+	for _, bad := range []string{"a%2", "a%zz", "a%2D"} {
+		if _, err := UnescapeNode(bad); err != ErrInvalidNode {
+			t.Fatalf("UnescapeNode(%q) = %v, want ErrInvalidNode", bad, err)
+		}
+	}
+}
+
+// TestConfigEscapeNodes checks a hyphenated pod name survives a Config
+// generator and parser pair.
+func TestConfigEscapeNodes(t *testing.T) {
+	c := Config{W: 4, Z: 6, EscapeNodes: true}
+	g, err := c.NewHLCWidGen("orders-api-5f6c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := g.Next()
+	if !ValidateHlcWid(id, 4, 6) {
+		t.Fatalf("escaped HLC-WID %s does not validate", id)
+	}
+	p, err := c.ParseHlcWid(id)
+	if err != nil || p.Node != "orders-api-5f6c" {
+		t.Fatalf("ParseHlcWid(%s) = %+v, %v", id, p, err)
+	}
+	if _, err := (Config{W: 4}).NewHLCWidGen("orders-api"); err != ErrInvalidNode {
+		t.Fatalf("unescaped hyphen = %v, want ErrInvalidNode", err)
+	}
+}
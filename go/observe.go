@@ -0,0 +1,51 @@
+package wid
+
+import "time"
+
+// ObserveTime merges a peer's wall-clock reading into the hybrid clock. A
+// bare timestamp carries no logical counter, so it is observed as counter 0
+// at t truncated to the generator's unit.
+func (g *HLCWidGen) ObserveTime(t time.Time) error {
+	if g.TimeUnit == TimeUnitMs {
+		return g.ObserveUnixMilli(t.UnixMilli())
+	}
+	return g.ObserveUnix(t.Unix())
+}
+
+// ObserveUnix observes a peer timestamp given in Unix seconds.
+func (g *HLCWidGen) ObserveUnix(sec int64) error {
+	if sec < 0 {
+		return ErrInvalidRemoteClock
+	}
+	if g.TimeUnit == TimeUnitMs {
+		return g.Observe(sec*1000-g.epoch, 0)
+	}
+	return g.Observe(sec-g.epoch, 0)
+}
+
+// ObserveUnixMilli observes a peer timestamp given in Unix milliseconds; in
+// sec mode it is floored to the containing second.
+func (g *HLCWidGen) ObserveUnixMilli(ms int64) error {
+	if ms < 0 {
+		return ErrInvalidRemoteClock
+	}
+	if g.TimeUnit == TimeUnitMs {
+		return g.Observe(ms-g.epoch, 0)
+	}
+	return g.Observe(ms/1000-g.epoch, 0)
+}
+
+// ObserveWid merges a received HLC-WID into the clock: its physical time and
+// logical counter are observed exactly as Observe would take them. The ID is
+// parsed with the generator's own W, Z and time unit, so a peer must share
+// them; a mismatching ID fails with the parser's error.
+func (g *HLCWidGen) ObserveWid(id string) error {
+	p, err := ParseHlcWidFast(id, g.W, g.Z, g.TimeUnit)
+	if err != nil {
+		return err
+	}
+	if g.TimeUnit == TimeUnitMs {
+		return g.Observe(p.Timestamp.UnixMilli(), p.LogicalCounter)
+	}
+	return g.Observe(p.Timestamp.Unix(), p.LogicalCounter)
+}
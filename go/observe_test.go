@@ -0,0 +1,59 @@
+package wid
+
+import (
+	"testing"
+	"time"
+)
+
+// TestObserveTimeAdvancesClock checks wall-clock observations from the future pull the clock forward per unit.
+func TestObserveTimeAdvancesClock(t *testing.T) {
+	future := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	g, _ := NewHLCWidGenWithUnit("n1", 4, 0, TimeUnitMs)
+	if err := g.ObserveTime(future); err != nil {
+		t.Fatal(err)
+	}
+	if pt, lc := g.State(); pt != future.UnixMilli() || lc != 1 {
+		t.Fatalf("ms state = %d,%d", pt, lc)
+	}
+	s, _ := NewHLCWidGenWithUnit("n1", 4, 0, TimeUnitSec)
+	if err := s.ObserveUnixMilli(future.UnixMilli()); err != nil {
+		t.Fatal(err)
+	}
+	if pt, _ := s.State(); pt != future.Unix() {
+		t.Fatalf("sec pt = %d, want %d", pt, future.Unix())
+	}
+	if err := s.ObserveUnix(future.Unix() - 10); err != nil {
+		t.Fatal(err)
+	}
+	if pt, _ := s.State(); pt != future.Unix() {
+		t.Fatal("an older observation must not move the clock back")
+	}
+	if s.ObserveUnix(-1) != ErrInvalidRemoteClock {
+		t.Fatal("negative timestamps must be rejected")
+	}
+}
+
+// TestObserveWid checks a peer's HLC-WID is merged with its logical counter
+// in both units, and that IDs of another shape are rejected.
+func TestObserveWid(t *testing.T) {
+	for _, unit := range []TimeUnit{TimeUnitSec, TimeUnitMs} {
+		peer, _ := NewHLCWidGenWithUnit("peer", 4, 0, unit)
+		peer.RestoreState(nowTick(unit)+100, 7)
+		id := peer.Next()
+		pt, lc := peer.State()
+		g, _ := NewHLCWidGenWithUnit("local", 4, 0, unit)
+		if err := g.ObserveWid(id); err != nil {
+			t.Fatal(err)
+		}
+		if gpt, glc := g.State(); gpt != pt || glc != lc+1 {
+			t.Fatalf("%s: state after ObserveWid(%s) = %d,%d, want %d,%d", unit, id, gpt, glc, pt, lc+1)
+		}
+		if next := g.Next(); next <= id[:len(id)-len("-peer")] {
+			t.Fatalf("%s: %s does not sort after observed %s", unit, next, id)
+		}
+	}
+	g, _ := NewHLCWidGen("local", 4, 0)
+	if err := g.ObserveWid("20261016T120000123.0001Z-peer"); err == nil {
+		t.Fatal("an ms ID must not be accepted by a sec generator")
+	}
+}
@@ -0,0 +1,79 @@
+//go:build go1.23
+
+package wid
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+)
+
+// LineError is a line ParseStream could not parse, with its 1-based number.
+type LineError struct {
+	Line int
+	Text string
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %q: %v", e.Line, e.Text, e.Err)
+}
+
+func (e *LineError) Unwrap() error { return e.Err }
+
+// StreamOptions says what ParseStreamWith does with malformed lines. By
+// default each one is yielded as (nil, *LineError) and parsing continues.
+type StreamOptions struct {
+	// SkipMalformed drops malformed lines without yielding them.
+	SkipMalformed bool
+	// Malformed, when set, collects malformed lines instead of yielding them.
+	Malformed *[]*LineError
+}
+
+// ParseStream reads newline-delimited WIDs from r and yields each parsed ID
+// as it is read, so arbitrarily large inputs are processed in constant
+// memory. Blank lines are ignored and surrounding whitespace is trimmed.
+// Malformed lines yield (nil, *LineError); a read error is yielded last.
+func ParseStream(r io.Reader, w, z int, unit TimeUnit) iter.Seq2[*ParsedWid, error] {
+	return ParseStreamWith(r, w, z, unit, StreamOptions{})
+}
+
+// ParseStreamWith is ParseStream with control over malformed lines.
+func ParseStreamWith(r io.Reader, w, z int, unit TimeUnit, opts StreamOptions) iter.Seq2[*ParsedWid, error] {
+	return func(yield func(*ParsedWid, error) bool) {
+		if err := checkParams(w, z, unit); err != nil {
+			yield(nil, err)
+			return
+		}
+		sc := bufio.NewScanner(r)
+		for n := 1; sc.Scan(); n++ {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" {
+				continue
+			}
+			p, err := ParseWidFast(line, w, z, unit)
+			if err != nil {
+				le := &LineError{Line: n, Text: line, Err: err}
+				switch {
+				case opts.Malformed != nil:
+					*opts.Malformed = append(*opts.Malformed, le)
+					continue
+				case opts.SkipMalformed:
+					continue
+				}
+				if !yield(nil, le) {
+					return
+				}
+				continue
+			}
+			if !yield(p, nil) {
+				return
+			}
+		}
+		if err := sc.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+//go:build go1.23
+
+package wid
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const streamInput = "20260212T091530.0000Z\n\nnot-a-wid\r\n  20260212T091530.0001Z  \n20260212T091531.0000Z\n"
+
+// TestParseStreamYieldsMalformed checks good lines parse in order and a bad
+// one is reported with its line number.
+func TestParseStreamYieldsMalformed(t *testing.T) {
+	var seqs []int
+	var bad []*LineError
+	for p, err := range ParseStream(strings.NewReader(streamInput), 4, 0, TimeUnitSec) {
+		var le *LineError
+		if errors.As(err, &le) {
+			bad = append(bad, le)
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		seqs = append(seqs, p.Sequence)
+	}
+	if len(seqs) != 3 || seqs[1] != 1 {
+		t.Fatalf("sequences = %v", seqs)
+	}
+	if len(bad) != 1 || bad[0].Line != 3 || bad[0].Text != "not-a-wid" {
+		t.Fatalf("malformed = %+v", bad)
+	}
+}
+
+// TestParseStreamWithCollect checks collected and skipped lines are not
+// yielded, and that breaking out stops reading.
+func TestParseStreamWithCollect(t *testing.T) {
+	var bad []*LineError
+	n := 0
+	for _, err := range ParseStreamWith(strings.NewReader(streamInput), 4, 0, TimeUnitSec, StreamOptions{Malformed: &bad}) {
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		n++
+	}
+	if n != 3 || len(bad) != 1 {
+		t.Fatalf("yielded %d, collected %d", n, len(bad))
+	}
+	n = 0
+	for range ParseStreamWith(strings.NewReader(streamInput), 4, 0, TimeUnitSec, StreamOptions{SkipMalformed: true}) {
+		if n++; n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Fatalf("break did not stop iteration: %d", n)
+	}
+}
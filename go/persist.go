@@ -0,0 +1,148 @@
+package wid
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrStateContention is returned when every compare-and-swap attempt of one
+// allocation lost to another writer.
+var ErrStateContention = errors.New("state store contention: retry budget exhausted")
+
+// GenState is a generator's persisted position: the last tick and the
+// sequence (HLC: logical counter) issued in it. The zero position of a new
+// store is {0, -1}, before the first ID of any tick.
+type GenState struct {
+	Tick int64
+	Seq  int
+}
+
+// InitialGenState is what a StateStore reports before anything was stored.
+var InitialGenState = GenState{Tick: 0, Seq: -1}
+
+// StateStore is shared generator state with compare-and-swap semantics, so
+// several processes (or restarts of one) can allocate from the same row
+// without reissuing an ID. Implementations can sit on a file, SQLite, Redis
+// or anything else offering an atomic conditional write.
+type StateStore interface {
+	// Load returns the current state, or InitialGenState when none is stored.
+	Load() (GenState, error)
+	// CompareAndSwap stores next only if the state is still old, reporting
+	// whether it did.
+	CompareAndSwap(old, next GenState) (bool, error)
+}
+
+// stateGen is what PersistentGen needs from the wrapped generator.
+type stateGen interface {
+	Next() string
+	State() (int64, int)
+}
+
+// persistMaxAttempts bounds one allocation's CAS loop.
+const persistMaxAttempts = 64
+
+// PersistentGen allocates IDs against a StateStore: every ID is computed from
+// the stored state and committed with CompareAndSwap, retrying when another
+// writer got there first. It is the library form of the CLI's E=sql mode.
+type PersistentGen struct {
+	store     StateStore
+	gen       stateGen
+	restore   func(GenState) error
+	mu        sync.Mutex
+	conflicts uint64
+	err       error
+}
+
+// NewPersistentWidGen returns a PersistentGen issuing WIDs.
+func NewPersistentWidGen(store StateStore, w, z int, unit TimeUnit) (*PersistentGen, error) {
+	g, err := NewWidGenWithUnit(w, z, unit)
+	if err != nil {
+		return nil, err
+	}
+	setRecoverUnit(store, unit)
+	return &PersistentGen{store: store, gen: g, restore: func(s GenState) error {
+		g.RestoreState(s.Tick, s.Seq)
+		return nil
+	}}, nil
+}
+
+// NewPersistentHLCWidGen returns a PersistentGen issuing HLC-WIDs for node.
+// Each node should use its own store (or key), as the CLI does.
+func NewPersistentHLCWidGen(store StateStore, node string, w, z int, unit TimeUnit) (*PersistentGen, error) {
+	g, err := NewHLCWidGenWithUnit(node, w, z, unit)
+	if err != nil {
+		return nil, err
+	}
+	setRecoverUnit(store, unit)
+	return &PersistentGen{store: store, gen: g, restore: func(s GenState) error {
+		// a fresh store's seq -1 is counter 0 at that tick for a clock
+		return g.RestoreState(s.Tick, max(s.Seq, 0))
+	}}, nil
+}
+
+// NextE allocates one ID, returning the store's error or ErrStateContention.
+func (p *PersistentGen) NextE() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := 0; i < persistMaxAttempts; i++ {
+		old, err := p.store.Load()
+		if err != nil {
+			return "", err
+		}
+		if err := p.restore(old); err != nil {
+			return "", err
+		}
+		id := p.gen.Next()
+		tick, seq := p.gen.State()
+		ok, err := p.store.CompareAndSwap(old, GenState{Tick: tick, Seq: seq})
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return id, nil
+		}
+		p.conflicts++
+	}
+	return "", ErrStateContention
+}
+
+// Next is NextE for the Generator interface. On failure it returns "" and
+// keeps the error for Err, in the manner of bufio.Scanner.
+func (p *PersistentGen) Next() string {
+	id, err := p.NextE()
+	if err != nil {
+		p.mu.Lock()
+		if p.err == nil {
+			p.err = err
+		}
+		p.mu.Unlock()
+	}
+	return id
+}
+
+// NextN allocates n IDs, stopping at the first failure (see Err).
+func (p *PersistentGen) NextN(n int) []string {
+	out := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		id := p.Next()
+		if id == "" {
+			break
+		}
+		out = append(out, id)
+	}
+	return out
+}
+
+// Err returns the first error Next or NextN swallowed.
+func (p *PersistentGen) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// Conflicts counts CAS attempts lost to other writers since creation.
+func (p *PersistentGen) Conflicts() uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.conflicts
+}
@@ -0,0 +1,52 @@
+package wid
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// TestPersistentGenSharedStore has several generators allocate from one
+// store concurrently and checks no ID is issued twice.
+func TestPersistentGenSharedStore(t *testing.T) {
+	store := NewFileStateStore(filepath.Join(t.TempDir(), "wid.state"))
+	var (
+		mu   sync.Mutex
+		seen = map[string]bool{}
+		wg   sync.WaitGroup
+	)
+	for i := 0; i < 4; i++ {
+		g, err := NewPersistentWidGen(store, 2, 0, TimeUnitSec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, id := range g.NextN(50) {
+				mu.Lock()
+				if seen[id] {
+					t.Errorf("duplicate %s", id)
+				}
+				seen[id] = true
+				mu.Unlock()
+			}
+			if err := g.Err(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(seen) != 200 {
+		t.Fatalf("issued %d unique ids, want 200", len(seen))
+	}
+	s, _ := store.Load()
+	if s == InitialGenState {
+		t.Fatal("store was never advanced")
+	}
+
+	h, _ := NewPersistentHLCWidGen(NewFileStateStore(filepath.Join(t.TempDir(), "hlc.state")), "node01", 4, 0, TimeUnitSec)
+	if id, err := h.NextE(); err != nil || !ValidateHlcWid(id, 4, 0) {
+		t.Fatalf("HLC NextE = %q, %v", id, err)
+	}
+}
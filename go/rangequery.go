@@ -0,0 +1,58 @@
+package wid
+
+import (
+	"strings"
+	"time"
+)
+
+// RangeForInterval returns the smallest and largest WIDs that can fall in the
+// window from..to, both ends inclusive at unit resolution, for BETWEEN
+// clauses and key-range scans over IDs of one W, Z and unit. lo has sequence
+// zero and no padding, which sorts before any padded ID of that instant; hi
+// has an all-nines sequence and, when z > 0, all-"f" padding. If to is before
+// from, lo sorts after hi and the range is empty. Invalid parameters, or an
+// end outside years 0-9999, give an empty bound.
+func RangeForInterval(from, to time.Time, w, z int, unit TimeUnit) (lo, hi string) {
+	tick := func(t time.Time) int64 {
+		if unit == TimeUnitMs {
+			return t.UnixMilli()
+		}
+		return t.Unix()
+	}
+	lo, _ = MinWidForTick(tick(from), w, z, unit)
+	hi, _ = MaxWidForTick(tick(to), w, z, unit)
+	return lo, hi
+}
+
+// MinWidForTick returns the smallest WID of a tick (Unix seconds or
+// milliseconds, per unit): sequence zero without padding. Use it for
+// partition boundaries and pagination cursors without issuing a real ID.
+func MinWidForTick(tick int64, w, z int, unit TimeUnit) (string, error) {
+	if err := checkTick(tick, w, z, unit); err != nil {
+		return "", err
+	}
+	return formatID(tick, 0, w, unit, "", ""), nil
+}
+
+// MaxWidForTick returns the largest WID of a tick: an all-nines sequence
+// and, when z > 0, all-"f" padding.
+func MaxWidForTick(tick int64, w, z int, unit TimeUnit) (string, error) {
+	if err := checkTick(tick, w, z, unit); err != nil {
+		return "", err
+	}
+	return formatID(tick, pow10(w)-1, w, unit, "", strings.Repeat("f", z)), nil
+}
+
+func checkTick(tick int64, w, z int, unit TimeUnit) error {
+	if err := checkParams(w, z, unit); err != nil {
+		return err
+	}
+	t := time.Unix(tick, 0)
+	if unit == TimeUnitMs {
+		t = time.UnixMilli(tick)
+	}
+	if y := t.UTC().Year(); y < 0 || y > 9999 {
+		return ErrInvalidTimestamp
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package wid
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRangeForIntervalBounds checks the bounds' exact form and that every ID
+// issued inside the window sorts between them while neighbours fall outside.
+func TestRangeForIntervalBounds(t *testing.T) {
+	from := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	to := from.Add(time.Second + 500*time.Millisecond)
+	lo, hi := RangeForInterval(from, to, 4, 6, TimeUnitSec)
+	if lo != "20261016T120000.0000Z" || hi != "20261016T120001.9999Z-ffffff" {
+		t.Fatalf("bounds = %s, %s", lo, hi)
+	}
+	inside := []string{lo, "20261016T120000.0000Z-000000", "20261016T120001.9999Z-fffffe", hi}
+	for _, id := range inside {
+		if id < lo || id > hi {
+			t.Errorf("%s outside [%s, %s]", id, lo, hi)
+		}
+	}
+	for _, id := range []string{"20261016T115959.9999Z-ffffff", "20261016T120002.0000Z"} {
+		if id >= lo && id <= hi {
+			t.Errorf("%s inside [%s, %s]", id, lo, hi)
+		}
+	}
+	if lo, hi := RangeForInterval(from, to, 2, 0, TimeUnitMs); lo != "20261016T120000000.00Z" || hi != "20261016T120001500.99Z" {
+		t.Fatalf("ms bounds = %s, %s", lo, hi)
+	}
+	if lo, hi := RangeForInterval(from, to, 0, 0, TimeUnitSec); lo != "" || hi != "" {
+		t.Fatal("invalid W must give empty bounds")
+	}
+}
+
+// TestMinMaxWidForTick checks the per-tick bounds bracket what a generator
+// issues in that tick.
+func TestMinMaxWidForTick(t *testing.T) {
+	g, _ := NewWidGenWithUnit(4, 6, TimeUnitMs)
+	id := g.Next()
+	tick, _ := g.State()
+	lo, err := MinWidForTick(tick, 4, 6, TimeUnitMs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hi, _ := MaxWidForTick(tick, 4, 6, TimeUnitMs)
+	if id < lo || id > hi {
+		t.Fatalf("%s outside [%s, %s]", id, lo, hi)
+	}
+	if next, _ := MinWidForTick(tick+1, 4, 6, TimeUnitMs); next <= hi {
+		t.Fatalf("next tick's minimum %s does not follow %s", next, hi)
+	}
+	if _, err := MaxWidForTick(1<<40, 4, 0, TimeUnitSec); err != ErrInvalidTimestamp {
+		t.Fatalf("year beyond 9999 = %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package wid
+
+import "strings"
+
+// RedactMask replaces each padding digit in a redacted ID.
+const RedactMask = 'x'
+
+// Redact masks the random padding of a WID or HLC-WID with RedactMask,
+// keeping the timestamp, sequence/logical counter, node and any TTL extension,
+// so logs stay sortable and correlatable by time without exposing the full
+// identifier. A namespace suffix and a check character are kept too; the
+// check character depends on the padding, so it is recomputed over the
+// masked ID. IDs without padding are returned unchanged. As with
+// TenantTagOf, an unpadded HLC-WID whose node is lowercase hex cannot be told
+// apart from a padded WID and has its node masked.
+func Redact(id string) string {
+	core, ns, check, ttl := splitSuffixes(id)
+	pad, ok := paddingOf(core)
+	if !ok {
+		return id
+	}
+	out := core[:len(core)-len(pad)] + strings.Repeat(string(RedactMask), len(pad)) + ns
+	if check != "" {
+		out = AppendChecksum(out)
+	}
+	return out + ttl
+}
+
+// splitSuffixes separates the extensions a generator may add to an ID, in
+// the order they are added: a "_<namespace>" (see NewWidGenWithNamespace), a
+// "-<check character>" (see AppendChecksum) and a "~<seconds>" TTL. Each part
+// keeps its separator, so core+ns+check+ttl == id.
+func splitSuffixes(id string) (core, ns, check, ttl string) {
+	core = id
+	if i := strings.Index(core, TTLSeparator); i >= 0 {
+		core, ttl = core[:i], core[i:]
+	}
+	if n := len(core); n >= 3 && core[n-2] == '-' {
+		if _, err := VerifyChecksum(core); err == nil {
+			core, check = core[:n-2], core[n-2:]
+		}
+	}
+	core, ns = stripNamespace(core)
+	if ns != "" {
+		ns = NamespaceSeparator + ns
+	}
+	return core, ns, check, ttl
+}
+
+// ValidateRedactedWid accepts a WID produced by Redact: the padding, when Z > 0,
+// must be exactly Z mask characters.
+func ValidateRedactedWid(id string, w, z int, unit TimeUnit) bool {
+	base, ok := unmaskPadding(id, z)
+	return ok && ValidateWidWithUnit(base, w, z, unit)
+}
+
+// ValidateRedactedHlcWid is ValidateRedactedWid for HLC-WIDs.
+func ValidateRedactedHlcWid(id string, w, z int, unit TimeUnit) bool {
+	base, ok := unmaskPadding(id, z)
+	return ok && ValidateHlcWidWithUnit(base, w, z, unit)
+}
+
+// unmaskPadding swaps a masked padding segment for zeros so the regular
+// validators can check the rest of the ID.
+func unmaskPadding(id string, z int) (string, bool) {
+	base, _, _ := strings.Cut(id, TTLSeparator)
+	if z == 0 {
+		return base, true
+	}
+	mask := strings.Repeat(string(RedactMask), z)
+	if !strings.HasSuffix(base, "-"+mask) {
+		return "", false
+	}
+	return base[:len(base)-z] + strings.Repeat("0", z), true
+}
@@ -0,0 +1,42 @@
+package wid
+
+import "testing"
+
+// TestRedactMasksPadding checks only the padding is masked, for WIDs, HLC-WIDs and TTL-bearing IDs.
+func TestRedactMasksPadding(t *testing.T) {
+	cases := map[string]string{
+		"20260212T091530.0042Z-a3f91c":        "20260212T091530.0042Z-xxxxxx",
+		"20260212T091530.0042Z-node01-a3f91c": "20260212T091530.0042Z-node01-xxxxxx",
+		"20260212T091530.0042Z-node01":        "20260212T091530.0042Z-node01",
+		"20260212T091530.0042Z":               "20260212T091530.0042Z",
+		"20260212T091530.0042Z-a3f91c~60":     "20260212T091530.0042Z-xxxxxx~60",
+		"20260212T091530.0042Z-a3f91c_orders": "20260212T091530.0042Z-xxxxxx_orders",
+	}
+	for in, want := range cases {
+		if got := Redact(in); got != want {
+			t.Errorf("Redact(%q) = %q, want %q", in, got, want)
+		}
+	}
+	// The check character is recomputed, as the original one hashes the padding.
+	in := AppendChecksum("20260212T091530.0042Z-a3f91c_orders") + "~60"
+	want := AppendChecksum("20260212T091530.0042Z-xxxxxx_orders") + "~60"
+	if got := Redact(in); got != want {
+		t.Errorf("Redact(%q) = %q, want %q", in, got, want)
+	}
+}
+
+// TestValidateRedacted checks the redacted validator mode accepts masked IDs only.
+func TestValidateRedacted(t *testing.T) {
+	if !ValidateRedactedWid("20260212T091530.0042Z-xxxxxx", 4, 6, TimeUnitSec) {
+		t.Fatal("masked WID should validate")
+	}
+	if ValidateRedactedWid("20260212T091530.0042Z-a3f91c", 4, 6, TimeUnitSec) {
+		t.Fatal("unmasked WID must not pass the redacted validator")
+	}
+	if !ValidateRedactedHlcWid("20260212T091530.0042Z-node01-xxxxxx", 4, 6, TimeUnitSec) {
+		t.Fatal("masked HLC-WID should validate")
+	}
+	if !ValidateRedactedWid("20260212T091530.0042Z", 4, 0, TimeUnitSec) {
+		t.Fatal("unpadded WID has nothing to mask")
+	}
+}
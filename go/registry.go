@@ -0,0 +1,66 @@
+package wid
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Generator is the behaviour shared by WidGen and HLCWidGen, so callers can
+// hold either one without caring which ID family it emits.
+type Generator interface {
+	Next() string
+	NextN(n int) []string
+}
+
+var (
+	_ Generator = (*WidGen)(nil)
+	_ Generator = (*HLCWidGen)(nil)
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Generator{}
+)
+
+// Register makes a generator available by name for the life of the process.
+// Like database/sql.Register it panics if g is nil or the name is taken, since
+// both are programming errors best caught at init time.
+func Register(name string, g Generator) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if g == nil {
+		panic("wid: Register generator is nil")
+	}
+	if _, dup := registry[name]; dup {
+		panic(fmt.Sprintf("wid: Register called twice for generator %q", name))
+	}
+	registry[name] = g
+}
+
+// Get returns the generator registered under name.
+func Get(name string) (Generator, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	g, ok := registry[name]
+	return g, ok
+}
+
+// Unregister removes a named generator; it is a no-op for unknown names.
+func Unregister(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	delete(registry, name)
+}
+
+// Generators lists the registered names in sorted order.
+func Generators() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,42 @@
+package wid
+
+import "testing"
+
+// TestRegistryRoundTrip registers both generator kinds and fetches them back by name.
+func TestRegistryRoundTrip(t *testing.T) {
+	w, _ := NewWidGen(4, 0)
+	h, _ := NewHLCWidGen("node01", 4, 0)
+	Register("test-wid", w)
+	Register("test-hlc", h)
+	defer Unregister("test-wid")
+	defer Unregister("test-hlc")
+
+	g, ok := Get("test-wid")
+	if !ok || !ValidateWid(g.Next(), 4, 0) {
+		t.Fatal("expected registered WidGen to be retrievable")
+	}
+	g, ok = Get("test-hlc")
+	if !ok || !ValidateHlcWid(g.Next(), 4, 0) {
+		t.Fatal("expected registered HLCWidGen to be retrievable")
+	}
+	if _, ok := Get("missing"); ok {
+		t.Fatal("unexpected generator for unknown name")
+	}
+	names := Generators()
+	if len(names) < 2 || names[0] > names[len(names)-1] {
+		t.Fatalf("Generators() = %v", names)
+	}
+}
+
+// TestRegistryDuplicatePanics checks a second registration under the same name panics.
+func TestRegistryDuplicatePanics(t *testing.T) {
+	g, _ := NewWidGen(4, 0)
+	Register("test-dup", g)
+	defer Unregister("test-dup")
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic on duplicate Register")
+		}
+	}()
+	Register("test-dup", g)
+}
@@ -0,0 +1,63 @@
+package wid
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrSequenceExhausted is returned by NextE under ReturnError when the
+// current tick has no sequence numbers left.
+var ErrSequenceExhausted = errors.New("sequence exhausted for the current tick")
+
+// RolloverPolicy decides what a WidGen does once a tick's W-digit sequence
+// is used up.
+type RolloverPolicy int
+
+const (
+	// BorrowFutureTick moves on to the next tick at once, so the ID's
+	// timestamp can run ahead of the wall clock. This is the default.
+	BorrowFutureTick RolloverPolicy = iota
+	// BlockUntilNextTick waits for the wall clock to reach the next tick;
+	// timestamps never lead the clock.
+	BlockUntilNextTick
+	// ReturnError makes NextE fail with ErrSequenceExhausted. Next, NextN
+	// and NextForContent cannot report an error and block instead.
+	ReturnError
+)
+
+func (p RolloverPolicy) String() string {
+	switch p {
+	case BorrowFutureTick:
+		return "borrow"
+	case BlockUntilNextTick:
+		return "block"
+	case ReturnError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// SetRolloverPolicy selects the behaviour on sequence exhaustion.
+func (g *WidGen) SetRolloverPolicy(p RolloverPolicy) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rollover = p
+}
+
+// waitPastLocked sleeps, holding g.mu so no other caller can claim the tick,
+// until the wall clock is past tick, and returns the new reading.
+func (g *WidGen) waitPastLocked(tick int64) int64 {
+	step := tickDuration(g.TimeUnit)
+	for {
+		now := g.now()
+		if now > tick {
+			g.lastNow = now
+			return now
+		}
+		if g.clock != nil {
+			continue // a virtual clock advances on every reading
+		}
+		next := time.Unix(0, 0).Add(time.Duration(g.epoch+tick+1) * step)
+		time.Sleep(max(time.Until(next), 100*time.Microsecond))
+	}
+}
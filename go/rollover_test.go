@@ -0,0 +1,41 @@
+package wid
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRolloverBlockNeverLeadsClock exhausts W=1 repeatedly in ms mode and
+// checks no ID's timestamp is ahead of the clock when it is returned.
+func TestRolloverBlockNeverLeadsClock(t *testing.T) {
+	g, _ := NewWidGenWithUnit(1, 0, TimeUnitMs)
+	g.SetRolloverPolicy(BlockUntilNextTick)
+	prev := ""
+	for i := 0; i < 40; i++ {
+		id := g.Next()
+		p, err := ParseWidWithUnit(id, 1, 0, TimeUnitMs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Timestamp.After(time.Now()) {
+			t.Fatalf("%s leads the wall clock", id)
+		}
+		if id <= prev {
+			t.Fatalf("%s not after %s", id, prev)
+		}
+		prev = id
+	}
+}
+
+// TestRolloverReturnError checks NextE reports exhaustion without issuing.
+func TestRolloverReturnError(t *testing.T) {
+	g, _ := NewWidGen(1, 0)
+	g.SetRolloverPolicy(ReturnError)
+	g.RestoreState(nowTick(TimeUnitSec)+60, 9)
+	if _, err := g.NextE(); err != ErrSequenceExhausted {
+		t.Fatalf("NextE on an exhausted tick = %v", err)
+	}
+	if tick, seq := g.State(); seq != 9 || tick <= nowTick(TimeUnitSec) {
+		t.Fatalf("state changed on error: %d %d", tick, seq)
+	}
+}
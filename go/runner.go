@@ -0,0 +1,179 @@
+package wid
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrRunnerConfig  = errors.New("runner needs a Generator and a Transport")
+	ErrRunnerRunning = errors.New("runner already started")
+)
+
+// Transport delivers the records a Runner emits. Publish may block; it should
+// honour ctx so Stop is not held up by a stuck delivery.
+type Transport interface {
+	Publish(ctx context.Context, rec map[string]any) error
+}
+
+// TransportFunc adapts a plain function to Transport.
+type TransportFunc func(ctx context.Context, rec map[string]any) error
+
+func (f TransportFunc) Publish(ctx context.Context, rec map[string]any) error {
+	return f(ctx, rec)
+}
+
+// RunnerConfig wires a Runner. Generator and Transport are required. To
+// survive restarts without reissuing IDs, pass a *PersistentGen as the
+// Generator; generators with NextE have their errors (clock regressions,
+// store failures) reported in Health instead of being papered over.
+type RunnerConfig struct {
+	Generator Generator
+	Transport Transport
+	// Interval between emissions; zero means one second.
+	Interval time.Duration
+	// Record builds the published record; the default is {"wid", "tick"}.
+	Record func(id string, tick int) map[string]any
+	// MaxBackoff caps the extra delay after consecutive transport failures;
+	// zero means five seconds.
+	MaxBackoff time.Duration
+}
+
+// RunnerHealth is a snapshot of a Runner, suitable for a readiness probe.
+type RunnerHealth struct {
+	Running     bool      `json:"running"`
+	StartedAt   time.Time `json:"started_at"`
+	Emitted     int       `json:"emitted"`
+	Failures    int       `json:"failures"`
+	LastID      string    `json:"last_id,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at"`
+	TransportOK bool      `json:"transport_ok"`
+}
+
+// Runner is the in-process form of the CLI's service loop (wid A=run): it
+// emits one ID per Interval over a Transport, backs off while the transport
+// fails and checkpoints generator state, without pid files or a daemon.
+type Runner struct {
+	cfg RunnerConfig
+
+	mu     sync.Mutex
+	health RunnerHealth
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewRunner validates cfg and fills in defaults.
+func NewRunner(cfg RunnerConfig) (*Runner, error) {
+	if cfg.Generator == nil || cfg.Transport == nil {
+		return nil, ErrRunnerConfig
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 5 * time.Second
+	}
+	if cfg.Record == nil {
+		cfg.Record = func(id string, tick int) map[string]any {
+			return map[string]any{"wid": id, "tick": tick}
+		}
+	}
+	return &Runner{cfg: cfg}, nil
+}
+
+// Start launches the emission loop in the background. The loop ends when ctx
+// is cancelled or Stop is called.
+func (r *Runner) Start(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.health.Running {
+		return ErrRunnerRunning
+	}
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+	r.health = RunnerHealth{Running: true, StartedAt: time.Now().UTC(), TransportOK: true}
+	go r.loop(ctx)
+	return nil
+}
+
+// Stop ends the loop and waits for it to exit. Stopping a Runner that is not
+// running is a no-op.
+func (r *Runner) Stop() error {
+	r.mu.Lock()
+	cancel, done := r.cancel, r.done
+	r.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	<-done
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cancel = nil
+	return nil
+}
+
+// Health returns a snapshot of the Runner's counters.
+func (r *Runner) Health() RunnerHealth {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.health
+}
+
+// next issues an ID, through NextE when the generator has one.
+func (r *Runner) next() (string, error) {
+	if g, ok := r.cfg.Generator.(interface{ NextE() (string, error) }); ok {
+		return g.NextE()
+	}
+	return r.cfg.Generator.Next(), nil
+}
+
+func (r *Runner) loop(ctx context.Context) {
+	defer func() {
+		r.mu.Lock()
+		r.health.Running = false
+		r.mu.Unlock()
+		close(r.done)
+	}()
+	failures := 0
+	for tick := 1; ; tick++ {
+		id, err := r.next()
+		var perr error
+		if err == nil {
+			perr = r.cfg.Transport.Publish(ctx, r.cfg.Record(id, tick))
+		}
+
+		r.mu.Lock()
+		if err != nil {
+			r.health.LastError = err.Error()
+			r.health.LastErrorAt = time.Now().UTC()
+		} else {
+			r.health.LastID = id
+		}
+		if perr != nil {
+			failures++
+			r.health.Failures++
+			r.health.TransportOK = false
+			r.health.LastError = perr.Error()
+			r.health.LastErrorAt = time.Now().UTC()
+		} else if err == nil {
+			failures = 0
+			r.health.Emitted++
+			r.health.TransportOK = true
+		}
+		r.mu.Unlock()
+
+		wait := r.cfg.Interval
+		if failures > 0 {
+			wait += min(100*time.Millisecond<<min(failures-1, 6), r.cfg.MaxBackoff)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
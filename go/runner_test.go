@@ -0,0 +1,70 @@
+package wid
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRunnerEmitsAndResumes runs a Runner over a PersistentGen, restarts it
+// on the same state file and checks the second run continues strictly after
+// the first.
+func TestRunnerEmitsAndResumes(t *testing.T) {
+	var mu sync.Mutex
+	var ids []string
+	tr := TransportFunc(func(_ context.Context, rec map[string]any) error {
+		mu.Lock()
+		defer mu.Unlock()
+		ids = append(ids, rec["wid"].(string))
+		return nil
+	})
+	path := filepath.Join(t.TempDir(), "state")
+	for run := 0; run < 2; run++ {
+		g, _ := NewPersistentWidGen(NewFileStateStore(path), 4, 0, TimeUnitSec)
+		r, err := NewRunner(RunnerConfig{Generator: g, Transport: tr, Interval: time.Millisecond})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Start(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Start(context.Background()); !errors.Is(err, ErrRunnerRunning) {
+			t.Fatalf("second Start = %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if err := r.Stop(); err != nil {
+			t.Fatal(err)
+		}
+		if h := r.Health(); h.Running || h.Emitted == 0 || !h.TransportOK {
+			t.Fatalf("health after run %d: %+v", run, h)
+		}
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("ids not increasing across restart: %s then %s", ids[i-1], ids[i])
+		}
+	}
+}
+
+// TestRunnerReportsTransportFailure checks failures surface in Health.
+func TestRunnerReportsTransportFailure(t *testing.T) {
+	g, _ := NewHLCWidGen("node01", 4, 0)
+	r, _ := NewRunner(RunnerConfig{
+		Generator: g,
+		Transport: TransportFunc(func(context.Context, map[string]any) error { return errors.New("down") }),
+		Interval:  time.Millisecond,
+	})
+	_ = r.Start(context.Background())
+	time.Sleep(10 * time.Millisecond)
+	_ = r.Stop()
+	h := r.Health()
+	if h.TransportOK || h.Failures == 0 || h.LastError != "down" || h.Emitted != 0 {
+		t.Fatalf("health = %+v", h)
+	}
+	if _, err := NewRunner(RunnerConfig{Generator: g}); !errors.Is(err, ErrRunnerConfig) {
+		t.Fatalf("NewRunner without transport = %v", err)
+	}
+}
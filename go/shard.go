@@ -0,0 +1,90 @@
+package wid
+
+import (
+	"errors"
+	"hash/fnv"
+)
+
+// ShardOf maps a WID or HLC-WID to one of n partitions. The hash is 64-bit
+// FNV-1a over the node and padding after the `Z`, run through the splitmix64
+// finalizer so that similar keys still land far apart, reduced modulo n; it
+// is stable across processes and releases. The timestamp is deliberately
+// excluded: hashing it would send each second's burst to the same shard. So
+// are a namespace, check character or TTL suffix, which would otherwise move
+// an ID to another shard. Unpadded (Z=0) WIDs have nothing after `Z`, and at
+// ordinary rates every sequence is 0, so their whole timestamp and sequence
+// are hashed instead. ShardOf panics if n <= 0.
+func ShardOf(id string, n int) int {
+	if n <= 0 {
+		panic("wid: ShardOf requires n > 0")
+	}
+	core, _, _, _ := splitSuffixes(id)
+	key := core
+	if zi := zIndex(core); zi >= 0 && zi+1 < len(core) {
+		key = core[zi+1:]
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return int(mix64(h.Sum64()) % uint64(n))
+}
+
+// mix64 is the splitmix64 finalizer.
+func mix64(x uint64) uint64 {
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
+
+// ErrInvalidShard is returned for a shard number that does not fit its width,
+// or a width that leaves the sequence and shard over MaxW digits.
+var ErrInvalidShard = errors.New("shard must fit shardWidth digits, with W+shardWidth at most 18")
+
+// NewShardedWidGen returns a generator for one member of a statically
+// partitioned fleet, in the manner of a Snowflake worker ID: shard is written
+// as shardWidth digits right after the W-digit sequence, so
+// "20261016T120000.0001" + "07" + "Z-<padding>" is sequence 1 of shard 7.
+// Each shard counts its own sequence, so shards never collide, and IDs still
+// sort by time, then sequence. To other tools the result is a plain WID with
+// W+shardWidth sequence digits; ParseShardedWid splits it again.
+func NewShardedWidGen(shard, shardWidth, w, z int, unit TimeUnit) (*WidGen, error) {
+	if err := checkShard(shard, shardWidth, w); err != nil {
+		return nil, err
+	}
+	g, err := NewWidGenWithUnit(w, z, unit)
+	if err != nil {
+		return nil, err
+	}
+	g.shard, g.shardWidth = shard, shardWidth
+	return g, nil
+}
+
+// ParseShardedWid parses a NewShardedWidGen ID: Sequence holds the W-digit
+// sequence and Shard the trailing shardWidth digits.
+func ParseShardedWid(id string, w, shardWidth, z int, unit TimeUnit) (*ParsedWid, error) {
+	if err := checkShard(0, shardWidth, w); err != nil {
+		return nil, err
+	}
+	p, err := ParseWidFast(id, w+shardWidth, z, unit)
+	if err != nil {
+		return nil, err
+	}
+	mul := pow10(shardWidth)
+	p.Sequence, p.Shard = p.Sequence/mul, p.Sequence%mul
+	return p, nil
+}
+
+// ValidateShardedWid reports whether id parses with ParseShardedWid.
+func ValidateShardedWid(id string, w, shardWidth, z int, unit TimeUnit) bool {
+	_, err := ParseShardedWid(id, w, shardWidth, z, unit)
+	return err == nil
+}
+
+func checkShard(shard, shardWidth, w int) error {
+	if w <= 0 || w > MaxW {
+		return ErrInvalidW
+	}
+	if shardWidth < 1 || w+shardWidth > MaxW || shard < 0 || shard >= pow10(shardWidth) {
+		return ErrInvalidShard
+	}
+	return nil
+}
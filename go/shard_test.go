@@ -0,0 +1,105 @@
+package wid
+
+import (
+	"testing"
+	"time"
+)
+
+// TestShardOfIgnoresTimestamp checks the shard depends on node/padding, not on the time prefix
+// or the ID's suffixes.
+func TestShardOfIgnoresTimestamp(t *testing.T) {
+	a := ShardOf("20260212T091530.0042Z-a3f91c", 16)
+	b := ShardOf("20270101T000000.0001Z-a3f91c", 16)
+	if a != b {
+		t.Fatalf("same padding mapped to shards %d and %d", a, b)
+	}
+	if ShardOf("20260212T091530.0042Z-node01", 16) != ShardOf("20260101T000000.0000Z-node01", 16) {
+		t.Fatal("HLC node should determine the shard")
+	}
+	for _, id := range []string{
+		"20260212T091530.0042Z-a3f91c~60",
+		"20260212T091530.0042Z-a3f91c_orders",
+		AppendChecksum("20260212T091530.0042Z-a3f91c"),
+	} {
+		if got := ShardOf(id, 1024); got != ShardOf("20260212T091530.0042Z-a3f91c", 1024) {
+			t.Fatalf("suffix moved %s to shard %d", id, got)
+		}
+	}
+}
+
+// TestShardOfSpreadsUnpaddedAtLowRate checks Z=0 WIDs issued one per second,
+// so all with sequence 0, still spread evenly over the shards.
+func TestShardOfSpreadsUnpaddedAtLowRate(t *testing.T) {
+	const n, ids = 16, 4096
+	counts := make([]int, n)
+	start := time.Date(2026, 2, 12, 9, 15, 30, 0, time.UTC)
+	for i := 0; i < ids; i++ {
+		counts[ShardOf(start.Add(time.Duration(i)*time.Second).Format("20060102T150405")+".0000Z", n)]++
+	}
+	for s, c := range counts {
+		if c < ids/n*3/4 || c > ids/n*5/4 {
+			t.Fatalf("shard %d got %d of %d IDs: %v", s, c, ids, counts)
+		}
+	}
+}
+
+// TestShardOfSpreads checks unpadded WIDs hash their sequence too and spread over shards.
+func TestShardOfSpreads(t *testing.T) {
+	g, _ := NewWidGen(4, 6)
+	seen := map[int]bool{}
+	for _, id := range g.NextN(200) {
+		s := ShardOf(id, 8)
+		if s < 0 || s >= 8 {
+			t.Fatalf("shard %d out of range", s)
+		}
+		seen[s] = true
+	}
+	if len(seen) < 6 {
+		t.Fatalf("poor spread over 8 shards: %v", seen)
+	}
+	if ShardOf("20260212T091530.0001Z", 64) == ShardOf("20260212T091530.0002Z", 64) &&
+		ShardOf("20260212T091530.0003Z", 64) == ShardOf("20260212T091530.0004Z", 64) {
+		t.Fatal("unpadded WIDs should hash their timestamp and sequence")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for n <= 0")
+		}
+	}()
+	ShardOf("20260212T091530.0001Z", 0)
+}
+
+// TestShardedWidGen checks shard digits land after the sequence, parse back
+// and keep two shards' IDs apart within one tick.
+func TestShardedWidGen(t *testing.T) {
+	a, err := NewShardedWidGen(7, 2, 4, 6, TimeUnitSec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := NewShardedWidGen(12, 2, 4, 6, TimeUnitSec)
+	seen := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		for _, g := range []*WidGen{a, b} {
+			id := g.Next()
+			base := id[:len(id)-7]
+			if seen[base] {
+				t.Fatalf("shards collided on %s", id)
+			}
+			seen[base] = true
+		}
+	}
+	id := a.Next()
+	p, err := ParseShardedWid(id, 4, 2, 6, TimeUnitSec)
+	if err != nil || p.Shard != 7 || p.Sequence != 50 {
+		t.Fatalf("ParseShardedWid(%s) = %+v, %v", id, p, err)
+	}
+	if !ValidateWid(id, 6, 6) || !ValidateShardedWid(id, 4, 2, 6, TimeUnitSec) || ValidateShardedWid(id, 4, 3, 6, TimeUnitSec) {
+		t.Fatalf("validation of %s", id)
+	}
+	if _, err := NewShardedWidGen(100, 2, 4, 6, TimeUnitSec); err != ErrInvalidShard {
+		t.Fatalf("shard 100 in 2 digits = %v", err)
+	}
+	if _, err := NewShardedWidGen(1, 15, 4, 6, TimeUnitSec); err != ErrInvalidShard {
+		t.Fatalf("W+shardWidth over 18 = %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package wid
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+)
+
+// Signature algorithms, by their JWS (RFC 7518) names. The algorithm follows
+// from the key type: Ed25519 keys sign EdDSA, ECDSA P-256 keys ES256 and RSA
+// keys PS256 (RSASSA-PSS, SHA-256, salt as long as the hash).
+const (
+	AlgEdDSA = "EdDSA"
+	AlgES256 = "ES256"
+	AlgPS256 = "PS256"
+)
+
+// MinRSABits is the smallest RSA modulus accepted for PS256.
+const MinRSABits = 2048
+
+var ErrUnsupportedKey = errors.New("unsupported key: want Ed25519, ECDSA P-256 or RSA of at least 2048 bits")
+
+// SignatureAlgorithm returns the JWS algorithm key signs with. key may be a
+// public key or a crypto.Signer.
+func SignatureAlgorithm(key any) (string, error) {
+	if s, ok := key.(crypto.Signer); ok {
+		key = s.Public()
+	}
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return AlgEdDSA, nil
+	case *ecdsa.PublicKey:
+		if k.Curve == elliptic.P256() {
+			return AlgES256, nil
+		}
+	case *rsa.PublicKey:
+		if k.N.BitLen() >= MinRSABits {
+			return AlgPS256, nil
+		}
+	}
+	return "", ErrUnsupportedKey
+}
+
+// ParseSigningKeyPEM decodes a private key of any supported algorithm: PKCS#8
+// ("PRIVATE KEY"), SEC 1 ("EC PRIVATE KEY"), PKCS#1 ("RSA PRIVATE KEY"), or
+// an unencrypted OpenSSH ed25519 key.
+func ParseSigningKeyPEM(b []byte) (crypto.Signer, error) {
+	blk, _ := pem.Decode(b)
+	if blk == nil {
+		return nil, ErrInvalidPrivateKeyPEM
+	}
+	var keyAny any
+	var err error
+	switch blk.Type {
+	case "OPENSSH PRIVATE KEY":
+		pk, err := ParseOpenSSHPrivateKey(blk.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return pk, nil
+	case "EC PRIVATE KEY":
+		keyAny, err = x509.ParseECPrivateKey(blk.Bytes)
+	case "RSA PRIVATE KEY":
+		keyAny, err = x509.ParsePKCS1PrivateKey(blk.Bytes)
+	default:
+		keyAny, err = x509.ParsePKCS8PrivateKey(blk.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s, ok := keyAny.(crypto.Signer)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+	if _, err := SignatureAlgorithm(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ParseVerifyingKeyPEM decodes a PKIX PEM public key of any supported
+// algorithm.
+func ParseVerifyingKeyPEM(b []byte) (crypto.PublicKey, error) {
+	blk, _ := pem.Decode(b)
+	if blk == nil {
+		return nil, ErrInvalidPublicKeyPEM
+	}
+	keyAny, err := x509.ParsePKIXPublicKey(blk.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := SignatureAlgorithm(keyAny); err != nil {
+		return nil, err
+	}
+	return keyAny, nil
+}
+
+// SignMessage signs msg with priv under its SignatureAlgorithm. ES256
+// signatures are the 64-byte r || s form JWS uses, not ASN.1.
+func SignMessage(priv crypto.Signer, msg []byte) ([]byte, error) {
+	alg, err := SignatureAlgorithm(priv)
+	if err != nil {
+		return nil, err
+	}
+	if alg == AlgEdDSA {
+		return priv.Sign(rand.Reader, msg, crypto.Hash(0))
+	}
+	digest := sha256.Sum256(msg)
+	if alg == AlgPS256 {
+		return priv.Sign(rand.Reader, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256})
+	}
+	der, err := priv.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	var rs struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(der, &rs); err != nil {
+		return nil, err
+	}
+	sig := make([]byte, 64)
+	rs.R.FillBytes(sig[:32])
+	rs.S.FillBytes(sig[32:])
+	return sig, nil
+}
+
+// VerifyMessage reports whether sig is a SignMessage signature of msg by
+// the holder of pub.
+func VerifyMessage(pub crypto.PublicKey, msg, sig []byte) bool {
+	switch k := pub.(type) {
+	case ed25519.PublicKey:
+		return len(k) == ed25519.PublicKeySize && ed25519.Verify(k, msg, sig)
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() || len(sig) != 64 {
+			return false
+		}
+		digest := sha256.Sum256(msg)
+		return ecdsa.Verify(k, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:]))
+	case *rsa.PublicKey:
+		if k.N.BitLen() < MinRSABits {
+			return false
+		}
+		digest := sha256.Sum256(msg)
+		return rsa.VerifyPSS(k, crypto.SHA256, digest[:], sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package wid
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// TestSignMessageAcrossAlgorithms round-trips each key type through the PEM
+// forms an organization's PKI may issue and checks the algorithm it maps to.
+func TestSignMessageAcrossAlgorithms(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	sec1, _ := x509.MarshalECPrivateKey(ecKey)
+	cases := []struct {
+		alg string
+		pem *pem.Block
+	}{
+		{AlgEdDSA, pkcs8Block(t, edKey)},
+		{AlgES256, pkcs8Block(t, ecKey)},
+		{AlgES256, &pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1}},
+		{AlgPS256, pkcs8Block(t, rsaKey)},
+		{AlgPS256, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}},
+	}
+	msg := []byte("wid-sig-v1:20260212T091530.0042Z-a3f91c")
+	for _, tc := range cases {
+		priv, err := ParseSigningKeyPEM(pem.EncodeToMemory(tc.pem))
+		if err != nil {
+			t.Fatalf("%s %s: %v", tc.alg, tc.pem.Type, err)
+		}
+		if alg, _ := SignatureAlgorithm(priv); alg != tc.alg {
+			t.Fatalf("%s: algorithm %s", tc.alg, alg)
+		}
+		pubDER, _ := x509.MarshalPKIXPublicKey(priv.Public())
+		pub, err := ParseVerifyingKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := SignMessage(priv, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifyMessage(pub, msg, sig) {
+			t.Fatalf("%s: signature rejected", tc.alg)
+		}
+		if VerifyMessage(pub, append(msg, '!'), sig) {
+			t.Fatalf("%s: tampered message accepted", tc.alg)
+		}
+	}
+	weak, _ := rsa.GenerateKey(rand.Reader, 1024)
+	if _, err := ParseSigningKeyPEM(pem.EncodeToMemory(pkcs8Block(t, weak))); !errors.Is(err, ErrUnsupportedKey) {
+		t.Fatalf("1024-bit RSA = %v", err)
+	}
+}
+
+// TestKeySetHoldsECAndRSAKeys parses EC and RSA JWKs and checks each key
+// verifies under its own kid.
+func TestKeySetHoldsECAndRSAKeys(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwks := `{"keys":[
+		{"kty":"EC","crv":"P-256","kid":"ec","x":"` + b64(ecKey.X.FillBytes(make([]byte, 32))) + `","y":"` + b64(ecKey.Y.FillBytes(make([]byte, 32))) + `"},
+		{"kty":"RSA","kid":"rsa","n":"` + b64(rsaKey.N.Bytes()) + `","e":"` + b64(big.NewInt(int64(rsaKey.E)).Bytes()) + `"}]}`
+	ks, err := ParseJWKS([]byte(jwks))
+	if err != nil || ks.Len() != 2 {
+		t.Fatalf("ParseJWKS: %v", err)
+	}
+	msg := []byte("m")
+	for kid, priv := range map[string]crypto.Signer{"ec": ecKey, "rsa": rsaKey} {
+		sig, _ := SignMessage(priv, msg)
+		if id, ok := ks.Verify(msg, sig, ""); !ok || id != kid {
+			t.Fatalf("%s: verified as %q, %v", kid, id, ok)
+		}
+		if PublicKeyFingerprint(ks.Key(kid)) != PublicKeyFingerprint(priv.Public()) {
+			t.Fatalf("%s: fingerprint differs after JWKS round trip", kid)
+		}
+	}
+}
+
+func pkcs8Block(t *testing.T, key any) *pem.Block {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+}
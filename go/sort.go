@@ -0,0 +1,71 @@
+package wid
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// idKey is the semantic order of any WID or HLC-WID: timestamp, then
+// sequence or logical counter, then node, with the raw string as the final
+// tie-break so the order is total.
+type idKey struct {
+	ts    time.Time
+	count int
+	node  string
+	raw   string
+}
+
+func keyOf(id string) (idKey, error) {
+	d, err := DetectParams(id)
+	if err != nil {
+		return idKey{}, err
+	}
+	if d.Kind == "hlc" {
+		p, err := ParseHlcWidFast(id, d.W, d.Z, d.TimeUnit)
+		if err != nil {
+			return idKey{}, err
+		}
+		return idKey{p.Timestamp, p.LogicalCounter, p.Node, id}, nil
+	}
+	p, err := ParseWidFast(id, d.W, d.Z, d.TimeUnit)
+	if err != nil {
+		return idKey{}, err
+	}
+	return idKey{p.Timestamp, p.Sequence, "", id}, nil
+}
+
+func (a idKey) compare(b idKey) int {
+	if c := a.ts.Compare(b.ts); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.count, b.count); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.node, b.node); c != 0 {
+		return c
+	}
+	return strings.Compare(a.raw, b.raw)
+}
+
+// SortIDs sorts ids in place by timestamp, then sequence (HLC: logical
+// counter, then node). Each ID's W, Z and time unit are detected on its own,
+// so a slice mixing widths sorts by the values the IDs encode rather than by
+// their spelling. If any ID cannot be parsed, ids is left unchanged and the
+// error is returned.
+func SortIDs(ids []string) error {
+	keys := make([]idKey, len(ids))
+	for i, id := range ids {
+		k, err := keyOf(id)
+		if err != nil {
+			return err
+		}
+		keys[i] = k
+	}
+	slices.SortStableFunc(keys, idKey.compare)
+	for i, k := range keys {
+		ids[i] = k.raw
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package wid
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestSortIDsMixedWidths checks a W=2 sequence 10 sorts after a W=4
+// sequence 0009 in the same second, which plain string order gets wrong.
+func TestSortIDsMixedWidths(t *testing.T) {
+	ids := []string{
+		"20260212T091531.0000Z",
+		"20260212T091530.10Z",
+		"20260212T091530.0009Z-abc123",
+		"20260212T091530000.0001Z",
+	}
+	if err := SortIDs(ids); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"20260212T091530000.0001Z",
+		"20260212T091530.0009Z-abc123",
+		"20260212T091530.10Z",
+		"20260212T091531.0000Z",
+	}
+	if !slices.Equal(ids, want) {
+		t.Fatalf("got %v, want %v", ids, want)
+	}
+	bad := []string{"20260212T091531.0000Z", "nope"}
+	if err := SortIDs(bad); err == nil || bad[0] != "20260212T091531.0000Z" {
+		t.Fatalf("bad input: err=%v ids=%v", err, bad)
+	}
+}
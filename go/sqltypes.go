@@ -0,0 +1,118 @@
+package wid
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// ID is a WID for struct fields: it validates on every decode (SQL scan,
+// text and JSON) and stores as a string. Its W, Z and time unit are detected
+// from the value, so one column can hold IDs from differently configured
+// generators. The empty ID stands for absence and maps to SQL NULL.
+type ID string
+
+// HlcID is ID for HLC-WIDs.
+type HlcID string
+
+// ParseID validates s as a WID.
+func ParseID(s string) (ID, error) {
+	if s == "" {
+		return "", nil
+	}
+	pw, _, _, err := ParseAny(s)
+	if err == nil && pw == nil {
+		err = ErrInvalidFormat
+	}
+	if err != nil {
+		return "", err
+	}
+	return ID(s), nil
+}
+
+// ParseHlcID validates s as an HLC-WID.
+func ParseHlcID(s string) (HlcID, error) {
+	if s == "" {
+		return "", nil
+	}
+	if _, _, err := parseAnyHlc(s); err != nil {
+		return "", err
+	}
+	return HlcID(s), nil
+}
+
+func (id ID) String() string { return string(id) }
+
+func (id ID) Value() (driver.Value, error) { return idValue(string(id)) }
+
+func (id *ID) Scan(src any) error {
+	return scanID(src, func(s string) (err error) { *id, err = ParseID(s); return })
+}
+
+func (id ID) MarshalText() ([]byte, error) { return []byte(id), nil }
+
+func (id *ID) UnmarshalText(b []byte) (err error) {
+	*id, err = ParseID(string(b))
+	return err
+}
+
+func (id ID) MarshalJSON() ([]byte, error) { return json.Marshal(string(id)) }
+
+func (id *ID) UnmarshalJSON(b []byte) error {
+	return unmarshalJSONID(b, func(s string) (err error) { *id, err = ParseID(s); return })
+}
+
+func (id HlcID) String() string { return string(id) }
+
+func (id HlcID) Value() (driver.Value, error) { return idValue(string(id)) }
+
+func (id *HlcID) Scan(src any) error {
+	return scanID(src, func(s string) (err error) { *id, err = ParseHlcID(s); return })
+}
+
+func (id HlcID) MarshalText() ([]byte, error) { return []byte(id), nil }
+
+func (id *HlcID) UnmarshalText(b []byte) (err error) {
+	*id, err = ParseHlcID(string(b))
+	return err
+}
+
+func (id HlcID) MarshalJSON() ([]byte, error) { return json.Marshal(string(id)) }
+
+func (id *HlcID) UnmarshalJSON(b []byte) error {
+	return unmarshalJSONID(b, func(s string) (err error) { *id, err = ParseHlcID(s); return })
+}
+
+func idValue(s string) (driver.Value, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return s, nil
+}
+
+// scanID hands a column value to set; NULL sets the empty ID.
+func scanID(src any, set func(string) error) error {
+	switch v := src.(type) {
+	case nil:
+		return set("")
+	case string:
+		return set(v)
+	case []byte:
+		return set(string(v))
+	default:
+		return fmt.Errorf("wid: cannot scan %T into an ID", src)
+	}
+}
+
+// unmarshalJSONID decodes a JSON string; null leaves the ID unchanged, as
+// encoding/json does for other types.
+func unmarshalJSONID(b []byte, set func(string) error) error {
+	if string(b) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return set(s)
+}
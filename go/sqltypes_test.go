@@ -0,0 +1,52 @@
+package wid
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestIDTypesRoundTrip checks ID and HlcID through SQL and JSON, including
+// NULL, and that malformed values are rejected on decode.
+func TestIDTypesRoundTrip(t *testing.T) {
+	type row struct {
+		ID   ID    `json:"id"`
+		Peer HlcID `json:"peer"`
+	}
+	in := row{ID: "20261016T120000.0001Z-abcdef", Peer: "20261016T120000123.0002Z-node1"}
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out row
+	if err := json.Unmarshal(b, &out); err != nil || out != in {
+		t.Fatalf("json round trip = %+v, %v", out, err)
+	}
+	if err := json.Unmarshal([]byte(`{"id":"20261016T120000.0001Z-node1"}`), &out); err == nil {
+		t.Fatal("an HLC-WID must not decode into an ID")
+	}
+	if err := json.Unmarshal([]byte(`{"peer":"not-a-wid"}`), &out); err == nil {
+		t.Fatal("garbage must not decode into an HlcID")
+	}
+
+	v, err := in.ID.Value()
+	if err != nil || v != string(in.ID) {
+		t.Fatalf("Value = %v, %v", v, err)
+	}
+	var id ID
+	if err := id.Scan([]byte(in.ID)); err != nil || id != in.ID {
+		t.Fatalf("Scan = %q, %v", id, err)
+	}
+	if err := id.Scan(nil); err != nil || id != "" {
+		t.Fatalf("Scan(nil) = %q, %v", id, err)
+	}
+	if v, _ := id.Value(); v != nil {
+		t.Fatalf("empty ID Value = %v, want NULL", v)
+	}
+	if err := id.Scan(42); err == nil {
+		t.Fatal("scanning an int must fail")
+	}
+	var h HlcID
+	if err := h.Scan("20261016T120000.0001Z-abc"); err != nil || h != "20261016T120000.0001Z-abc" {
+		t.Fatalf("hex node Scan = %q, %v", h, err)
+	}
+}
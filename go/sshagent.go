@@ -0,0 +1,134 @@
+package wid
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+)
+
+var (
+	ErrNoSSHAgent   = errors.New("no ssh-agent: SSH_AUTH_SOCK is not set")
+	ErrNoAgentKey   = errors.New("ssh-agent holds no matching ssh-ed25519 key")
+	ErrAgentFailure = errors.New("ssh-agent refused the request")
+)
+
+// ssh-agent protocol messages (draft-miller-ssh-agent).
+const (
+	agentRequestIdentities = 11
+	agentIdentitiesAnswer  = 12
+	agentSignRequest       = 13
+	agentSignResponse      = 14
+)
+
+// maxAgentReply bounds one agent response.
+const maxAgentReply = 256 << 10
+
+// SSHAgentSigner signs with an ssh-ed25519 key held by ssh-agent, so a
+// developer's existing SSH key signs WIDs without its private half being
+// read from disk. Ed25519 agent signatures cover the message itself, so they
+// are the signatures the key file would give and verify against the
+// id_ed25519.pub as usual.
+type SSHAgentSigner struct {
+	socket  string
+	pub     ed25519.PublicKey
+	blob    []byte
+	comment string
+}
+
+// NewSSHAgentSigner asks the agent at socket ($SSH_AUTH_SOCK when empty) for
+// its keys and picks the first ssh-ed25519 key whose fingerprint (see
+// PublicKeyFingerprint) or comment equals match, or the first one when match
+// is empty.
+func NewSSHAgentSigner(socket, match string) (*SSHAgentSigner, error) {
+	if socket == "" {
+		socket = os.Getenv("SSH_AUTH_SOCK")
+	}
+	if socket == "" {
+		return nil, ErrNoSSHAgent
+	}
+	reply, err := agentCall(socket, []byte{agentRequestIdentities})
+	if err != nil {
+		return nil, err
+	}
+	if len(reply) == 0 || reply[0] != agentIdentitiesAnswer {
+		return nil, ErrAgentFailure
+	}
+	r := &sshReader{b: reply[1:]}
+	for n := r.uint32(); n > 0 && !r.err; n-- {
+		blob, comment := r.bytes(), string(r.bytes())
+		kr := &sshReader{b: blob}
+		typ, key := string(kr.bytes()), kr.bytes()
+		if r.err || kr.err || typ != "ssh-ed25519" || len(key) != ed25519.PublicKeySize {
+			continue
+		}
+		pub := ed25519.PublicKey(append([]byte(nil), key...))
+		if match == "" || match == comment || match == PublicKeyFingerprint(pub) {
+			return &SSHAgentSigner{socket: socket, pub: pub, blob: append([]byte(nil), blob...), comment: comment}, nil
+		}
+	}
+	if r.err {
+		return nil, ErrInvalidSSHKey
+	}
+	return nil, ErrNoAgentKey
+}
+
+// Public returns the agent key's Ed25519 public key.
+func (s *SSHAgentSigner) Public() crypto.PublicKey { return s.pub }
+
+// Comment is the comment the agent lists the key under, usually its file.
+func (s *SSHAgentSigner) Comment() string { return s.comment }
+
+// Sign has the agent sign msg. Like ed25519.PrivateKey.Sign it takes the
+// message itself, so opts must not name a hash.
+func (s *SSHAgentSigner) Sign(_ io.Reader, msg []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts.HashFunc() != 0 {
+		return nil, errors.New("ssh-agent Ed25519 keys sign unhashed messages")
+	}
+	req := append([]byte{agentSignRequest}, sshString(nil, s.blob)...)
+	req = sshString(req, msg)
+	req = binary.BigEndian.AppendUint32(req, 0)
+	reply, err := agentCall(s.socket, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(reply) == 0 || reply[0] != agentSignResponse {
+		return nil, ErrAgentFailure
+	}
+	r := &sshReader{b: reply[1:]}
+	sr := &sshReader{b: r.bytes()}
+	typ, sig := string(sr.bytes()), sr.bytes()
+	if r.err || sr.err || typ != "ssh-ed25519" || len(sig) != ed25519.SignatureSize {
+		return nil, ErrAgentFailure
+	}
+	return append([]byte(nil), sig...), nil
+}
+
+// agentCall sends one length-framed request to the agent and returns its
+// reply.
+func agentCall(socket string, req []byte) ([]byte, error) {
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if _, err := conn.Write(sshString(nil, req)); err != nil {
+		return nil, err
+	}
+	var n [4]byte
+	if _, err := io.ReadFull(conn, n[:]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(n[:])
+	if size > maxAgentReply {
+		return nil, ErrAgentFailure
+	}
+	reply := make([]byte, size)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
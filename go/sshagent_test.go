@@ -0,0 +1,98 @@
+package wid
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+// fakeAgent serves the identity and sign requests of the ssh-agent protocol
+// for keys, listed under their comments.
+func fakeAgent(t *testing.T, keys map[string]ed25519.PrivateKey, order []string) string {
+	t.Helper()
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	blobOf := func(k ed25519.PrivateKey) []byte {
+		return sshString(sshString(nil, []byte("ssh-ed25519")), k.Public().(ed25519.PublicKey))
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			var n [4]byte
+			io.ReadFull(conn, n[:])
+			req := make([]byte, binary.BigEndian.Uint32(n[:]))
+			io.ReadFull(conn, req)
+			var reply []byte
+			switch req[0] {
+			case agentRequestIdentities:
+				reply = binary.BigEndian.AppendUint32([]byte{agentIdentitiesAnswer}, uint32(len(order)))
+				for _, c := range order {
+					reply = sshString(sshString(reply, blobOf(keys[c])), []byte(c))
+				}
+			case agentSignRequest:
+				r := &sshReader{b: req[1:]}
+				blob, msg := r.bytes(), r.bytes()
+				reply = []byte{5}
+				for _, k := range keys {
+					if string(blobOf(k)) == string(blob) {
+						sig := sshString(sshString(nil, []byte("ssh-ed25519")), ed25519.Sign(k, msg))
+						reply = sshString([]byte{agentSignResponse}, sig)
+					}
+				}
+			}
+			conn.Write(sshString(nil, reply))
+			conn.Close()
+		}
+	}()
+	return sock
+}
+
+// TestSSHAgentSignerSelectsAndSigns picks agent keys by comment and
+// fingerprint and checks their signatures verify like file-key ones.
+func TestSSHAgentSignerSelectsAndSigns(t *testing.T) {
+	_, work, _ := ed25519.GenerateKey(rand.Reader)
+	_, home, _ := ed25519.GenerateKey(rand.Reader)
+	sock := fakeAgent(t, map[string]ed25519.PrivateKey{"work": work, "home": home}, []string{"work", "home"})
+
+	for match, want := range map[string]ed25519.PrivateKey{
+		"":                                  work,
+		"home":                              home,
+		PublicKeyFingerprint(home.Public()): home,
+	} {
+		s, err := NewSSHAgentSigner(sock, match)
+		if err != nil {
+			t.Fatalf("match %q: %v", match, err)
+		}
+		if !s.Public().(ed25519.PublicKey).Equal(want.Public()) {
+			t.Fatalf("match %q picked %s", match, s.Comment())
+		}
+		msg := []byte("wid-sig-v1:20260212T091530.0042Z-a3f91c")
+		sig, err := SignMessage(s, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifyMessage(want.Public(), msg, sig) {
+			t.Fatalf("match %q: agent signature rejected", match)
+		}
+	}
+	if _, err := NewSSHAgentSigner(sock, "laptop"); !errors.Is(err, ErrNoAgentKey) {
+		t.Fatalf("unknown key = %v", err)
+	}
+	s, _ := NewSSHAgentSigner(sock, "")
+	if _, err := s.Sign(nil, []byte("m"), crypto.SHA256); err == nil {
+		t.Fatal("hashed sign request accepted")
+	}
+}
@@ -0,0 +1,62 @@
+package wid
+
+// GenStats counts what a generator has done since it was created or last
+// reset. MaxSeq is the highest sequence (or HLC logical counter) issued within
+// a single tick; compared with Capacity it shows how close the busiest tick
+// came to exhaustion.
+type GenStats struct {
+	Issued           uint64 `json:"issued"`
+	Rollovers        uint64 `json:"rollovers"`
+	ClockRegressions uint64 `json:"clock_regressions"`
+	MaxSeq           int    `json:"max_seq"`
+	Capacity         int    `json:"capacity"`
+}
+
+// PeakUtilization is the busiest tick's share of the sequence space, from 0
+// to 1; alert on it before Rollovers starts to climb.
+func (s GenStats) PeakUtilization() float64 {
+	if s.Capacity == 0 || s.Issued == 0 {
+		return 0
+	}
+	return float64(s.MaxSeq+1) / float64(s.Capacity)
+}
+
+func (s *GenStats) note(seq int) {
+	s.Issued++
+	if seq > s.MaxSeq {
+		s.MaxSeq = seq
+	}
+}
+
+// Stats returns a snapshot of the generator's counters.
+func (g *WidGen) Stats() GenStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.stats
+}
+
+// ResetStats returns the counters and starts a new measuring period, so a
+// scraper can report per-interval figures.
+func (g *WidGen) ResetStats() GenStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s := g.stats
+	g.stats = GenStats{Capacity: s.Capacity}
+	return s
+}
+
+// Stats returns a snapshot of the generator's counters.
+func (g *HLCWidGen) Stats() GenStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.stats
+}
+
+// ResetStats is WidGen.ResetStats for HLC generators.
+func (g *HLCWidGen) ResetStats() GenStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s := g.stats
+	g.stats = GenStats{Capacity: s.Capacity}
+	return s
+}
@@ -0,0 +1,26 @@
+package wid
+
+import "testing"
+
+// TestGenStats drives a W=1 generator past one tick's capacity and checks
+// the counters and that ResetStats starts a fresh period.
+func TestGenStats(t *testing.T) {
+	g, _ := NewWidGen(1, 0)
+	g.NextN(25)
+	s := g.Stats()
+	if s.Issued != 25 || s.Rollovers == 0 || s.MaxSeq != 9 || s.Capacity != 10 || s.PeakUtilization() != 1 {
+		t.Fatalf("stats after 25 ids at W=1: %+v", s)
+	}
+	if r := g.ResetStats(); r != s {
+		t.Fatalf("ResetStats returned %+v, want %+v", r, s)
+	}
+	if s := g.Stats(); s.Issued != 0 || s.Capacity != 10 || s.PeakUtilization() != 0 {
+		t.Fatalf("stats after reset: %+v", s)
+	}
+
+	h, _ := NewHLCWidGen("node01", 2, 0)
+	h.NextN(3)
+	if s := h.Stats(); s.Issued != 3 || s.MaxSeq < 1 || s.Rollovers != 0 {
+		t.Fatalf("HLC stats: %+v", s)
+	}
+}
@@ -0,0 +1,64 @@
+package wid
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrTooOld    = errors.New("timestamp is older than the allowed age")
+	ErrTooFuture = errors.New("timestamp is too far in the future")
+)
+
+// StrictOptions bounds how far an ID's timestamp may lie from the current
+// time. MaxAge of zero means no age limit; MaxFuture is the clock skew
+// tolerated ahead of Now, so zero rejects any timestamp later than Now. A zero
+// Now means time.Now().
+type StrictOptions struct {
+	MaxAge    time.Duration
+	MaxFuture time.Duration
+	Now       time.Time
+}
+
+// CheckTime reports whether ts lies inside the window, returning ErrTooOld or
+// ErrTooFuture when it does not.
+func (o StrictOptions) CheckTime(ts time.Time) error {
+	now := o.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	delta := now.Sub(ts)
+	if delta < 0 && -delta > o.MaxFuture {
+		return ErrTooFuture
+	}
+	if o.MaxAge > 0 && delta > o.MaxAge {
+		return ErrTooOld
+	}
+	return nil
+}
+
+// ValidateWidStrict is ValidateWidDetailed plus a plausibility check of the
+// embedded timestamp against opts, so services can reject IDs minted long ago
+// or by a clock running ahead.
+func ValidateWidStrict(id string, w, z int, unit TimeUnit, opts StrictOptions) error {
+	if err := ValidateWidDetailed(id, w, z, unit); err != nil {
+		return err
+	}
+	p, err := ParseWidWithUnit(id, w, z, unit)
+	if err != nil {
+		return err
+	}
+	return opts.CheckTime(p.Timestamp)
+}
+
+// ValidateHlcWidStrict is the HLC-WID counterpart of ValidateWidStrict.
+func ValidateHlcWidStrict(id string, w, z int, unit TimeUnit, opts StrictOptions) error {
+	if err := ValidateHlcWidDetailed(id, w, z, unit); err != nil {
+		return err
+	}
+	p, err := ParseHlcWidWithUnit(id, w, z, unit)
+	if err != nil {
+		return err
+	}
+	return opts.CheckTime(p.Timestamp)
+}
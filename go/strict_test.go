@@ -0,0 +1,39 @@
+package wid
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestValidateWidStrictWindow checks the age and future bounds around a
+// fixed Now, and that format errors still come first.
+func TestValidateWidStrictWindow(t *testing.T) {
+	now := time.Date(2026, 2, 12, 9, 15, 30, 0, time.UTC)
+	opts := StrictOptions{MaxAge: time.Minute, MaxFuture: 5 * time.Second, Now: now}
+	cases := []struct {
+		id   string
+		want error
+	}{
+		{"20260212T091530.0000Z", nil},
+		{"20260212T091435.0000Z", nil},
+		{"20260212T091429.0000Z", ErrTooOld},
+		{"20260212T091535.0000Z", nil},
+		{"20260212T091536.0000Z", ErrTooFuture},
+	}
+	for _, c := range cases {
+		if err := ValidateWidStrict(c.id, 4, 0, TimeUnitSec, opts); !errors.Is(err, c.want) {
+			t.Fatalf("%s: got %v, want %v", c.id, err, c.want)
+		}
+	}
+	var ve *ValidationError
+	if err := ValidateWidStrict("20260212T091530.000Z", 4, 0, TimeUnitSec, opts); !errors.As(err, &ve) {
+		t.Fatalf("malformed id: got %v, want *ValidationError", err)
+	}
+	if err := ValidateHlcWidStrict("20260212T091540.0000Z-node01", 4, 0, TimeUnitSec, opts); !errors.Is(err, ErrTooFuture) {
+		t.Fatalf("HLC future: got %v", err)
+	}
+	if err := (StrictOptions{Now: now}).CheckTime(now.Add(-24 * time.Hour)); err != nil {
+		t.Fatalf("zero MaxAge should not limit age: %v", err)
+	}
+}
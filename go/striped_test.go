@@ -0,0 +1,46 @@
+package wid
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestStripedWidGenConcurrentUnique issues IDs from many goroutines and
+// checks they are all valid and distinct, and that NextN increases.
+func TestStripedWidGenConcurrentUnique(t *testing.T) {
+	g, err := NewStripedWidGen(8, 4, 0, TimeUnitSec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	var wg sync.WaitGroup
+	for w := 0; w < 16; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			local := make([]string, 0, 2000)
+			for i := 0; i < 2000; i++ {
+				local = append(local, g.Next())
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, id := range local {
+				if seen[id] || !ValidateWid(id, 4, 0) {
+					t.Errorf("duplicate or invalid id %s", id)
+				}
+				seen[id] = true
+			}
+		}()
+	}
+	wg.Wait()
+	ids := g.NextN(3000)
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("NextN not increasing: %s then %s", ids[i-1], ids[i])
+		}
+	}
+	if _, err := NewStripedWidGen(0, 4, 0, TimeUnitSec); err != ErrInvalidStripes {
+		t.Fatalf("zero stripes = %v", err)
+	}
+}
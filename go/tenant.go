@@ -0,0 +1,89 @@
+package wid
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidTenantTag is returned when the tenant tag length does not fit the padding.
+var ErrInvalidTenantTag = errors.New("tenant tag length must be between 1 and Z")
+
+// TenantTag derives the n-hex-character tag a tenant's WIDs carry at the start
+// of their padding: the leading digits of HMAC-SHA256(secret, tenant). Without
+// the secret the tag reveals nothing about the tenant name. n is capped at 64
+// (MaxZ), the length of the hex digest.
+func TenantTag(secret []byte, tenant string, n int) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(tenant))
+	h := hex.EncodeToString(mac.Sum(nil))
+	if n > len(h) {
+		n = len(h)
+	}
+	return h[:n]
+}
+
+// NewTenantWidGen returns a generator whose padding starts with the tenant's
+// tagLen-digit TenantTag and is random for the remaining Z-tagLen digits, so
+// routers can partition by tenant from the ID alone while IDs stay unguessable.
+// Keep Z-tagLen large enough for the collision resistance you need.
+func NewTenantWidGen(secret []byte, tenant string, tagLen, w, z int, unit TimeUnit) (*WidGen, error) {
+	g, err := NewWidGenWithUnit(w, z, unit)
+	if err != nil {
+		return nil, err
+	}
+	if tagLen < 1 || tagLen > z {
+		return nil, ErrInvalidTenantTag
+	}
+	g.padPrefix = TenantTag(secret, tenant, tagLen)
+	return g, nil
+}
+
+// TenantTagOf returns the first n padding digits of a WID or HLC-WID, or false
+// when the ID has no padding segment that long. An unpadded HLC-WID whose node
+// is itself lowercase hex is indistinguishable from a padded one, so tenant
+// generators should always use Z > 0.
+func TenantTagOf(id string, n int) (string, bool) {
+	pad, ok := paddingOf(id)
+	if !ok || n < 1 || len(pad) < n {
+		return "", false
+	}
+	return pad[:n], true
+}
+
+// BelongsToTenant reports whether id was produced by NewTenantWidGen for tenant.
+func BelongsToTenant(id string, secret []byte, tenant string, tagLen int) bool {
+	tag, ok := TenantTagOf(id, tagLen)
+	return ok && hmac.Equal([]byte(tag), []byte(TenantTag(secret, tenant, tagLen)))
+}
+
+// paddingOf extracts the trailing hex padding segment after Z, if any.
+func paddingOf(id string) (string, bool) {
+	zi := zIndex(id)
+	if zi < 0 || zi+1 >= len(id) || id[zi+1] != '-' {
+		return "", false
+	}
+	rest := id[zi+2:]
+	rest = rest[strings.LastIndexByte(rest, '-')+1:]
+	if rest == "" || !isLowerHexStr(rest) {
+		return "", false
+	}
+	return rest, true
+}
+
+// zIndex locates the Z that terminates the timestamp/sequence prefix.
+func zIndex(id string) int {
+	return strings.IndexByte(id, 'Z')
+}
+
+func isLowerHexStr(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,46 @@
+package wid
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestTenantWidGenTagsPadding checks tenant IDs carry the derived tag and stay valid.
+func TestTenantWidGenTagsPadding(t *testing.T) {
+	secret := []byte("s3cret")
+	g, err := NewTenantWidGen(secret, "acme", 4, 4, 12, TimeUnitSec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag := TenantTag(secret, "acme", 4)
+	a, b := g.Next(), g.Next()
+	for _, id := range []string{a, b} {
+		if !ValidateWid(id, 4, 12) {
+			t.Fatalf("tenant WID %q failed validation", id)
+		}
+		if !strings.HasPrefix(id[strings.LastIndex(id, "-")+1:], tag) {
+			t.Fatalf("expected tag %s in %s", tag, id)
+		}
+		if !BelongsToTenant(id, secret, "acme", 4) || BelongsToTenant(id, secret, "globex", 4) {
+			t.Fatalf("tenant membership wrong for %s", id)
+		}
+	}
+	if a[len(a)-8:] == b[len(b)-8:] {
+		t.Fatal("non-tag padding should stay random")
+	}
+}
+
+// TestTenantWidGenRejectsBadTagLen ensures the tag must fit inside the padding.
+func TestTenantWidGenRejectsBadTagLen(t *testing.T) {
+	for _, n := range []int{0, 7} {
+		if _, err := NewTenantWidGen([]byte("k"), "acme", n, 4, 6, TimeUnitSec); err != ErrInvalidTenantTag {
+			t.Fatalf("tagLen %d: expected ErrInvalidTenantTag, got %v", n, err)
+		}
+	}
+	if len(TenantTag([]byte("k"), "acme", MaxZ)) != MaxZ {
+		t.Fatal("TenantTag must support MaxZ digits")
+	}
+	if _, ok := TenantTagOf("20260212T091530.0000Z", 2); ok {
+		t.Fatal("unpadded WID has no tenant tag")
+	}
+}
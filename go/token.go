@@ -0,0 +1,95 @@
+package wid
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken   = errors.New("token must be <id>.<base64url signature>")
+	ErrTokenSignature = errors.New("token signature does not verify")
+)
+
+// tokenDomain prefixes the signed message so a token signature can never be
+// replayed as an A=sign signature ("wid-sig-v1:") or the other way round.
+const tokenDomain = "wid-token-v1:"
+
+// SignToken makes id self-certifying: it returns "<id>.<sig>", where sig is
+// the unpadded base64url Ed25519 signature of "wid-token-v1:" || id. The
+// signature alphabet has no '.', so the last '.' always separates it from
+// the ID. id may be any WID or HLC-WID, with or without a TTL extension.
+func SignToken(id string, priv ed25519.PrivateKey) (string, error) {
+	if _, err := tokenTime(id); err != nil {
+		return "", err
+	}
+	sig := ed25519.Sign(priv, []byte(tokenDomain+id))
+	return id + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// VerifyToken checks a SignToken token against pub and the timestamp of its
+// ID against window (see StrictOptions), and returns the ID. An ID with a TTL
+// extension must also not have expired at window.Now. The signature is
+// checked first, so a forged token reports ErrTokenSignature whatever its
+// timestamp.
+func VerifyToken(token string, pub ed25519.PublicKey, window StrictOptions) (string, error) {
+	return verifyToken(token, window, func(msg, sig []byte) bool { return ed25519.Verify(pub, msg, sig) })
+}
+
+// VerifyTokenKeySet is VerifyToken accepting a signature by any key of ks,
+// for verifiers that span a key rotation.
+func VerifyTokenKeySet(token string, ks *KeySet, window StrictOptions) (string, error) {
+	return verifyToken(token, window, func(msg, sig []byte) bool {
+		_, ok := ks.Verify(msg, sig, "")
+		return ok
+	})
+}
+
+func verifyToken(token string, window StrictOptions, verify func(msg, sig []byte) bool) (string, error) {
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return "", ErrInvalidToken
+	}
+	id := token[:i]
+	sig, err := base64.RawURLEncoding.DecodeString(token[i+1:])
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return "", ErrInvalidToken
+	}
+	if !verify([]byte(tokenDomain+id), sig) {
+		return "", ErrTokenSignature
+	}
+	ts, err := tokenTime(id)
+	if err != nil {
+		return "", err
+	}
+	if err := window.CheckTime(ts); err != nil {
+		return "", err
+	}
+	now := window.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if IsExpiredAt(id, now) {
+		return "", ErrTooOld
+	}
+	return id, nil
+}
+
+// tokenTime parses the ID of a token, TTL extension aside, and returns its
+// timestamp.
+func tokenTime(id string) (time.Time, error) {
+	base, _, _, err := SplitTTL(id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	pw, ph, _, err := ParseAny(base)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if pw != nil {
+		return pw.Timestamp, nil
+	}
+	return ph.Timestamp, nil
+}
@@ -0,0 +1,50 @@
+package wid
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSignVerifyToken checks a token round-trips and that tampering, the
+// wrong key and the time window are all rejected.
+func TestSignVerifyToken(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	id := "20260212T091530.0042Z-node01-a3f91c"
+	tok, err := SignToken(id, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(tok, id+".") {
+		t.Fatalf("token %s does not start with the ID", tok)
+	}
+	window := StrictOptions{MaxAge: time.Minute, Now: time.Date(2026, 2, 12, 9, 16, 0, 0, time.UTC)}
+	if got, err := VerifyToken(tok, pub, window); err != nil || got != id {
+		t.Fatalf("VerifyToken = %q, %v", got, err)
+	}
+	tampered := strings.Replace(tok, "0042Z", "0043Z", 1)
+	if _, err := VerifyToken(tampered, pub, window); !errors.Is(err, ErrTokenSignature) {
+		t.Fatalf("tampered token = %v", err)
+	}
+	if _, err := VerifyToken(tok, otherPub, window); !errors.Is(err, ErrTokenSignature) {
+		t.Fatalf("wrong key = %v", err)
+	}
+	window.Now = window.Now.Add(time.Hour)
+	if _, err := VerifyToken(tok, pub, window); !errors.Is(err, ErrTooOld) {
+		t.Fatalf("stale token = %v", err)
+	}
+	if _, err := VerifyToken(id, pub, window); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("unsigned ID = %v", err)
+	}
+	ttlTok, _ := SignToken("20260212T091530.0042Z-a3f91c~30", priv)
+	if _, err := VerifyToken(ttlTok, pub, StrictOptions{Now: time.Date(2026, 2, 12, 9, 16, 1, 0, time.UTC)}); !errors.Is(err, ErrTooOld) {
+		t.Fatalf("expired TTL token = %v", err)
+	}
+	if _, err := SignToken("not-an-id", priv); err == nil {
+		t.Fatal("SignToken accepted a non-ID")
+	}
+}
@@ -0,0 +1,146 @@
+package wid
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TracestateKey is the W3C tracestate list-member key under which a WID is carried.
+const TracestateKey = "wid"
+
+// Maximum tracestate list members allowed by W3C Trace Context.
+const maxTracestateMembers = 32
+
+var ErrInvalidTracestateValue = errors.New("WID cannot be carried as a tracestate value")
+
+type widContextKey struct{}
+
+func traceDigest(id string) []byte {
+	sum := sha256.Sum256([]byte("wid-trace-v1:" + id))
+	return sum[:]
+}
+
+// TraceID derives a deterministic 32-hex W3C trace-id from a WID.
+func TraceID(id string) string {
+	d := traceDigest(id)
+	b := d[:16]
+	if allZero(b) {
+		// the all-zero trace-id is invalid per W3C Trace Context
+		b[15] = 1
+	}
+	return hex.EncodeToString(b)
+}
+
+// SpanID derives a deterministic 16-hex W3C parent-id from a WID.
+func SpanID(id string) string {
+	d := traceDigest(id)
+	b := d[16:24]
+	if allZero(b) {
+		b[7] = 1
+	}
+	return hex.EncodeToString(b)
+}
+
+// Traceparent builds a sampled version-00 traceparent header derived from a WID.
+func Traceparent(id string) string {
+	return fmt.Sprintf("00-%s-%s-01", TraceID(id), SpanID(id))
+}
+
+func allZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func validTracestateValue(v string) bool {
+	if v == "" || len(v) > 256 || v[len(v)-1] == ' ' {
+		return false
+	}
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		if c < 0x20 || c > 0x7e || c == ',' || c == '=' {
+			return false
+		}
+	}
+	return true
+}
+
+// TracestateWithWid returns tracestate with the WID placed as the left-most
+// `wid=` member, replacing any previous WID entry and trimming to 32 members.
+func TracestateWithWid(tracestate, id string) (string, error) {
+	if !validTracestateValue(id) {
+		return "", ErrInvalidTracestateValue
+	}
+	members := []string{TracestateKey + "=" + id}
+	for _, m := range strings.Split(tracestate, ",") {
+		m = strings.TrimSpace(m)
+		if m == "" || strings.HasPrefix(m, TracestateKey+"=") {
+			continue
+		}
+		members = append(members, m)
+	}
+	if len(members) > maxTracestateMembers {
+		members = members[:maxTracestateMembers]
+	}
+	return strings.Join(members, ","), nil
+}
+
+// WidFromTracestate extracts the WID carried in a tracestate header, if any.
+func WidFromTracestate(tracestate string) (string, bool) {
+	for _, m := range strings.Split(tracestate, ",") {
+		m = strings.TrimSpace(m)
+		if v, ok := strings.CutPrefix(m, TracestateKey+"="); ok && v != "" {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// ContextWithWid returns a child context carrying the request WID.
+func ContextWithWid(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, widContextKey{}, id)
+}
+
+// WidFromContext returns the request WID stored by ContextWithWid or TraceMiddleware.
+func WidFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(widContextKey{}).(string)
+	return id, ok && id != ""
+}
+
+// TraceMiddleware assigns every request a WID and links it to the W3C trace
+// context. A WID already present in the incoming tracestate is reused when it
+// parses (see ParseAny); otherwise, as for a missing or forged one, next()
+// mints one, so clients cannot inject arbitrary text into X-Wid or the logs.
+// Requests without a traceparent get one derived from the WID, so the trace-id
+// can be recomputed from the ID alone. The WID is stored in the request context
+// and echoed in the X-Wid response header.
+func TraceMiddleware(next func() string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := r.Header.Get("tracestate")
+		id, ok := WidFromTracestate(state)
+		if ok {
+			_, _, _, err := ParseAny(id)
+			ok = err == nil
+		}
+		if !ok {
+			id = next()
+		}
+		r = r.Clone(ContextWithWid(r.Context(), id))
+		if r.Header.Get("traceparent") == "" {
+			r.Header.Set("traceparent", Traceparent(id))
+		}
+		if ts, err := TracestateWithWid(state, id); err == nil {
+			r.Header.Set("tracestate", ts)
+		}
+		w.Header().Set("X-Wid", id)
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,72 @@
+package wid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestTraceparentDeterministic checks the trace context derived from a WID is stable and well-formed.
+func TestTraceparentDeterministic(t *testing.T) {
+	id := "20260212T091530.0042Z-a3f91c"
+	tp := Traceparent(id)
+	if tp != Traceparent(id) {
+		t.Fatal("traceparent must be deterministic")
+	}
+	parts := strings.Split(tp, "-")
+	if len(parts) != 4 || parts[0] != "00" || len(parts[1]) != 32 || len(parts[2]) != 16 || parts[3] != "01" {
+		t.Fatalf("malformed traceparent %q", tp)
+	}
+	if parts[1] != TraceID(id) || TraceID(id) == TraceID("20260212T091530.0043Z-a3f91c") {
+		t.Fatalf("trace-id not derived from WID: %q", tp)
+	}
+}
+
+// TestTracestateRoundTrip ensures the WID member is placed first and can be read back.
+func TestTracestateRoundTrip(t *testing.T) {
+	ts, err := TracestateWithWid("vendor=abc,wid=old", "20260212T091530.0000Z-node01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts != "wid=20260212T091530.0000Z-node01,vendor=abc" {
+		t.Fatalf("tracestate = %q", ts)
+	}
+	id, ok := WidFromTracestate(ts)
+	if !ok || id != "20260212T091530.0000Z-node01" {
+		t.Fatalf("WidFromTracestate = %q, %v", id, ok)
+	}
+	if _, err := TracestateWithWid("", "bad,value"); err != ErrInvalidTracestateValue {
+		t.Fatalf("expected ErrInvalidTracestateValue, got %v", err)
+	}
+}
+
+// TestTraceMiddleware verifies requests are tagged with a WID and derived trace headers.
+func TestTraceMiddleware(t *testing.T) {
+	id := "20260212T091530.0000Z"
+	var seen, parent string
+	h := TraceMiddleware(func() string { return id }, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = WidFromContext(r.Context())
+		parent = r.Header.Get("traceparent")
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if seen != id || rec.Header().Get("X-Wid") != id {
+		t.Fatalf("context/header WID mismatch: %q %q", seen, rec.Header().Get("X-Wid"))
+	}
+	if parent != Traceparent(id) {
+		t.Fatalf("traceparent = %q", parent)
+	}
+	for state, want := range map[string]string{
+		"wid=20260212T091530.0042Z-a3f91c": "20260212T091530.0042Z-a3f91c",
+		"wid=<script>,vendor=1":            id,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("tracestate", state)
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if got := rec.Header().Get("X-Wid"); got != want {
+			t.Fatalf("tracestate %q: X-Wid = %q, want %q", state, got, want)
+		}
+	}
+}
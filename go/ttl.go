@@ -0,0 +1,112 @@
+package wid
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TTLSeparator introduces the optional expiry extension appended to a WID or
+// HLC-WID: `<id>~<seconds>`. The ID expires <seconds> after its own timestamp.
+// '~' is outside the WID alphabet, so the base ID is recovered unambiguously.
+const TTLSeparator = "~"
+
+// ErrInvalidTTL is returned for a non-positive or malformed TTL.
+var ErrInvalidTTL = errors.New("TTL must be a positive number of seconds")
+
+// AppendTTL attaches an expiry offset to id. The TTL is stored in whole
+// seconds, rounded up.
+func AppendTTL(id string, ttl time.Duration) (string, error) {
+	if ttl <= 0 {
+		return "", ErrInvalidTTL
+	}
+	secs := int64((ttl + time.Second - 1) / time.Second)
+	return id + TTLSeparator + strconv.FormatInt(secs, 10), nil
+}
+
+// SplitTTL separates the base ID from its TTL extension. ok is false when id
+// has no extension; err is set when the extension is present but malformed.
+func SplitTTL(id string) (base string, ttl time.Duration, ok bool, err error) {
+	base, raw, found := strings.Cut(id, TTLSeparator)
+	if !found {
+		return id, 0, false, nil
+	}
+	secs, perr := strconv.ParseInt(raw, 10, 64)
+	if perr != nil || secs <= 0 || raw[0] == '+' {
+		return base, 0, true, ErrInvalidTTL
+	}
+	return base, time.Duration(secs) * time.Second, true, nil
+}
+
+// ExpiresAt returns the instant id stops being valid. ok is false for IDs
+// without a TTL extension, which never expire.
+func ExpiresAt(id string) (t time.Time, ok bool, err error) {
+	base, ttl, ok, err := SplitTTL(id)
+	if !ok || err != nil {
+		return time.Time{}, ok, err
+	}
+	ts, err := timestampOf(base)
+	if err != nil {
+		return time.Time{}, true, err
+	}
+	return ts.Add(ttl), true, nil
+}
+
+// IsExpired reports whether a TTL-bearing ID has expired. IDs without a TTL
+// never expire; IDs whose TTL or timestamp cannot be read are treated as
+// expired so callers fail closed.
+func IsExpired(id string) bool {
+	return IsExpiredAt(id, time.Now())
+}
+
+// IsExpiredAt is IsExpired evaluated at now.
+func IsExpiredAt(id string, now time.Time) bool {
+	exp, ok, err := ExpiresAt(id)
+	if err != nil {
+		return true
+	}
+	return ok && !now.Before(exp)
+}
+
+// ValidateWidWithTTL validates the base ID as a WID and rejects it once its
+// TTL has passed at now. IDs without a TTL are validated as plain WIDs.
+func ValidateWidWithTTL(id string, w, z int, unit TimeUnit, now time.Time) bool {
+	base, _, _, err := SplitTTL(id)
+	return err == nil && ValidateWidWithUnit(base, w, z, unit) && !IsExpiredAt(id, now)
+}
+
+// ValidateHlcWidWithTTL is ValidateWidWithTTL for HLC-WIDs.
+func ValidateHlcWidWithTTL(id string, w, z int, unit TimeUnit, now time.Time) bool {
+	base, _, _, err := SplitTTL(id)
+	return err == nil && ValidateHlcWidWithUnit(base, w, z, unit) && !IsExpiredAt(id, now)
+}
+
+// NextWithTTL generates a WID that expires ttl after its timestamp.
+func (g *WidGen) NextWithTTL(ttl time.Duration) (string, error) {
+	return AppendTTL(g.Next(), ttl)
+}
+
+// NextWithTTL generates an HLC-WID that expires ttl after its timestamp.
+func (g *HLCWidGen) NextWithTTL(ttl time.Duration) (string, error) {
+	return AppendTTL(g.Next(), ttl)
+}
+
+// timestampOf reads the timestamp of a WID or HLC-WID, inferring the time unit
+// from the number of time digits (6 for sec, 9 for ms).
+func timestampOf(id string) (time.Time, error) {
+	dot := strings.IndexByte(id, '.')
+	if len(id) < 9 || id[8] != 'T' || dot < 9 {
+		return time.Time{}, ErrInvalidFormat
+	}
+	unit := TimeUnitSec
+	if dot-9 == timeDigits(TimeUnitMs) {
+		unit = TimeUnitMs
+	}
+	for _, c := range id[:8] + id[9:dot] {
+		if c < '0' || c > '9' {
+			return time.Time{}, ErrInvalidTimestamp
+		}
+	}
+	return parseCalendar(id[:8], id[9:dot], unit)
+}
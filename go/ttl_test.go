@@ -0,0 +1,48 @@
+package wid
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTTLRoundTrip checks the TTL extension is appended, split and evaluated against the ID's own timestamp.
+func TestTTLRoundTrip(t *testing.T) {
+	id, err := AppendTTL("20260212T091530.0042Z-a3f91c", 90*time.Second+time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "20260212T091530.0042Z-a3f91c~91" {
+		t.Fatalf("AppendTTL = %q", id)
+	}
+	base, ttl, ok, err := SplitTTL(id)
+	if err != nil || !ok || base != "20260212T091530.0042Z-a3f91c" || ttl != 91*time.Second {
+		t.Fatalf("SplitTTL = %q %v %v %v", base, ttl, ok, err)
+	}
+	issued := time.Date(2026, 2, 12, 9, 15, 30, 0, time.UTC)
+	if IsExpiredAt(id, issued.Add(90*time.Second)) || !IsExpiredAt(id, issued.Add(91*time.Second)) {
+		t.Fatal("expiry boundary wrong")
+	}
+	if !ValidateWidWithTTL(id, 4, 6, TimeUnitSec, issued) || ValidateWidWithTTL(id, 4, 6, TimeUnitSec, issued.Add(time.Hour)) {
+		t.Fatal("ValidateWidWithTTL must honour expiry")
+	}
+	if IsExpired("20260212T091530.0042Z") {
+		t.Fatal("IDs without TTL never expire")
+	}
+}
+
+// TestTTLMalformed ensures malformed TTLs fail closed.
+func TestTTLMalformed(t *testing.T) {
+	for _, id := range []string{"20260212T091530.0042Z~0", "20260212T091530.0042Z~x", "20260212T091530.0042Z~+5", "garbage~10"} {
+		if !IsExpiredAt(id, time.Unix(0, 0)) {
+			t.Fatalf("%q should be treated as expired", id)
+		}
+	}
+	if _, err := AppendTTL("x", 0); err != ErrInvalidTTL {
+		t.Fatalf("expected ErrInvalidTTL, got %v", err)
+	}
+	g, _ := NewHLCWidGenWithUnit("node01", 4, 0, TimeUnitMs)
+	id, _ := g.NextWithTTL(time.Minute)
+	if !ValidateHlcWidWithTTL(id, 4, 0, TimeUnitMs, time.Now()) {
+		t.Fatalf("fresh ms HLC-WID %q should be valid", id)
+	}
+}
@@ -0,0 +1,133 @@
+package wid
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrSequenceWidth     = errors.New("sequence has the wrong number of digits")
+	ErrWrongTimeUnit     = errors.New("timestamp has the other time unit's digit count")
+	ErrPaddingCharset    = errors.New("padding must be lowercase hex")
+	ErrPaddingLength     = errors.New("padding has the wrong length")
+	ErrUnexpectedPadding = errors.New("padding present but Z is 0")
+)
+
+// ValidationError says which part of an ID failed validation and where.
+// Err is one of the package's sentinel errors, so callers can branch with
+// errors.Is and still print the position for humans.
+type ValidationError struct {
+	Field  string // date, time, sequence, node, padding, timestamp or format
+	Offset int    // byte offset of the offending part
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s at offset %d: %v", e.Field, e.Offset, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+// ValidateWidDetailed is ValidateWidWithUnit returning why an ID failed: nil
+// for a valid ID, the parameter error for bad W/Z/unit, and otherwise a
+// *ValidationError.
+func ValidateWidDetailed(id string, w, z int, unit TimeUnit) error {
+	if err := checkParams(w, z, unit); err != nil {
+		return err
+	}
+	if _, err := ParseWidFast(id, w, z, unit); err == nil {
+		return nil
+	}
+	return diagnose(id, w, z, unit, false)
+}
+
+// ValidateHlcWidDetailed is the HLC-WID counterpart of ValidateWidDetailed.
+func ValidateHlcWidDetailed(id string, w, z int, unit TimeUnit) error {
+	if err := checkParams(w, z, unit); err != nil {
+		return err
+	}
+	if _, err := ParseHlcWidFast(id, w, z, unit); err == nil {
+		return nil
+	}
+	return diagnose(id, w, z, unit, true)
+}
+
+// diagnose walks an ID that failed to parse field by field and reports the
+// first problem, in the order the parsers check them.
+func diagnose(id string, w, z int, unit TimeUnit, hlc bool) error {
+	fail := func(field string, off int, err error) error {
+		return &ValidationError{Field: field, Offset: off, Err: err}
+	}
+	digits := func(from int) int {
+		n := 0
+		for from+n < len(id) && id[from+n] >= '0' && id[from+n] <= '9' {
+			n++
+		}
+		return n
+	}
+	if digits(0) != 8 {
+		return fail("date", 0, ErrInvalidFormat)
+	}
+	if len(id) < 9 || id[8] != 'T' {
+		return fail("format", 8, ErrInvalidFormat)
+	}
+	td := timeDigits(unit)
+	if n := digits(9); n != td {
+		other := TimeUnitMs
+		if unit == TimeUnitMs {
+			other = TimeUnitSec
+		}
+		if n == timeDigits(other) {
+			return fail("time", 9, ErrWrongTimeUnit)
+		}
+		return fail("time", 9, ErrInvalidFormat)
+	}
+	pos := 9 + td
+	if pos >= len(id) || id[pos] != '.' {
+		return fail("format", pos, ErrInvalidFormat)
+	}
+	pos++
+	if digits(pos) != w {
+		return fail("sequence", pos, ErrSequenceWidth)
+	}
+	pos += w
+	if pos >= len(id) || id[pos] != 'Z' {
+		return fail("format", pos, ErrInvalidFormat)
+	}
+	pos++
+	if hlc {
+		if pos >= len(id) || id[pos] != '-' {
+			return fail("node", pos, ErrInvalidFormat)
+		}
+		pos++
+		end := pos
+		for end < len(id) && !isNodeStop(id[end]) {
+			end++
+		}
+		if end == pos {
+			return fail("node", pos, ErrInvalidNode)
+		}
+		pos = end
+	}
+	if _, err := parseCalendar(id[:8], id[9:9+td], unit); err != nil {
+		return fail("timestamp", 0, err)
+	}
+	if pos == len(id) {
+		return fail("format", pos, ErrInvalidFormat)
+	}
+	if id[pos] != '-' {
+		return fail("padding", pos, ErrInvalidFormat)
+	}
+	if z == 0 {
+		return fail("padding", pos, ErrUnexpectedPadding)
+	}
+	for i := pos + 1; i < len(id); i++ {
+		if c := id[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return fail("padding", i, ErrPaddingCharset)
+		}
+	}
+	if len(id)-pos-1 != z {
+		return fail("padding", pos+1, ErrPaddingLength)
+	}
+	return fail("format", 0, ErrInvalidFormat)
+}
@@ -0,0 +1,53 @@
+package wid
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestValidateWidDetailed checks each failure class maps to its sentinel.
+func TestValidateWidDetailed(t *testing.T) {
+	cases := []struct {
+		id   string
+		unit TimeUnit
+		hlc  bool
+		want error
+	}{
+		{"20261016T120000.0001Z-abcdef", TimeUnitSec, false, nil},
+		{"20261016T120000123.0001Z", TimeUnitMs, false, nil},
+		{"20261016T120000.0001Z-node1-abcdef", TimeUnitSec, true, nil},
+		{"20261016T120000.0001Z", TimeUnitMs, false, ErrWrongTimeUnit},
+		{"20261016T120000123.0001Z", TimeUnitSec, false, ErrWrongTimeUnit},
+		{"20261016T120000.001Z", TimeUnitSec, false, ErrSequenceWidth},
+		{"20261301T120000.0001Z", TimeUnitSec, false, ErrInvalidTimestamp},
+		{"20260230T120000.0001Z", TimeUnitSec, false, ErrInvalidTimestamp},
+		{"20261016T120000.0001Z-ABCDEF", TimeUnitSec, false, ErrPaddingCharset},
+		{"20261016T120000.0001Z-abcd", TimeUnitSec, false, ErrPaddingLength},
+		{"20261016T120000.0001z", TimeUnitSec, false, ErrInvalidFormat},
+		{"20261016T120000.0001Z-", TimeUnitSec, true, ErrInvalidNode},
+	}
+	for _, c := range cases {
+		var err error
+		if c.hlc {
+			err = ValidateHlcWidDetailed(c.id, 4, 6, c.unit)
+		} else {
+			err = ValidateWidDetailed(c.id, 4, 6, c.unit)
+		}
+		if !errors.Is(err, c.want) || (c.want == nil) != (err == nil) {
+			t.Errorf("%s (%s): got %v, want %v", c.id, c.unit, err, c.want)
+		}
+		var ve *ValidationError
+		if err != nil && !errors.As(err, &ve) {
+			t.Errorf("%s: %v is not a *ValidationError", c.id, err)
+		}
+	}
+	if err := ValidateWidDetailed("x", 4, 0, TimeUnitSec); err == nil {
+		t.Fatal("expected an error for garbage input")
+	}
+	if err := ValidateWidDetailed("20261016T120000.0001Z-ab", 4, 0, TimeUnitSec); !errors.Is(err, ErrUnexpectedPadding) {
+		t.Fatalf("Z=0 with padding: %v", err)
+	}
+	if err := ValidateWidDetailed("20261016T120000.0001Z", 0, 0, TimeUnitSec); err != ErrInvalidW {
+		t.Fatalf("W=0: %v", err)
+	}
+}
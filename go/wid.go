@@ -31,6 +31,8 @@ var (
 	ErrInvalidRemoteClock  = errors.New("remote clock values must be non-negative")
 	ErrInvalidTimeUnit     = errors.New("time-unit must be sec or ms")
 	ErrInvalidTimeUnitText = errors.New("invalid time-unit")
+	ErrInvalidBatchSize    = errors.New("batch size must be non-negative")
+	ErrSequenceOverflow    = errors.New("batch does not fit in the current tick's remaining sequence")
 )
 
 // TimeUnit enumerates the supported time-precision modes for WID and HLC helpers.
@@ -69,11 +71,27 @@ func nowTick(unit TimeUnit) int64 {
 
 func formatTS(tick int64, unit TimeUnit) string {
 	if unit == TimeUnitMs {
-		return time.UnixMilli(tick).UTC().Format("20060102T150405000")
+		t := time.UnixMilli(tick).UTC()
+		// Go layouts have no fractional-second form without a separator, so
+		// the milliseconds are appended by hand.
+		return t.Format("20060102T150405") + fmt.Sprintf("%03d", t.Nanosecond()/int(time.Millisecond))
 	}
 	return time.Unix(tick, 0).UTC().Format("20060102T150405")
 }
 
+// formatID lays out an ID from its parts; an empty node or padding is left
+// out.
+func formatID(tick int64, seq, w int, unit TimeUnit, node, padding string) string {
+	id := fmt.Sprintf("%s.%0*dZ", formatTS(tick, unit), w, seq)
+	if node != "" {
+		id += "-" + node
+	}
+	if padding != "" {
+		id += "-" + padding
+	}
+	return id
+}
+
 func randomHex(z int) string {
 	if z <= 0 {
 		return ""
@@ -89,6 +107,19 @@ func randomHex(z int) string {
 	return hex.EncodeToString(b)[:z]
 }
 
+// padding returns a fresh padding segment: the fixed prefix, if any, then
+// random hex up to Z characters.
+func (g *WidGen) padding() string {
+	if g.padOf != nil {
+		return "" // format derives it from the ID body
+	}
+	n := g.Z - len(g.padPrefix)
+	if g.randHex != nil {
+		return g.padPrefix + g.randHex(n)
+	}
+	return g.padPrefix + randomHex(n)
+}
+
 func isValidNode(node string) bool {
 	if node == "" {
 		return false
@@ -145,6 +176,10 @@ type ParsedWid struct {
 	Sequence    int
 	Padding     *string
 	Millisecond int
+	// Namespace is the issuing domain ParseNamespacedWid found, or "".
+	Namespace string
+	// Shard is the shard of a NewShardedWidGen ID, set by ParseShardedWid.
+	Shard int
 }
 
 // ParsedHlcWid captures fields produced by parsing an HLC-WID.
@@ -322,7 +357,31 @@ type WidGen struct {
 	maxSeq   int
 	lastTick int64
 	lastSeq  int
-	mu       sync.Mutex
+	// padPrefix is a fixed leading part of the padding (see NewTenantWidGen).
+	padPrefix string
+	// padOf derives the padding from the "<timestamp>.<sequence>Z" body
+	// (see NewAuthenticatedWidGen and NewMetadataWidGen).
+	padOf func(body string) string
+	// nsSuffix is "_<namespace>" for NewWidGenWithNamespace, else "".
+	nsSuffix string
+	// shard/shardWidth are the digits NewShardedWidGen puts after the sequence.
+	shard, shardWidth int
+	// epoch is Config.Epoch in ticks, subtracted from the clock (see epoch.go).
+	epoch int64
+	// clock and randHex replace the wall clock and randomHex in
+	// NewDeterministicWidGen generators.
+	clock   func() int64
+	randHex func(n int) string
+	// contentTick/contentIDs cache NextForContent results for the current tick.
+	contentTick int64
+	contentIDs  map[string]string
+	// lastNow/tolerance back NextE's clock-regression check (see clock.go).
+	lastNow   int64
+	tolerance time.Duration
+	checksum  bool
+	rollover  RolloverPolicy
+	stats     GenStats
+	mu        sync.Mutex
 }
 
 // NewWidGen creates a generator in seconds precision with W/Z defaults.
@@ -341,13 +400,35 @@ func NewWidGenWithUnit(w, z int, unit TimeUnit) (*WidGen, error) {
 	if unit != TimeUnitSec && unit != TimeUnitMs {
 		return nil, ErrInvalidTimeUnit
 	}
-	return &WidGen{W: w, Z: z, TimeUnit: unit, maxSeq: pow10(w) - 1, lastSeq: -1}, nil
+	return &WidGen{W: w, Z: z, TimeUnit: unit, maxSeq: pow10(w) - 1, lastSeq: -1, stats: GenStats{Capacity: pow10(w)}}, nil
 }
 
 func (g *WidGen) Next() string {
 	g.mu.Lock()
 	defer g.mu.Unlock()
-	now := nowTick(g.TimeUnit)
+	return g.nextLocked(g.padding())
+}
+
+// nextLocked advances the sequence and formats an ID with the given padding.
+// The caller must hold g.mu.
+func (g *WidGen) nextLocked(padding string) string {
+	return g.nextAtLocked(g.now(), padding)
+}
+
+// nextAtLocked is nextLocked for a clock reading taken by the caller.
+// ReturnError cannot be reported here, so it waits like BlockUntilNextTick.
+func (g *WidGen) nextAtLocked(now int64, padding string) string {
+	id, _ := g.issueLocked(now, padding, false)
+	return id
+}
+
+// issueLocked advances the sequence, applying the rollover policy when it is
+// exhausted; with canFail, ReturnError yields ErrSequenceExhausted.
+func (g *WidGen) issueLocked(now int64, padding string, canFail bool) (string, error) {
+	if now < g.lastNow {
+		g.stats.ClockRegressions++
+	}
+	g.lastNow = now
 	tick := now
 	if tick <= g.lastTick {
 		tick = g.lastTick
@@ -357,27 +438,70 @@ func (g *WidGen) Next() string {
 		seq = g.lastSeq + 1
 	}
 	if seq > g.maxSeq {
-		tick++
+		g.stats.Rollovers++
+		switch {
+		case g.rollover == BorrowFutureTick:
+			tick++
+		case g.rollover == ReturnError && canFail:
+			return "", ErrSequenceExhausted
+		default:
+			tick = g.waitPastLocked(tick)
+		}
 		seq = 0
 	}
 	g.lastTick = tick
 	g.lastSeq = seq
-	ts := formatTS(tick, g.TimeUnit)
-	seqStr := fmt.Sprintf("%0*d", g.W, seq)
-	if g.Z > 0 {
-		return fmt.Sprintf("%s.%sZ-%s", ts, seqStr, randomHex(g.Z))
+	g.stats.note(seq)
+	return g.format(tick, seq, padding), nil
+}
+
+// format lays out an issued ID with the generator's shard digits, namespace
+// and check character.
+func (g *WidGen) format(tick int64, seq int, padding string) string {
+	if g.shardWidth > 0 {
+		seq = seq*pow10(g.shardWidth) + g.shard
 	}
-	return fmt.Sprintf("%s.%sZ", ts, seqStr)
+	if g.padOf != nil {
+		padding = g.padOf(formatID(tick, seq, g.W+g.shardWidth, g.TimeUnit, "", ""))
+	}
+	return g.withChecksum(formatID(tick, seq, g.W+g.shardWidth, g.TimeUnit, "", padding) + g.nsSuffix)
 }
 
+// NextN issues n IDs under a single lock acquisition. Like Next, it follows
+// the rollover policy when the sequence runs out.
 func (g *WidGen) NextN(n int) []string {
 	out := make([]string, n)
+	g.mu.Lock()
+	defer g.mu.Unlock()
 	for i := range out {
-		out[i] = g.Next()
+		out[i] = g.nextLocked(g.padding())
 	}
 	return out
 }
 
+// NextBatch is NextN without borrowing: it issues all n IDs within the
+// current tick, or none and ErrSequenceOverflow if they do not fit. Callers
+// that need more than one tick's worth split the batch.
+func (g *WidGen) NextBatch(n int) ([]string, error) {
+	if n < 0 {
+		return nil, ErrInvalidBatchSize
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	avail := g.maxSeq - g.lastSeq
+	if g.now() > g.lastTick {
+		avail = g.maxSeq + 1
+	}
+	if n > avail {
+		return nil, ErrSequenceOverflow
+	}
+	out := make([]string, n)
+	for i := range out {
+		out[i] = g.nextLocked(g.padding())
+	}
+	return out, nil
+}
+
 func (g *WidGen) State() (int64, int) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -400,7 +524,13 @@ type HLCWidGen struct {
 	maxLC    int
 	pt       int64
 	lc       int
-	mu       sync.Mutex
+	// lastNow/tolerance back NextE's clock-regression check (see clock.go).
+	lastNow   int64
+	tolerance time.Duration
+	checksum  bool
+	epoch     int64
+	stats     GenStats
+	mu        sync.Mutex
 }
 
 // NewHLCWidGen creates an HLC generator that emits clock-synced IDs.
@@ -422,11 +552,12 @@ func NewHLCWidGenWithUnit(node string, w, z int, unit TimeUnit) (*HLCWidGen, err
 	if unit != TimeUnitSec && unit != TimeUnitMs {
 		return nil, ErrInvalidTimeUnit
 	}
-	return &HLCWidGen{W: w, Z: z, Node: node, TimeUnit: unit, maxLC: pow10(w) - 1}, nil
+	return &HLCWidGen{W: w, Z: z, Node: node, TimeUnit: unit, maxLC: pow10(w) - 1, stats: GenStats{Capacity: pow10(w)}}, nil
 }
 
 func (g *HLCWidGen) rollover() {
 	if g.lc > g.maxLC {
+		g.stats.Rollovers++
 		g.pt++
 		g.lc = 0
 	}
@@ -439,7 +570,13 @@ func (g *HLCWidGen) Observe(remotePT int64, remoteLC int) error {
 	}
 	g.mu.Lock()
 	defer g.mu.Unlock()
-	now := nowTick(g.TimeUnit)
+	g.observeLocked(remotePT, remoteLC)
+	return nil
+}
+
+// observeLocked is Observe's merge rule. The caller must hold g.mu.
+func (g *HLCWidGen) observeLocked(remotePT int64, remoteLC int) {
+	now := g.now()
 	newPT := now
 	if g.pt > newPT {
 		newPT = g.pt
@@ -463,14 +600,27 @@ func (g *HLCWidGen) Observe(remotePT int64, remoteLC int) error {
 	}
 	g.pt = newPT
 	g.rollover()
-	return nil
 }
 
 // Next generates the next HLC-WID string from the hybrid clock.
 func (g *HLCWidGen) Next() string {
 	g.mu.Lock()
 	defer g.mu.Unlock()
-	now := nowTick(g.TimeUnit)
+	return g.nextLocked()
+}
+
+// nextLocked advances the clock and formats an HLC-WID. The caller must hold
+// g.mu.
+func (g *HLCWidGen) nextLocked() string {
+	return g.nextAtLocked(g.now())
+}
+
+// nextAtLocked is nextLocked for a clock reading taken by the caller.
+func (g *HLCWidGen) nextAtLocked(now int64) string {
+	if now < g.lastNow {
+		g.stats.ClockRegressions++
+	}
+	g.lastNow = now
 	if now > g.pt {
 		g.pt = now
 		g.lc = 0
@@ -478,23 +628,44 @@ func (g *HLCWidGen) Next() string {
 		g.lc++
 	}
 	g.rollover()
-	ts := formatTS(g.pt, g.TimeUnit)
-	lcStr := fmt.Sprintf("%0*d", g.W, g.lc)
-	if g.Z > 0 {
-		return fmt.Sprintf("%s.%sZ-%s-%s", ts, lcStr, g.Node, randomHex(g.Z))
-	}
-	return fmt.Sprintf("%s.%sZ-%s", ts, lcStr, g.Node)
+	g.stats.note(g.lc)
+	return g.withChecksum(formatID(g.pt, g.lc, g.W, g.TimeUnit, g.Node, randomHex(g.Z)))
 }
 
-// NextN produces a batch of HLC-WIDs for `n` sequential ticks.
+// NextN produces a batch of HLC-WIDs for `n` sequential ticks under a
+// single lock acquisition.
 func (g *HLCWidGen) NextN(n int) []string {
 	out := make([]string, n)
+	g.mu.Lock()
+	defer g.mu.Unlock()
 	for i := range out {
-		out[i] = g.Next()
+		out[i] = g.nextLocked()
 	}
 	return out
 }
 
+// NextBatch issues n HLC-WIDs within the current physical tick, or none and
+// ErrSequenceOverflow when the logical counter cannot hold them.
+func (g *HLCWidGen) NextBatch(n int) ([]string, error) {
+	if n < 0 {
+		return nil, ErrInvalidBatchSize
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	avail := g.maxLC - g.lc
+	if g.now() > g.pt {
+		avail = g.maxLC + 1
+	}
+	if n > avail {
+		return nil, ErrSequenceOverflow
+	}
+	out := make([]string, n)
+	for i := range out {
+		out[i] = g.nextLocked()
+	}
+	return out, nil
+}
+
 // State reports the current physical and logical counter.
 func (g *HLCWidGen) State() (int64, int) {
 	g.mu.Lock()
@@ -1,6 +1,9 @@
 package wid
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 // TestWidGenMonotonic verifies generated WIDs stay strictly increasing.
 func TestWidGenMonotonic(t *testing.T) {
@@ -64,6 +67,15 @@ func TestWidMsValidateAndParse(t *testing.T) {
 	}
 }
 
+// TestFormatTSMilliseconds checks millisecond timestamps carry their
+// milliseconds rather than a literal 000.
+func TestFormatTSMilliseconds(t *testing.T) {
+	tick := time.Date(2026, 10, 16, 22, 13, 20, 123*int(time.Millisecond), time.UTC).UnixMilli()
+	if got := formatTS(tick, TimeUnitMs); got != "20261016T221320123" {
+		t.Fatalf("formatTS = %s, want 20261016T221320123", got)
+	}
+}
+
 // TestHlcWidValidateConformance exercises HLC validation acceptance and rejection cases.
 func TestHlcWidValidateConformance(t *testing.T) {
 	cases := []struct {
@@ -159,3 +171,28 @@ func TestInvalidParams(t *testing.T) {
 		t.Errorf("expected ErrInvalidNode, got %v", err)
 	}
 }
+
+// TestNextBatch checks batches are unique and sorted, and that NextBatch
+// refuses a batch larger than a tick's sequence space instead of borrowing.
+func TestNextBatch(t *testing.T) {
+	g, _ := NewWidGen(1, 0)
+	ids, err := g.NextBatch(10)
+	if err != nil || len(ids) != 10 {
+		t.Fatalf("NextBatch(10) at W=1: %d ids, %v", len(ids), err)
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("batch not increasing: %s then %s", ids[i-1], ids[i])
+		}
+	}
+	if _, err := g.NextBatch(11); err != ErrSequenceOverflow {
+		t.Fatalf("NextBatch(11) at W=1: %v", err)
+	}
+	h, _ := NewHLCWidGen("node01", 1, 0)
+	if _, err := h.NextBatch(-1); err != ErrInvalidBatchSize {
+		t.Fatalf("NextBatch(-1): %v", err)
+	}
+	if ids := h.NextN(25); ids[24] <= ids[0] {
+		t.Fatalf("HLC NextN not increasing: %v", ids)
+	}
+}